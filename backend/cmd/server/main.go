@@ -3,12 +3,17 @@ package main
 import (
 	"backend/internal/database"
 	"backend/internal/handlers"
+	"backend/internal/languages"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -29,6 +34,12 @@ func main() {
 		log.Println("✅ Static directory found")
 	}
 
+	// Контекст сервера отменяется по SIGINT/SIGTERM. Он является родительским для
+	// контекстов всех запросов и интерактивных сессий, поэтому при остановке
+	// запущенные программы убиваются
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Создаем экземпляр TaskHandler
 	taskHandler := handlers.NewTaskHandler(database.DB)
 
@@ -88,8 +99,12 @@ func main() {
 	// ОБНОВЛЕНО: Используем методы TaskHandler
 	http.HandleFunc("/api/tasks", loggingMiddleware(corsMiddleware(taskHandler.GetTasksHandler)))
 	http.HandleFunc("/api/check", loggingMiddleware(corsMiddleware(handlers.CheckHandler)))
+	http.HandleFunc("/api/submissions", loggingMiddleware(corsMiddleware(handlers.SubmissionsHandler)))
+	http.HandleFunc("/api/submissions/", loggingMiddleware(corsMiddleware(handlers.GetSubmissionHandler)))
 	http.HandleFunc("/api/ai/review", loggingMiddleware(corsMiddleware(handlers.AIReviewHandler)))
 	http.HandleFunc("/api/execute", loggingMiddleware(corsMiddleware(handlers.ExecuteHandler)))
+	http.HandleFunc("/api/execute/stream", loggingMiddleware(corsMiddleware(handlers.ExecuteStreamHandler)))
+	http.HandleFunc("/api/execute/interactive", loggingMiddleware(handlers.NewInteractiveRunHandler(ctx, getAllowedOrigins())))
 	http.HandleFunc("/api/auth/login", loggingMiddleware(corsMiddleware(handlers.LoginHandler)))
 	http.HandleFunc("/api/auth/register", loggingMiddleware(corsMiddleware(handlers.RegisterHandler)))
 	http.HandleFunc("/api/auth/guest", loggingMiddleware(corsMiddleware(handlers.GuestAuthHandler)))
@@ -112,6 +127,7 @@ func main() {
 
 	// Admin routes (только для преподавателей)
 	http.HandleFunc("/api/admin/statistics", loggingMiddleware(corsMiddleware(handlers.TeacherOnlyMiddleware(handlers.StatisticsHandler))))
+	http.HandleFunc("/api/teacher/submissions", loggingMiddleware(corsMiddleware(handlers.TeacherOnlyMiddleware(handlers.GetTeacherSubmissionsHandler))))
 
 	// ДОБАВЛЕНО: Роуты для управления задачами (учительская панель)
 	http.HandleFunc("/api/teacher/tasks", loggingMiddleware(corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
@@ -125,16 +141,26 @@ func main() {
 		}
 	})))
 
+	// Экспорт и импорт задач zip-пакетами
+	http.HandleFunc("/api/teacher/tasks/export", loggingMiddleware(corsMiddleware(taskHandler.ExportTasksHandler)))
+	http.HandleFunc("/api/teacher/tasks/import", loggingMiddleware(corsMiddleware(taskHandler.ImportTasksHandler)))
+
 	http.HandleFunc("/api/teacher/tasks/", loggingMiddleware(corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		// Извлекаем ID из URL
 		path := strings.TrimPrefix(r.URL.Path, "/api/teacher/tasks/")
-		id := path
+		id, route, _ := strings.Cut(path, "/")
 
 		// Добавляем ID в query параметры для хендлера
 		q := r.URL.Query()
 		q.Set("id", id)
 		r.URL.RawQuery = q.Encode()
 
+		// Версии сгенерированных тестов: /api/teacher/tasks/{id}/test-sets/...
+		if route != "" {
+			taskHandler.TestSetsHandler(w, r, route)
+			return
+		}
+
 		switch r.Method {
 		case "PUT":
 			taskHandler.UpdateTaskHandler(w, r)
@@ -154,14 +180,15 @@ func main() {
 			"api":         "ok",
 			"environment": getEnvironment(),
 			"timestamp":   time.Now().Format(time.RFC3339),
-			"compilers":   []string{"python", "node", "g++", "javac"},
+			"compilers":   languages.IDs(),
 		}
 
 		response := map[string]interface{}{
-			"status":  healthStatus,
-			"checks":  checks,
-			"version": "1.0.0",
-			"uptime":  time.Since(startTime).String(),
+			"status":    healthStatus,
+			"checks":    checks,
+			"version":   "1.0.0",
+			"uptime":    time.Since(startTime).String(),
+			"languages": languages.All(),
 		}
 
 		json.NewEncoder(w).Encode(response)
@@ -176,7 +203,8 @@ func main() {
 			"environment": getEnvironment(),
 			"port":        port,
 			"version":     "1.0.0",
-			"compilers":   []string{"python", "node", "g++", "javac"},
+			"compilers":   languages.IDs(),
+			"languages":   languages.All(),
 		}
 		json.NewEncoder(w).Encode(response)
 	})))
@@ -198,17 +226,12 @@ func main() {
 		taskId := parts[2]
 
 		// Валидация языка
-		supportedLanguages := map[string]bool{
-			"python":     true,
-			"javascript": true,
-			"cpp":        true,
-			"java":       true,
-		}
-
-		if !supportedLanguages[lang] {
-			http.Error(w, `{"error": "Unsupported language. Use: python, javascript, cpp, java"}`, http.StatusBadRequest)
+		language, ok := languages.Lookup(lang)
+		if !ok {
+			http.Error(w, fmt.Sprintf(`{"error": "Unsupported language. Use: %s"}`, strings.Join(languages.IDs(), ", ")), http.StatusBadRequest)
 			return
 		}
+		lang = language.ID
 
 		task := map[string]interface{}{
 			"id":          taskId,
@@ -217,7 +240,7 @@ func main() {
 			"language":    lang,
 			"topic":       topic,
 			"difficulty":  "beginner",
-			"defaultCode": getDefaultCode(lang),
+			"defaultCode": getDefaultCode(language),
 			"supported":   true,
 			"environment": getEnvironment(),
 		}
@@ -241,7 +264,7 @@ func main() {
 			"error":         "API endpoint not found",
 			"path":          r.URL.Path,
 			"timestamp":     time.Now().Format(time.RFC3339),
-			"documentation": "Available endpoints: /api/execute, /api/check, /api/task/:lang/:topic/:id, /api/tasks, /api/teacher/tasks",
+			"documentation": "Available endpoints: /api/execute, /api/execute/stream, /api/execute/interactive, /api/check, /api/task/:lang/:topic/:id, /api/tasks, /api/teacher/tasks",
 		})
 	})))
 
@@ -251,13 +274,30 @@ func main() {
 	log.Printf("   GET  /health")
 	log.Printf("   GET  /api/health")
 	log.Printf("   POST /api/execute")
+	log.Printf("   POST /api/execute/stream (SSE)")
+	log.Printf("   GET  /api/execute/interactive (WebSocket)")
 	log.Printf("   POST /api/check")
+	log.Printf("   POST /api/submissions")
+	log.Printf("   GET  /api/submissions?task_id= (history, auth)")
+	log.Printf("   GET  /api/submissions/:id")
 	log.Printf("   GET  /api/task/:lang/:topic/:id")
 	log.Printf("   GET  /api/tasks")
 	log.Printf("   GET  /api/teacher/tasks (for teachers)")
 	log.Printf("   POST /api/teacher/tasks (for teachers)")
 	log.Printf("   PUT  /api/teacher/tasks/:id (for teachers)")
 	log.Printf("   DELETE /api/teacher/tasks/:id (for teachers)")
+	log.Printf("   GET  /api/teacher/tasks/export?ids= (zip, for teachers)")
+	log.Printf("   POST /api/teacher/tasks/import (zip, for teachers)")
+	log.Printf("   GET  /api/teacher/submissions (for teachers)")
+
+	// Воркеры очереди проверки решений
+	waitSubmissionWorkers := handlers.StartSubmissionWorkers(ctx)
+
+	// Начальные задачи из пакета: тесты проверяются эталонными решениями,
+	// поэтому загрузка идет в фоне и не задерживает старт сервера
+	if path := os.Getenv("TASKS_SEED_PATH"); path != "" {
+		go taskHandler.SeedTasks(ctx, path)
+	}
 
 	// Запускаем сервер
 	server := &http.Server{
@@ -265,9 +305,29 @@ func main() {
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
 	}
 
-	log.Fatal(server.ListenAndServe())
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("🛑 Shutting down server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️ Server shutdown error: %v", err)
+	}
+	// Прерванные проверки возвращаются в очередь
+	waitSubmissionWorkers()
+	handlers.CleanupExecutor()
+	log.Printf("✅ Server stopped")
 }
 
 var startTime = time.Now()
@@ -318,29 +378,9 @@ func getAllowedOrigins() []string {
 	}
 }
 
-func getDefaultCode(lang string) string {
-	switch lang {
-	case "python":
-		return "# Write your Python code here\nprint(\"Hello World\")"
-	case "javascript":
-		return "// Write your JavaScript code here\nconsole.log(\"Hello World\")"
-	case "cpp":
-		return `// Write your C++ code here
-#include <iostream>
-using namespace std;
-
-int main() {
-    std::cout << "Hello World" << std::endl;
-    return 0;
-}`
-	case "java":
-		return `// Write your Java code here
-public class Main {
-    public static void main(String[] args) {
-        System.out.println("Hello World");
-    }
-}`
-	default:
-		return "// Write your code here"
+func getDefaultCode(lang *languages.Language) string {
+	if lang.Template != "" {
+		return lang.Template
 	}
+	return "// Write your code here"
 }
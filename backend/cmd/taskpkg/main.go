@@ -0,0 +1,141 @@
+// Команда taskpkg выгружает задачи преподавателя в пакет и загружает их из пакета.
+// Пакет - zip-архив или директория (например, в git-репозитории курса):
+//
+//	taskpkg export [-teacher 1] [-ids 1,2] tasks.zip|dir
+//	taskpkg import [-teacher 1] [-languages python,cpp] tasks.zip|dir
+//
+// Импорт принимает и пакеты задач Polygon и Kattis; -languages задает языки
+// их задач (по умолчанию - язык главного решения)
+//
+// Подключение к БД настраивается теми же переменными DB_*, что и у сервера
+package main
+
+import (
+	"backend/internal/database"
+	"backend/internal/handlers"
+	"backend/internal/taskpackage"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	teacherID := flags.Int("teacher", 1, "ID преподавателя - владельца задач")
+	ids := flags.String("ids", "", "ID задач для экспорта через запятую (по умолчанию все)")
+	langs := flags.String("languages", "", "Языки задач Polygon и Kattis через запятую")
+	flags.Parse(os.Args[2:])
+	if flags.NArg() != 1 {
+		usage()
+	}
+	path := flags.Arg(0)
+
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = exportTasks(*teacherID, *ids, path)
+	case "import":
+		err = importTasks(*teacherID, *langs, path)
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintln(os.Stderr, "  taskpkg export [-teacher 1] [-ids 1,2] <tasks.zip|dir>")
+	fmt.Fprintln(os.Stderr, "  taskpkg import [-teacher 1] [-languages python,cpp] <tasks.zip|dir>")
+	os.Exit(2)
+}
+
+func exportTasks(teacherID int, ids, path string) error {
+	database.Init()
+	defer database.Close()
+
+	var taskIDs []string
+	if ids != "" {
+		taskIDs = strings.Split(ids, ",")
+	}
+	tasks, err := handlers.NewTaskHandler(database.DB).ExportTasks(teacherID, taskIDs)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return fmt.Errorf("no tasks to export")
+	}
+
+	if strings.HasSuffix(path, ".zip") {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := taskpackage.Write(file, tasks); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	} else if err := taskpackage.WriteDir(path, tasks); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Exported %d tasks to %s\n", len(tasks), path)
+	return nil
+}
+
+func importTasks(teacherID int, langs, path string) error {
+	var options taskpackage.Options
+	if langs != "" {
+		options.Languages = strings.Split(langs, ",")
+	}
+
+	// Пакет читается до подключения к БД, чтобы ошибки в нем не ждали PostgreSQL
+	tasks, err := taskpackage.Open(path, options)
+	if err != nil {
+		return err
+	}
+
+	database.Init()
+	defer database.Close()
+	defer handlers.CleanupExecutor()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	results, err := handlers.NewTaskHandler(database.DB).ImportTasks(ctx, tasks, teacherID)
+	failed := 0
+	for _, result := range results {
+		switch {
+		case result.Error != "":
+			failed++
+			fmt.Printf("❌ %s (%s): %s\n", result.Title, result.Language, result.Error)
+		case !result.IsPublished:
+			fmt.Printf("⚠️ %s (%s): %s id=%s, not published\n", result.Title, result.Language, result.Action, result.ID)
+		default:
+			fmt.Printf("✅ %s (%s): %s id=%s\n", result.Title, result.Language, result.Action, result.ID)
+		}
+		for _, item := range result.Unmapped {
+			fmt.Printf("   ⚠️ %s\n", item)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("import interrupted: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tasks failed to import", failed, len(results))
+	}
+	return nil
+}
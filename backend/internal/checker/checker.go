@@ -0,0 +1,235 @@
+// Package checker сравнивает вывод решения с ожидаемым ответом теста.
+// Встроенные чекеры покрывают типичные случаи (точное совпадение, токены,
+// числа с погрешностью и т.д.), для остальных задач преподаватель может
+// загрузить собственную программу-чекер
+package checker
+
+import (
+	"backend/internal/executor"
+	"backend/internal/models"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultAbsEpsilon - погрешность float-чекера, если в задаче она не задана
+const defaultAbsEpsilon = 1e-6
+
+// Result - результат проверки ответа на одном тесте
+type Result struct {
+	Passed  bool
+	Message string // Пояснение к вердикту, например первое расхождение
+}
+
+// Checker проверяет вывод решения на тесте. Ошибка означает сбой самого
+// чекера, а не неверный ответ
+type Checker interface {
+	Check(ctx context.Context, input, expected, actual string) (Result, error)
+	Close() error
+}
+
+// Validate проверяет настройки чекера задачи. nil - чекер по умолчанию
+func Validate(config *models.Checker) error {
+	if config == nil {
+		return nil
+	}
+	switch config.Type {
+	case "", models.CheckerExact, models.CheckerWhitespace, models.CheckerTokens,
+		models.CheckerUnorderedLines, models.CheckerCaseInsensitive:
+	case models.CheckerFloat:
+		if config.AbsEpsilon < 0 || config.RelEpsilon < 0 ||
+			math.IsNaN(config.AbsEpsilon) || math.IsNaN(config.RelEpsilon) {
+			return fmt.Errorf("epsilon must be non-negative")
+		}
+	case models.CheckerCustom:
+		if strings.TrimSpace(config.Code) == "" {
+			return fmt.Errorf("custom checker code is required")
+		}
+		if config.Language == "" {
+			return fmt.Errorf("custom checker language is required")
+		}
+	default:
+		return fmt.Errorf("unknown checker type: %s", config.Type)
+	}
+	return nil
+}
+
+// New создает чекер по настройкам задачи. Пользовательский чекер компилируется
+// в ex один раз; после проверки всех тестов чекер нужно закрыть
+func New(ctx context.Context, config *models.Checker, ex executor.Executor) (Checker, error) {
+	if err := Validate(config); err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return builtinChecker(compareExact), nil
+	}
+
+	switch config.Type {
+	case models.CheckerWhitespace:
+		return builtinChecker(compareWhitespace), nil
+	case models.CheckerTokens:
+		return builtinChecker(compareTokens), nil
+	case models.CheckerFloat:
+		absEps, relEps := floatEpsilons(config)
+		return builtinChecker(func(expected, actual string) Result {
+			return compareFloats(expected, actual, absEps, relEps)
+		}), nil
+	case models.CheckerUnorderedLines:
+		return builtinChecker(compareUnorderedLines), nil
+	case models.CheckerCaseInsensitive:
+		return builtinChecker(compareCaseInsensitive), nil
+	case models.CheckerCustom:
+		return newCustomChecker(ctx, config, ex)
+	}
+	return builtinChecker(compareExact), nil
+}
+
+// floatEpsilons возвращает погрешности float-чекера с учетом значения по умолчанию
+func floatEpsilons(config *models.Checker) (absEps, relEps float64) {
+	absEps, relEps = config.AbsEpsilon, config.RelEpsilon
+	if absEps == 0 && relEps == 0 {
+		absEps = defaultAbsEpsilon
+	}
+	return absEps, relEps
+}
+
+// builtinChecker - чекер, сравнивающий ответы функцией без запуска программ
+type builtinChecker func(expected, actual string) Result
+
+// Check реализует Checker
+func (c builtinChecker) Check(_ context.Context, _, expected, actual string) (Result, error) {
+	return c(expected, actual), nil
+}
+
+// Close реализует Checker
+func (c builtinChecker) Close() error {
+	return nil
+}
+
+// compareExact сравнивает вывод целиком, игнорируя начальные и конечные
+// пробельные символы и различие \r\n и \n
+func compareExact(expected, actual string) Result {
+	return compareLines(trimmedLines(expected), trimmedLines(actual), func(e, a string) bool {
+		return e == a
+	})
+}
+
+// compareCaseInsensitive - compareExact без учета регистра
+func compareCaseInsensitive(expected, actual string) Result {
+	return compareLines(trimmedLines(expected), trimmedLines(actual), strings.EqualFold)
+}
+
+// compareWhitespace сравнивает построчно, считая любые последовательности
+// пробелов внутри строки одним пробелом
+func compareWhitespace(expected, actual string) Result {
+	return compareLines(collapsedLines(expected), collapsedLines(actual), func(e, a string) bool {
+		return e == a
+	})
+}
+
+// compareTokens сравнивает последовательности слов, разделенных любыми пробельными символами
+func compareTokens(expected, actual string) Result {
+	return compareTokenLists(strings.Fields(expected), strings.Fields(actual), func(e, a string) bool {
+		return e == a
+	})
+}
+
+// compareFloats сравнивает токены, числа - с абсолютной или относительной погрешностью
+func compareFloats(expected, actual string, absEps, relEps float64) Result {
+	return compareTokenLists(strings.Fields(expected), strings.Fields(actual), func(e, a string) bool {
+		if e == a {
+			return true
+		}
+		expectedValue, err := strconv.ParseFloat(e, 64)
+		if err != nil {
+			return false
+		}
+		actualValue, err := strconv.ParseFloat(a, 64)
+		if err != nil || math.IsNaN(actualValue) {
+			return false
+		}
+		diff := math.Abs(expectedValue - actualValue)
+		return diff <= absEps || diff <= relEps*math.Abs(expectedValue)
+	})
+}
+
+// compareUnorderedLines сравнивает наборы непустых строк без учета порядка
+func compareUnorderedLines(expected, actual string) Result {
+	expectedLines := nonEmptyLines(collapsedLines(expected))
+	actualLines := nonEmptyLines(collapsedLines(actual))
+	if len(expectedLines) != len(actualLines) {
+		return Result{Message: fmt.Sprintf("Ожидалось строк: %d, получено: %d", len(expectedLines), len(actualLines))}
+	}
+
+	sort.Strings(expectedLines)
+	sort.Strings(actualLines)
+	for i := range expectedLines {
+		if expectedLines[i] != actualLines[i] {
+			return Result{Message: fmt.Sprintf("Строка «%s» отсутствует в выводе", expectedLines[i])}
+		}
+	}
+	return Result{Passed: true}
+}
+
+// compareLines сравнивает списки строк функцией equal
+func compareLines(expected, actual []string, equal func(e, a string) bool) Result {
+	for i := 0; i < len(expected) && i < len(actual); i++ {
+		if !equal(expected[i], actual[i]) {
+			return Result{Message: fmt.Sprintf("Строка %d: ожидалось «%s», получено «%s»", i+1, expected[i], actual[i])}
+		}
+	}
+	if len(expected) != len(actual) {
+		return Result{Message: fmt.Sprintf("Ожидалось строк: %d, получено: %d", len(expected), len(actual))}
+	}
+	return Result{Passed: true}
+}
+
+// compareTokenLists сравнивает списки токенов функцией equal
+func compareTokenLists(expected, actual []string, equal func(e, a string) bool) Result {
+	for i := 0; i < len(expected) && i < len(actual); i++ {
+		if !equal(expected[i], actual[i]) {
+			return Result{Message: fmt.Sprintf("Токен %d: ожидалось «%s», получено «%s»", i+1, expected[i], actual[i])}
+		}
+	}
+	if len(expected) != len(actual) {
+		return Result{Message: fmt.Sprintf("Ожидалось токенов: %d, получено: %d", len(expected), len(actual))}
+	}
+	return Result{Passed: true}
+}
+
+// trimmedLines разбивает вывод без начальных и конечных пробельных символов на строки
+// и убирает пробелы в конце строк
+func trimmedLines(output string) []string {
+	output = strings.TrimSpace(strings.ReplaceAll(output, "\r\n", "\n"))
+	if output == "" {
+		return nil
+	}
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return lines
+}
+
+// collapsedLines - trimmedLines с заменой пробелов внутри строк на один пробел
+func collapsedLines(output string) []string {
+	lines := trimmedLines(output)
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return lines
+}
+
+// nonEmptyLines убирает пустые строки
+func nonEmptyLines(lines []string) []string {
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
@@ -0,0 +1,141 @@
+package checker
+
+import (
+	"backend/internal/models"
+	"context"
+	"testing"
+)
+
+func TestBuiltinCheckers(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *models.Checker
+		expected string
+		actual   string
+		passed   bool
+	}{
+		{"exact match", nil, "1 2\n3", "1 2\n3", true},
+		{"exact trailing whitespace", nil, "1 2\n3\n", "1 2   \r\n3\n\n", true},
+		{"exact leading newline", nil, "42", "\n42", true},
+		{"exact inner spaces differ", nil, "1 2", "1  2", false},
+		{"exact extra line", nil, "1", "1\n2", false},
+		{"exact empty", nil, "", "  \n", true},
+		{"whitespace collapses spaces", &models.Checker{Type: models.CheckerWhitespace}, "1 2\n3", "1 \t 2\n3  ", true},
+		{"whitespace keeps lines", &models.Checker{Type: models.CheckerWhitespace}, "1 2", "1\n2", false},
+		{"tokens ignore lines", &models.Checker{Type: models.CheckerTokens}, "1 2\n3", "1\n2 3\n", true},
+		{"tokens order", &models.Checker{Type: models.CheckerTokens}, "1 2", "2 1", false},
+		{"tokens missing", &models.Checker{Type: models.CheckerTokens}, "1 2 3", "1 2", false},
+		{"tokens case", &models.Checker{Type: models.CheckerTokens}, "Yes", "yes", false},
+		{"case insensitive", &models.Checker{Type: models.CheckerCaseInsensitive}, "YES\nNo", "yes\nno ", true},
+		{"case insensitive different", &models.Checker{Type: models.CheckerCaseInsensitive}, "yes", "no", false},
+		{"unordered lines", &models.Checker{Type: models.CheckerUnorderedLines}, "a b\nc\n", "c\n\na  b", true},
+		{"unordered lines duplicates", &models.Checker{Type: models.CheckerUnorderedLines}, "a\na\nb", "a\nb\nb", false},
+		{"float default epsilon", &models.Checker{Type: models.CheckerFloat}, "0.3333333", "0.33333335", true},
+		{"float default epsilon exceeded", &models.Checker{Type: models.CheckerFloat}, "0.333", "0.334", false},
+		{"float absolute", &models.Checker{Type: models.CheckerFloat, AbsEpsilon: 0.01}, "1.5 2", "1.509 1.995", true},
+		{"float absolute boundary", &models.Checker{Type: models.CheckerFloat, AbsEpsilon: 0.5}, "1", "1.5", true},
+		{"float absolute exceeded", &models.Checker{Type: models.CheckerFloat, AbsEpsilon: 0.01}, "1.5", "1.52", false},
+		{"float relative", &models.Checker{Type: models.CheckerFloat, RelEpsilon: 1e-3}, "1000000", "1000500", true},
+		{"float relative exceeded", &models.Checker{Type: models.CheckerFloat, RelEpsilon: 1e-3}, "1000000", "1002000", false},
+		{"float exponent notation", &models.Checker{Type: models.CheckerFloat}, "0.0001", "1e-4", true},
+		{"float words compared exactly", &models.Checker{Type: models.CheckerFloat}, "answer 1.0", "answer 1.0000001", true},
+		{"float words differ", &models.Checker{Type: models.CheckerFloat}, "yes 1", "no 1", false},
+		{"float NaN", &models.Checker{Type: models.CheckerFloat, AbsEpsilon: 1}, "1", "NaN", false},
+		{"float not a number", &models.Checker{Type: models.CheckerFloat, AbsEpsilon: 1}, "1", "one", false},
+		{"float token count", &models.Checker{Type: models.CheckerFloat}, "1 2", "1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(context.Background(), tt.config, nil)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			defer c.Close()
+
+			result, err := c.Check(context.Background(), "", tt.expected, tt.actual)
+			if err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if result.Passed != tt.passed {
+				t.Errorf("Passed = %v, want %v (message %q)", result.Passed, tt.passed, result.Message)
+			}
+			if !result.Passed && result.Message == "" {
+				t.Errorf("failed check without a message")
+			}
+		})
+	}
+}
+
+func TestCompareMessages(t *testing.T) {
+	tests := []struct {
+		name     string
+		compare  func(expected, actual string) Result
+		expected string
+		actual   string
+		message  string
+	}{
+		{"exact line", compareExact, "1\n2", "1\n3", "Строка 2: ожидалось «2», получено «3»"},
+		{"exact line count", compareExact, "1\n2", "1", "Ожидалось строк: 2, получено: 1"},
+		{"tokens", compareTokens, "1 2 3", "1 2 4", "Токен 3: ожидалось «3», получено «4»"},
+		{"token count", compareTokens, "1 2", "1 2 3", "Ожидалось токенов: 2, получено: 3"},
+		{"unordered missing line", compareUnorderedLines, "a\nb", "a\nc", "Строка «b» отсутствует в выводе"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.compare(tt.expected, tt.actual); result.Message != tt.message {
+				t.Errorf("Message = %q, want %q", result.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *models.Checker
+		wantErr bool
+	}{
+		{"default", nil, false},
+		{"empty type", &models.Checker{}, false},
+		{"tokens", &models.Checker{Type: models.CheckerTokens}, false},
+		{"float", &models.Checker{Type: models.CheckerFloat, AbsEpsilon: 1e-9, RelEpsilon: 1e-9}, false},
+		{"negative epsilon", &models.Checker{Type: models.CheckerFloat, AbsEpsilon: -1}, true},
+		{"custom without code", &models.Checker{Type: models.CheckerCustom, Language: "python"}, true},
+		{"custom without language", &models.Checker{Type: models.CheckerCustom, Code: "print('OK')"}, true},
+		{"custom", &models.Checker{Type: models.CheckerCustom, Language: "python", Code: "print('OK')"}, false},
+		{"unknown type", &models.Checker{Type: "regex"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitCheckerOutput(t *testing.T) {
+	tests := []struct {
+		output  string
+		verdict string
+		message string
+	}{
+		{"OK", "OK", ""},
+		{"OK\n", "OK", ""},
+		{"WA expected 3, got 4\n", "WA", "expected 3, got 4"},
+		{"  PE\n\tbad format  ", "PE", "bad format"},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			verdict, message := splitCheckerOutput(tt.output)
+			if verdict != tt.verdict || message != tt.message {
+				t.Errorf("splitCheckerOutput(%q) = %q, %q, want %q, %q", tt.output, verdict, message, tt.verdict, tt.message)
+			}
+		})
+	}
+}
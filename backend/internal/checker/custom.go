@@ -0,0 +1,97 @@
+package checker
+
+import (
+	"backend/internal/executor"
+	"backend/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// customCheckerTimeout - лимит времени чекера на один тест
+	customCheckerTimeout = 10 * time.Second
+	// maxCheckerMessageLength - максимальная длина сообщения чекера в результате теста
+	maxCheckerMessageLength = 1000
+)
+
+// customCheckerInput - данные теста, которые чекер получает одной строкой JSON на stdin
+type customCheckerInput struct {
+	Input    string `json:"input"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// customChecker запускает программу-чекер преподавателя в песочнице исполнителя.
+// Чекер читает customCheckerInput из stdin и выводит в stdout вердикт AC или WA
+// первым словом, остаток вывода - пояснение к вердикту. Ненулевой код возврата
+// или другой вердикт считаются сбоем чекера: так необработанное исключение
+// в чекере не превращается в неверный ответ студента
+type customChecker struct {
+	session executor.Session
+}
+
+// newCustomChecker компилирует чекер. Ошибка компиляции - ошибка настройки задачи
+func newCustomChecker(ctx context.Context, config *models.Checker, ex executor.Executor) (Checker, error) {
+	session, compileResult, err := executor.PrepareSession(ctx, ex, executor.ExecutionRequest{
+		Code:     config.Code,
+		Language: config.Language,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare checker: %w", err)
+	}
+	if compileResult != nil {
+		return nil, fmt.Errorf("checker compilation failed: %s", compileResult.ErrorMessage())
+	}
+	return &customChecker{session: session}, nil
+}
+
+// Check реализует Checker
+func (c *customChecker) Check(ctx context.Context, input, expected, actual string) (Result, error) {
+	data, err := json.Marshal(customCheckerInput{Input: input, Expected: expected, Actual: actual})
+	if err != nil {
+		return Result{}, err
+	}
+
+	result, err := c.session.Run(ctx, []string{string(data)}, customCheckerTimeout)
+	if err != nil {
+		return Result{}, err
+	}
+	if result.TimedOut {
+		return Result{}, fmt.Errorf("checker timed out (%v)", customCheckerTimeout)
+	}
+
+	if result.ExitCode != 0 {
+		return Result{}, fmt.Errorf("checker failed with exit code %d: %s", result.ExitCode, result.ErrorMessage())
+	}
+
+	verdict, message := splitCheckerOutput(result.Stdout)
+	if len(message) > maxCheckerMessageLength {
+		message = message[:maxCheckerMessageLength] + "..."
+	}
+
+	switch models.Verdict(strings.ToUpper(verdict)) {
+	case models.VerdictAccepted:
+		return Result{Passed: true, Message: message}, nil
+	case models.VerdictWrongAnswer:
+		return Result{Message: message}, nil
+	}
+	return Result{}, fmt.Errorf("checker returned unknown verdict %q", verdict)
+}
+
+// splitCheckerOutput отделяет вердикт (первое слово вывода) от сообщения
+func splitCheckerOutput(output string) (verdict, message string) {
+	output = strings.TrimSpace(output)
+	end := strings.IndexAny(output, " \t\r\n")
+	if end < 0 {
+		return output, ""
+	}
+	return output[:end], strings.TrimSpace(output[end:])
+}
+
+// Close реализует Checker
+func (c *customChecker) Close() error {
+	return c.session.Close()
+}
@@ -0,0 +1,183 @@
+package checker
+
+import (
+	"backend/internal/executor"
+	"backend/internal/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// defaultJSONEpsilon - погрешность сравнения дробных чисел в результатах функций:
+// покрывает только разницу в печати чисел разными языками
+const defaultJSONEpsilon = 1e-9
+
+// NewFunction создает чекер результатов задачи "напишите функцию". Результаты
+// сравниваются как JSON-значения; дробные числа - с погрешностью float-чекера,
+// если он задан. Пользовательский чекер получает результаты в виде JSON
+func NewFunction(ctx context.Context, config *models.Checker, ex executor.Executor) (Checker, error) {
+	if config != nil && config.Type == models.CheckerCustom {
+		return New(ctx, config, ex)
+	}
+	if err := Validate(config); err != nil {
+		return nil, err
+	}
+
+	absEps, relEps := defaultJSONEpsilon, defaultJSONEpsilon
+	if config != nil && config.Type == models.CheckerFloat {
+		absEps, relEps = floatEpsilons(config)
+	}
+	return builtinChecker(func(expected, actual string) Result {
+		return compareJSON(expected, actual, absEps, relEps)
+	}), nil
+}
+
+// compareJSON сравнивает два JSON-значения
+func compareJSON(expected, actual string, absEps, relEps float64) Result {
+	expectedValue, err := decodeJSON(expected)
+	if err != nil {
+		return Result{Message: "Некорректный ожидаемый результат: " + err.Error()}
+	}
+	actualValue, err := decodeJSON(actual)
+	if err != nil {
+		return Result{Message: "Результат функции не является корректным JSON"}
+	}
+
+	if path, ok := equalJSON(expectedValue, actualValue, "", absEps, relEps); !ok {
+		if path == "" {
+			return Result{Message: fmt.Sprintf("Ожидалось %s, получено %s", expected, actual)}
+		}
+		return Result{Message: fmt.Sprintf("Элемент %s: ожидалось %s, получено %s",
+			path, encodeJSON(valueAt(expectedValue, path)), encodeJSON(valueAt(actualValue, path)))}
+	}
+	return Result{Passed: true}
+}
+
+// decodeJSON разбирает значение, сохраняя числа в исходной записи
+func decodeJSON(data string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// equalJSON рекурсивно сравнивает значения и возвращает путь первого расхождения
+func equalJSON(expected, actual interface{}, path string, absEps, relEps float64) (string, bool) {
+	switch e := expected.(type) {
+	case json.Number:
+		a, ok := actual.(json.Number)
+		if !ok || !equalNumbers(e, a, absEps, relEps) {
+			return path, false
+		}
+		return "", true
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		// nil-срез Go сериализуется в null
+		if actual == nil && len(e) == 0 {
+			return "", true
+		}
+		if !ok || len(a) != len(e) {
+			return path, false
+		}
+		for i := range e {
+			if p, ok := equalJSON(e[i], a[i], fmt.Sprintf("%s[%d]", path, i), absEps, relEps); !ok {
+				return p, false
+			}
+		}
+		return "", true
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok || len(a) != len(e) {
+			return path, false
+		}
+		keys := make([]string, 0, len(e))
+		for key := range e {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, ok := a[key]
+			if !ok {
+				return path, false
+			}
+			if p, ok := equalJSON(e[key], value, fmt.Sprintf("%s[%q]", path, key), absEps, relEps); !ok {
+				return p, false
+			}
+		}
+		return "", true
+	case nil:
+		if a, ok := actual.([]interface{}); ok && len(a) == 0 {
+			return "", true
+		}
+		return path, actual == nil
+	default:
+		// string и bool
+		return path, expected == actual
+	}
+}
+
+// equalNumbers сравнивает целые числа точно, остальные - с погрешностью
+func equalNumbers(expected, actual json.Number, absEps, relEps float64) bool {
+	if e, err := expected.Int64(); err == nil {
+		if a, err := actual.Int64(); err == nil {
+			return e == a
+		}
+	}
+	e, err := expected.Float64()
+	if err != nil {
+		return false
+	}
+	a, err := actual.Float64()
+	if err != nil {
+		return false
+	}
+	diff := math.Abs(e - a)
+	return diff <= absEps || diff <= relEps*math.Abs(e)
+}
+
+// valueAt возвращает элемент по пути из equalJSON ([1][2], ["key"])
+func valueAt(value interface{}, path string) interface{} {
+	for path != "" && value != nil {
+		end := strings.IndexByte(path, ']')
+		if end < 0 {
+			return nil
+		}
+		index := path[1:end]
+		path = path[end+1:]
+		switch v := value.(type) {
+		case []interface{}:
+			var i int
+			if _, err := fmt.Sscan(index, &i); err != nil || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		case map[string]interface{}:
+			var key string
+			if err := json.Unmarshal([]byte(index), &key); err != nil {
+				return nil
+			}
+			value = v[key]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// encodeJSON выводит значение в компактном JSON
+func encodeJSON(value interface{}) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(buf.String())
+}
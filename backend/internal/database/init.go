@@ -65,8 +65,14 @@ func Init() {
 	createTaskSolutionsTable()
 	createTasksTable()
 	fixTasksTable()
+	createTaskTestSetsTable()
+	createSubmissionsTable()
 	createDefaultUsers()
-	createSampleTasks()
+	// Задачи из пакета (TASKS_SEED_PATH) загружает сервер, примеры тогда не нужны
+	if os.Getenv("TASKS_SEED_PATH") == "" {
+		createSampleTasks()
+	}
+	convertSampleFunctionTasks()
 }
 
 func createUsersTable() {
@@ -178,6 +184,101 @@ func createTasksTable() {
 	}
 }
 
+// createTaskTestSetsTable создает таблицу версий сгенерированных тестов задач
+func createTaskTestSetsTable() {
+	query := `
+	CREATE TABLE IF NOT EXISTS task_test_sets (
+		id SERIAL PRIMARY KEY,
+		task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		version INTEGER NOT NULL,
+		generator JSONB,
+		tests JSONB NOT NULL,
+		created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (task_id, version)
+	)
+	`
+	_, err := DB.Exec(query)
+	if err != nil {
+		log.Fatalf("❌ Ошибка при создании таблицы task_test_sets: %v", err)
+	}
+	log.Println("✅ Таблица task_test_sets создана/проверена")
+}
+
+// createSubmissionsTable создает очередь решений на асинхронную проверку
+func createSubmissionsTable() {
+	query := `
+	CREATE TABLE IF NOT EXISTS submissions (
+		id VARCHAR(32) PRIMARY KEY,
+		user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+		task_id VARCHAR(255) NOT NULL,
+		language VARCHAR(50) NOT NULL,
+		code TEXT NOT NULL,
+		files JSONB,
+		tests JSONB,
+		status VARCHAR(20) NOT NULL DEFAULT 'queued',
+		verdict VARCHAR(10),
+		result JSONB,
+		error TEXT,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		passed_tests INTEGER NOT NULL DEFAULT 0,
+		total_tests INTEGER NOT NULL DEFAULT 0,
+		time_ms BIGINT NOT NULL DEFAULT 0,
+		cpu_time_ms BIGINT NOT NULL DEFAULT 0,
+		memory_kb BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		started_at TIMESTAMP,
+		heartbeat_at TIMESTAMP,
+		finished_at TIMESTAMP
+	);
+	ALTER TABLE submissions ADD COLUMN IF NOT EXISTS passed_tests INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE submissions ADD COLUMN IF NOT EXISTS total_tests INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE submissions ADD COLUMN IF NOT EXISTS time_ms BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE submissions ADD COLUMN IF NOT EXISTS cpu_time_ms BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE submissions ADD COLUMN IF NOT EXISTS memory_kb BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE submissions ADD COLUMN IF NOT EXISTS files JSONB;
+	ALTER TABLE submissions ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMP;
+	CREATE INDEX IF NOT EXISTS idx_submissions_queued ON submissions(created_at) WHERE status = 'queued';
+	CREATE INDEX IF NOT EXISTS idx_submissions_status ON submissions(status);
+	CREATE INDEX IF NOT EXISTS idx_submissions_user_id ON submissions(user_id);
+	CREATE INDEX IF NOT EXISTS idx_submissions_user_task ON submissions(user_id, task_id, language, created_at);
+	`
+	_, err := DB.Exec(query)
+	if err != nil {
+		log.Fatalf("❌ Ошибка при создании таблицы submissions: %v", err)
+	}
+
+	// Переносим в историю решения, сохраненные до её появления (по одному на задачу)
+	migrateQuery := `
+	INSERT INTO submissions (id, user_id, task_id, language, code, status, verdict,
+		passed_tests, total_tests, created_at, finished_at)
+	SELECT 'legacy' || ts.id, ts.user_id, ts.task_id, ts.language, ts.code, 'done',
+		CASE WHEN ts.success THEN 'AC' ELSE 'WA' END,
+		ts.passed_tests, ts.total_tests, ts.created_at, ts.created_at
+	FROM task_solutions ts
+	ON CONFLICT (id) DO NOTHING
+	`
+	if _, err := DB.Exec(migrateQuery); err != nil {
+		log.Printf("⚠️ Ошибка при переносе task_solutions в submissions: %v", err)
+	}
+
+	// Лучшее решение пользователя по задаче: больше пройденных тестов, затем раньше отправленное.
+	// Попытки на своих тестах (tests не NULL) решением задачи не считаются
+	viewQuery := `
+	CREATE OR REPLACE VIEW best_solutions AS
+	SELECT DISTINCT ON (user_id, task_id, language)
+		id AS submission_id, user_id, task_id, language, code, verdict,
+		verdict = 'AC' AS success, passed_tests, total_tests, time_ms, created_at
+	FROM submissions
+	WHERE user_id IS NOT NULL AND status = 'done' AND tests IS NULL
+	ORDER BY user_id, task_id, language, (verdict = 'AC') DESC, passed_tests DESC, created_at ASC
+	`
+	if _, err := DB.Exec(viewQuery); err != nil {
+		log.Fatalf("❌ Ошибка при создании представления best_solutions: %v", err)
+	}
+	log.Println("✅ Таблица submissions готова")
+}
+
 func createDefaultUsers() {
 	log.Println("🔄 Начинаем создание тестовых пользователей...")
 
@@ -292,6 +393,14 @@ func fixTasksTable() {
 		`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS template TEXT`,
 		`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS created_by INTEGER REFERENCES users(id) ON DELETE SET NULL`,
 		`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS is_published BOOLEAN DEFAULT TRUE`,
+		`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS checker JSONB`,
+		`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS function_signature JSONB`,
+		`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS entry_point VARCHAR(255)`,
+		`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS support_files JSONB`,
+		`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS starter_files JSONB`,
+		`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS reference_solution JSONB`,
+		`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS validation JSONB`,
+		`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS test_set_version INTEGER`,
 	}
 
 	for _, query := range queries {
@@ -326,6 +435,7 @@ func createSampleTasks() {
 		template    string
 		starterCode string
 		tests       string
+		function    string // Сигнатура для задач "напишите функцию"
 		createdBy   int
 	}{
 		{
@@ -350,12 +460,13 @@ func createSampleTasks() {
 		},
 		{
 			title:       "Факториал числа",
-			description: "Напишите функцию для вычисления факториала числа",
+			description: factorialDescription,
 			language:    "python",
 			difficulty:  "intermediate",
 			template:    `def factorial(n):\n    if n == 0:\n        return 1\n    result = 1\n    for i in range(1, n + 1):\n        result *= i\n    return result\n\n# Тестирование\nprint(factorial(5))`,
-			starterCode: `def factorial(n):\n    # Ваш код здесь\n    pass\n\nprint(factorial(5))`,
-			tests:       `[{"input": "5", "expected_output": "120"}, {"input": "0", "expected_output": "1"}]`,
+			starterCode: factorialStarterCode,
+			tests:       factorialTests,
+			function:    factorialFunction,
 			createdBy:   1,
 		},
 		{
@@ -380,12 +491,13 @@ func createSampleTasks() {
 		},
 		{
 			title:       "Сумма массивов",
-			description: "Напишите функцию, которая суммирует все элементы массива",
+			description: sumArrayDescription,
 			language:    "javascript",
 			difficulty:  "intermediate",
 			template:    `function sumArray(arr) {\n    return arr.reduce((a, b) => a + b, 0);\n}\n\nconsole.log(sumArray([1, 2, 3, 4, 5]));`,
-			starterCode: `function sumArray(arr) {\n    // Ваш код здесь\n}\n\nconsole.log(sumArray([1, 2, 3, 4, 5]));`,
-			tests:       `[{"input": "", "expected_output": "15"}, {"input": "", "expected_output": "0"}]`,
+			starterCode: sumArrayStarterCode,
+			tests:       sumArrayTests,
+			function:    sumArrayFunction,
 			createdBy:   1,
 		},
 	}
@@ -394,10 +506,16 @@ func createSampleTasks() {
 	for _, task := range sampleTasks {
 		query := `
         INSERT INTO tasks (title, description, language, difficulty, template, 
-                          starter_code, tests, created_by, is_published, created_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, true, CURRENT_TIMESTAMP)
+                          starter_code, tests, created_by, is_published, created_at,
+                          function_signature)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, true, CURRENT_TIMESTAMP, $9)
         `
 
+		var function interface{} // NULL для задач на программу целиком
+		if task.function != "" {
+			function = task.function
+		}
+
 		_, err := DB.Exec(query,
 			task.title,
 			task.description,
@@ -407,6 +525,7 @@ func createSampleTasks() {
 			task.starterCode,
 			task.tests,
 			task.createdBy,
+			function,
 		)
 
 		if err != nil {
@@ -419,3 +538,60 @@ func createSampleTasks() {
 
 	log.Printf("📊 Всего добавлено %d тестовых задач", successCount)
 }
+
+// Тестовые задачи "напишите функцию": тесты задают аргументы и результат функции
+const (
+	factorialDescription = "Напишите функцию factorial(n), которая возвращает факториал числа n"
+	factorialStarterCode = "def factorial(n):\n    # Ваш код здесь\n    pass\n"
+	factorialFunction    = `{"name": "factorial", "params": [{"name": "n", "type": "int"}], "return_type": "long"}`
+	factorialTests       = `[{"args": [5], "expected_return": 120}, {"args": [0], "expected_return": 1}, {"args": [1], "expected_return": 1}, {"args": [10], "expected_return": 3628800, "is_hidden": true}, {"args": [20], "expected_return": 2432902008176640000, "is_hidden": true}]`
+
+	sumArrayDescription = "Напишите функцию sumArray(arr), которая возвращает сумму всех элементов массива"
+	sumArrayStarterCode = "function sumArray(arr) {\n    // Ваш код здесь\n}\n"
+	sumArrayFunction    = `{"name": "sumArray", "params": [{"name": "arr", "type": "int[]"}], "return_type": "int"}`
+	sumArrayTests       = `[{"args": [[1, 2, 3, 4, 5]], "expected_return": 15}, {"args": [[]], "expected_return": 0}, {"args": [[-3, 3, 7]], "expected_return": 7}, {"args": [[100, -1]], "expected_return": 99, "is_hidden": true}]`
+)
+
+// convertSampleFunctionTasks переводит созданные ранее тестовые задачи на функции
+// на проверку через харнесс. Задача меняется, только если её тесты не правили
+func convertSampleFunctionTasks() {
+	conversions := []struct {
+		title       string
+		oldTests    string
+		description string
+		starterCode string
+		tests       string
+		function    string
+	}{
+		{
+			title:       "Факториал числа",
+			oldTests:    `[{"input": "5", "expected_output": "120"}, {"input": "0", "expected_output": "1"}]`,
+			description: factorialDescription,
+			starterCode: factorialStarterCode,
+			tests:       factorialTests,
+			function:    factorialFunction,
+		},
+		{
+			title:       "Сумма массивов",
+			oldTests:    `[{"input": "", "expected_output": "15"}, {"input": "", "expected_output": "0"}]`,
+			description: sumArrayDescription,
+			starterCode: sumArrayStarterCode,
+			tests:       sumArrayTests,
+			function:    sumArrayFunction,
+		},
+	}
+
+	for _, conversion := range conversions {
+		result, err := DB.Exec(`
+		UPDATE tasks
+		SET description = $3, starter_code = $4, tests = $5, function_signature = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE title = $1 AND tests = $2::jsonb AND function_signature IS NULL
+		`, conversion.title, conversion.oldTests, conversion.description, conversion.starterCode,
+			conversion.tests, conversion.function)
+		if err != nil {
+			log.Printf("⚠️ Ошибка при обновлении задачи '%s': %v", conversion.title, err)
+		} else if n, _ := result.RowsAffected(); n > 0 {
+			log.Printf("✅ Задача '%s' переведена на проверку функции", conversion.title)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// dockerPoolLabel помечает контейнеры пула, чтобы их можно было найти и удалить вручную
+const dockerPoolLabel = "code-executor.pool"
+
+// dockerRunUser - непривилегированный пользователь (nobody), от которого в
+// контейнере работают keep-alive, компиляция и решения
+const dockerRunUser = "65534:65534"
+
+// dockerEnv - окружение контейнера: домашняя директория и кэши компиляторов
+// лежат в /tmp, так как остальная файловая система доступна только на чтение
+var dockerEnv = []string{"HOME=/tmp", "XDG_CACHE_HOME=/tmp/.cache", "DOTNET_CLI_HOME=/tmp"}
+
+// dockerResetScript возвращает контейнер в исходное состояние между решениями:
+// убивает все процессы, кроме PID 1 (keep-alive) и самого скрипта, и очищает
+// рабочую директорию и /tmp. Больше решение ничего изменить не может: корневая
+// файловая система только для чтения, а процессы работают не от root
+const dockerResetScript = `kill -9 -1 2>/dev/null; rm -rf /code/* /code/.[!.]* /code/..?* /tmp/* /tmp/.[!.]* /tmp/..?*; true`
+
+// dockerResetTimeout - лимит времени на сброс контейнера
+const dockerResetTimeout = 10 * time.Second
+
+// DockerPoolConfig - настройки пула прогретых контейнеров
+type DockerPoolConfig struct {
+	Size           int           // Прогретых контейнеров на образ (0 - контейнер создается на каждое решение)
+	MaxRuns        int           // Через сколько запусков контейнер пересоздается
+	HealthInterval time.Duration // Период проверки простаивающих контейнеров
+}
+
+// DefaultDockerPoolConfig возвращает настройки пула по умолчанию
+func DefaultDockerPoolConfig() DockerPoolConfig {
+	return DockerPoolConfig{
+		Size:           2,
+		MaxRuns:        100,
+		HealthInterval: 30 * time.Second,
+	}
+}
+
+// DockerPoolConfigFromEnv читает настройки пула из переменных окружения:
+// DOCKER_POOL_SIZE, DOCKER_POOL_MAX_RUNS, DOCKER_POOL_HEALTH_INTERVAL_SECONDS
+func DockerPoolConfigFromEnv() DockerPoolConfig {
+	cfg := DefaultDockerPoolConfig()
+
+	// DOCKER_POOL_SIZE=0 отключает прогрев, поэтому 0 отличаем от отсутствия переменной
+	if value, ok := os.LookupEnv("DOCKER_POOL_SIZE"); ok {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			cfg.Size = n
+		}
+	}
+	if n := envInt("DOCKER_POOL_MAX_RUNS"); n > 0 {
+		cfg.MaxRuns = n
+	}
+	if sec := envInt("DOCKER_POOL_HEALTH_INTERVAL_SECONDS"); sec > 0 {
+		cfg.HealthInterval = time.Duration(sec) * time.Second
+	}
+
+	return cfg
+}
+
+// pooledContainer - запущенный контейнер пула
+type pooledContainer struct {
+	id     string
+	runs   int  // Запусков программ с момента создания
+	broken bool // Контейнер в неизвестном состоянии и не должен возвращаться в пул
+}
+
+// containerPool хранит прогретые контейнеры одного образа. Контейнер выдается
+// одному решению целиком, после решения сбрасывается и возвращается в пул
+type containerPool struct {
+	client     *client.Client
+	image      string
+	hostConfig *container.HostConfig
+	config     DockerPoolConfig
+
+	mu      sync.Mutex
+	idle    []*pooledContainer
+	warming int // Контейнеры, которые сейчас создаются для пула
+	closed  bool
+	done    chan struct{}
+}
+
+// newContainerPool создает пул и запускает его прогрев и проверки
+func newContainerPool(cli *client.Client, image string, hostConfig *container.HostConfig, config DockerPoolConfig) *containerPool {
+	p := &containerPool{
+		client:     cli,
+		image:      image,
+		hostConfig: hostConfig,
+		config:     config,
+		done:       make(chan struct{}),
+	}
+	if config.Size > 0 {
+		go p.refill()
+		go p.maintain()
+	}
+	return p
+}
+
+// acquire выдает прогретый контейнер или создает новый, если пул пуст
+func (p *containerPool) acquire(ctx context.Context) (*pooledContainer, error) {
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		c := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		go p.refill()
+		return c, nil
+	}
+	p.mu.Unlock()
+
+	if p.config.Size > 0 {
+		log.Printf("⚠️ Container pool for %s is empty, starting a cold container", p.image)
+		go p.refill()
+	}
+	return p.create(ctx)
+}
+
+// release возвращает контейнер после решения. Сброс выполняется в фоне,
+// чтобы не задерживать ответ
+func (p *containerPool) release(c *pooledContainer) {
+	go p.recycle(c)
+}
+
+// recycle сбрасывает контейнер и возвращает его в пул либо удаляет
+func (p *containerPool) recycle(c *pooledContainer) {
+	if c.broken || c.runs >= p.config.MaxRuns || p.config.Size == 0 {
+		p.remove(c)
+		go p.refill()
+		return
+	}
+
+	if err := p.reset(c); err != nil {
+		log.Printf("⚠️ Failed to reset container %.12s: %v", c.id, err)
+		p.remove(c)
+		go p.refill()
+		return
+	}
+
+	p.mu.Lock()
+	if p.closed || len(p.idle) >= p.config.Size {
+		p.mu.Unlock()
+		p.remove(c)
+		return
+	}
+	p.idle = append(p.idle, c)
+	p.mu.Unlock()
+}
+
+// refill создает контейнеры, пока в пуле их меньше config.Size
+func (p *containerPool) refill() {
+	p.mu.Lock()
+	missing := p.config.Size - len(p.idle) - p.warming
+	if p.closed || missing <= 0 {
+		p.mu.Unlock()
+		return
+	}
+	p.warming += missing
+	p.mu.Unlock()
+
+	for i := 0; i < missing; i++ {
+		c, err := p.create(context.Background())
+
+		p.mu.Lock()
+		p.warming--
+		if err == nil && !p.closed {
+			p.idle = append(p.idle, c)
+			c = nil
+		}
+		p.mu.Unlock()
+
+		if err != nil {
+			log.Printf("⚠️ Failed to warm up container for %s: %v", p.image, err)
+			return
+		}
+		if c != nil {
+			p.remove(c)
+		}
+	}
+}
+
+// maintain периодически проверяет простаивающие контейнеры и пополняет пул
+func (p *containerPool) maintain() {
+	ticker := time.NewTicker(p.config.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+
+		p.mu.Lock()
+		idle := p.idle
+		p.idle = nil
+		p.mu.Unlock()
+
+		var healthy []*pooledContainer
+		for _, c := range idle {
+			if p.healthy(c) {
+				healthy = append(healthy, c)
+			} else {
+				log.Printf("⚠️ Container %.12s for %s is unhealthy, replacing", c.id, p.image)
+				p.remove(c)
+			}
+		}
+
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			for _, c := range healthy {
+				p.remove(c)
+			}
+			return
+		}
+		p.idle = append(p.idle, healthy...)
+		p.mu.Unlock()
+
+		p.refill()
+	}
+}
+
+// healthy проверяет, что контейнер запущен и выполняет команды
+func (p *containerPool) healthy(c *pooledContainer) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), dockerResetTimeout)
+	defer cancel()
+
+	inspect, err := p.client.ContainerInspect(ctx, c.id)
+	if err != nil || inspect.State == nil || !inspect.State.Running {
+		return false
+	}
+	result, err := dockerExec(ctx, p.client, c.id, []string{"true"}, nil, dockerResetTimeout)
+	return err == nil && !result.TimedOut && result.ExitCode == 0
+}
+
+// create создает и запускает контейнер без сети, ожидающий команд
+func (p *containerPool) create(ctx context.Context) (*pooledContainer, error) {
+	resp, err := p.client.ContainerCreate(ctx, &container.Config{
+		Image:      p.image,
+		Cmd:        dockerKeepAliveCommand,
+		WorkingDir: dockerWorkDir,
+		User:       dockerRunUser,
+		Env:        dockerEnv,
+		Labels:     map[string]string{dockerPoolLabel: p.image},
+	}, p.hostConfig, nil, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create container: %w", err)
+	}
+
+	c := &pooledContainer{id: resp.ID}
+	if err := p.client.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		p.remove(c)
+		return nil, fmt.Errorf("failed to start container: %w", err)
+	}
+	return c, nil
+}
+
+// reset убивает оставшиеся процессы решения и очищает файлы
+func (p *containerPool) reset(c *pooledContainer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dockerResetTimeout)
+	defer cancel()
+
+	result, err := dockerExec(ctx, p.client, c.id, []string{"sh", "-c", dockerResetScript}, nil, dockerResetTimeout)
+	if err != nil {
+		return err
+	}
+	if result.TimedOut || result.ExitCode != 0 {
+		return fmt.Errorf("reset exited with code %d: %s", result.ExitCode, result.ErrorMessage())
+	}
+	return nil
+}
+
+// remove удаляет контейнер вместе со всеми процессами
+func (p *containerPool) remove(c *pooledContainer) {
+	err := p.client.ContainerRemove(context.Background(), c.id, types.ContainerRemoveOptions{Force: true})
+	if err != nil {
+		log.Printf("⚠️ Failed to remove container %.12s: %v", c.id, err)
+	}
+}
+
+// close останавливает пул и удаляет простаивающие контейнеры. Выданные
+// контейнеры удаляются при возврате
+func (p *containerPool) close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	close(p.done)
+	for _, c := range idle {
+		p.remove(c)
+	}
+}
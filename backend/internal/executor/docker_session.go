@@ -0,0 +1,432 @@
+package executor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/languages"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// dockerKeepAliveCommand держит контейнер запущенным между запусками
+var dockerKeepAliveCommand = []string{"sh", "-c", "while :; do sleep 3600; done"}
+
+// dockerKillScript убивает все процессы контейнера, кроме PID 1 (keep-alive)
+const dockerKillScript = "kill -9 -1"
+
+// dockerWorkDir - рабочая директория решения в контейнере
+const dockerWorkDir = "/code"
+
+// dockerUsageFile - куда dockerUsageScript записывает потребление ресурсов запуска
+const dockerUsageFile = "/tmp/.run_usage"
+
+// dockerUsageScript читает из первой строки stdin токен запуска, запускает
+// программу ("$@") с остальным вводом и записывает в файл $0 токен, ее
+// процессорное время в тиках, частоту тиков, пиковый RSS в КБ и число
+// срабатываний OOM killer cgroup за время запуска. Программа работает под тем
+// же пользователем и может писать в /tmp, поэтому файл пишется только после
+// того, как убиты все ее процессы, а токен, которого программа не видит ни в
+// аргументах, ни в окружении, подтверждает, что файл записан этим запуском. Счетчики времени и памяти
+// cgroup общие для всего контейнера (компиляция, прошлые запуски), поэтому
+// значения снимаются из /proc программы раз в 20 мс: последние миллисекунды
+// работы и еще не завершенные дочерние процессы в них не попадают. Счетчик
+// oom_kill берется из memory.events (cgroup v2) или memory.oom_control (v1)
+// до и после запуска. Код возврата программы сохраняется
+const dockerUsageScript = `oom_kills() {
+	for f in /sys/fs/cgroup/memory.events /sys/fs/cgroup/memory/memory.oom_control; do
+		[ -r "$f" ] || continue
+		while read -r key value; do
+			[ "$key" = oom_kill ] && echo "$value" && return
+		done < "$f"
+	done
+	echo 0
+}
+read -r token
+ooms=$(oom_kills)
+exec 3<&0
+"$@" <&3 3<&- &
+pid=$!
+peak=0
+ticks=0
+while [ -r /proc/$pid/stat ]; do
+	read -r stat < /proc/$pid/stat || break
+	set -- ${stat##*") "}
+	case $1 in Z|X) break ;; esac
+	ticks=$((${12} + ${13} + ${14} + ${15}))
+	while read -r key value rest; do
+		[ "$key" = VmHWM: ] && [ "$value" -gt "$peak" ] && peak=$value
+	done < /proc/$pid/status
+	sleep 0.02
+done 2>/dev/null
+wait $pid
+code=$?
+kill -9 -1 2>/dev/null
+rm -rf "$0"
+echo "$token $ticks $(getconf CLK_TCK 2>/dev/null || echo 100) $peak $(($(oom_kills) - ooms))" > "$0"
+exit $code`
+
+// dockerSession - контейнер из пула с исходным кодом и скомпилированной
+// программой. Каждый запуск выполняется через docker exec
+type dockerSession struct {
+	client        *client.Client
+	pool          *containerPool
+	container     *pooledContainer
+	lang          *languages.Language
+	command       []string     // Команда запуска программы
+	env           []string     // Переменные окружения запуска
+	streams       runStreams   // Ввод и вывод в реальном времени
+	output        OutputLimits // Лимиты размера вывода программы
+	compileOutput string
+	stopped       bool // Контейнер пришлось остановить целиком, перед запуском его нужно стартовать
+}
+
+// Prepare реализует SessionExecutor: берет контейнер из пула, записывает
+// в него код и компилирует программу. Программа остается в рабочей директории до конца сессии
+func (d *DockerExecutorImpl) Prepare(ctx context.Context, req ExecutionRequest) (Session, *ExecutionResult, error) {
+	log.Printf("🐳 DockerExecutor preparing %s session, length: %d chars", req.Language, len(req.Code))
+
+	lang, ok := languages.Lookup(req.Language)
+	if !ok {
+		return nil, errorResult("Unsupported language: " + req.Language), nil
+	}
+	req, err := resolveFiles(req, lang)
+	if err != nil {
+		return nil, errorResult(err.Error()), nil
+	}
+	fileName := req.EntryPoint
+
+	pool := d.pool(lang)
+	c, err := pool.acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	session := &dockerSession{
+		client:    d.client,
+		pool:      pool,
+		container: c,
+		lang:      lang,
+		command:   append([]string{"sh", "-c", dockerUsageScript, dockerUsageFile}, lang.RunCommand(dockerWorkDir, fileName)...),
+		streams:   req.streams(),
+		output:    d.output,
+	}
+	if req.streaming() {
+		session.env = streamingEnv
+	}
+
+	files := append([]File{{Name: fileName, Content: lang.WrapCode(req.Code)}}, req.Files...)
+	for _, file := range files {
+		if err := session.writeFile(ctx, file); err != nil {
+			session.container.broken = true
+			session.Close()
+			return nil, nil, fmt.Errorf("failed to write file %s: %w", file.Name, err)
+		}
+	}
+
+	if compileCommand := lang.CompileCommand(dockerWorkDir, fileName, sourceNames(fileName, req.Files)...); compileCommand != "" {
+		log.Printf("🔨 Compiling %s code in container %.12s...", lang.Name, c.id)
+		result, err := session.exec(ctx, dockerExecRequest{
+			Cmd:     []string{"sh", "-c", "(" + compileCommand + ") 2>&1"},
+			Timeout: compileTimeout,
+		})
+		if err != nil {
+			session.Close()
+			return nil, nil, err
+		}
+		if result.TimedOut {
+			session.Close()
+			return nil, compileErrorResult(fmt.Sprintf("Compilation timeout (%v)", compileTimeout)), nil
+		}
+		if result.ExitCode != 0 {
+			log.Printf("❌ Docker compilation failed")
+			session.Close()
+			return nil, compileErrorResult(strings.TrimSpace(result.Stdout)), nil
+		}
+		session.compileOutput = strings.TrimSpace(result.Stdout)
+	}
+
+	return session, nil, nil
+}
+
+// dockerWriteFileScript записывает stdin в файл $0, создавая поддиректории;
+// $1 - права файла
+const dockerWriteFileScript = `mkdir -p "$(dirname "$0")" && cat > "$0" && chmod "$1" "$0"`
+
+// writeFile записывает файл в рабочую директорию контейнера. Содержимое
+// передается через stdin: docker cp не работает с пустыми рабочими директориями
+// на tmpfs и требует tar-архива, а exec уже есть
+func (s *dockerSession) writeFile(ctx context.Context, file File) error {
+	mode := "644"
+	if file.ReadOnly {
+		mode = "444"
+	}
+	result, err := s.exec(ctx, dockerExecRequest{
+		Cmd:     []string{"sh", "-c", dockerWriteFileScript, file.Name, mode},
+		Stdin:   []byte(file.Content),
+		Timeout: dockerResetTimeout,
+	})
+	if err == nil && (result.TimedOut || result.ExitCode != 0) {
+		err = fmt.Errorf("exit code %d: %s", result.ExitCode, result.ErrorMessage())
+	}
+	return err
+}
+
+// Run запускает скомпилированную программу в контейнере сессии
+func (s *dockerSession) Run(ctx context.Context, inputs []string, timeout time.Duration) (*ExecutionResult, error) {
+	if timeout <= 0 {
+		timeout = s.lang.TimeLimit()
+	}
+	if timeout <= 0 {
+		timeout = defaultDockerTimeout
+	}
+
+	// Контейнер был остановлен целиком; файловая система при этом сохраняется
+	if s.stopped {
+		if err := s.client.ContainerStart(ctx, s.container.id, types.ContainerStartOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to restart container: %w", err)
+		}
+		s.stopped = false
+	}
+
+	token, err := usageToken()
+	if err != nil {
+		return nil, err
+	}
+	stdin := []byte(token + "\n")
+	if len(inputs) > 0 {
+		stdin = append(stdin, strings.Join(inputs, "\n")+"\n"...)
+	}
+
+	s.container.runs++
+	result, err := s.exec(ctx, dockerExecRequest{
+		Cmd:          s.command,
+		Env:          s.env,
+		Stdin:        stdin,
+		StdinStream:  s.streams.stdin,
+		Timeout:      timeout,
+		Stdout:       s.streams.stdout,
+		Stderr:       s.streams.stderr,
+		OutputLimits: s.output,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.TimedOut && !result.OutputLimitExceeded() {
+		s.readUsage(ctx, result, token)
+	}
+	result.CompileOutput = s.compileOutput
+
+	log.Printf("📊 Docker session run result - Exit code: %d, Output: %d chars, Error: %d chars",
+		result.ExitCode, len(result.Stdout), len(result.Stderr))
+	return result, nil
+}
+
+// readUsage дописывает в результат процессорное время, пиковую память и
+// признак OOM, записанные dockerUsageScript. Программа считается убитой OOM
+// killer, только если он сработал в cgroup во время запуска, а не по коду 137:
+// SIGKILL мог прийти и от самой программы. Если файл не удалось прочитать из-за
+// ошибки Docker, поля остаются нулевыми. Если файла нет или он записан не этим
+// запуском, программа помешала измерению, и запуск считается ошибкой выполнения
+func (s *dockerSession) readUsage(ctx context.Context, result *ExecutionResult, token string) {
+	usage, err := dockerExec(ctx, s.client, s.container.id, []string{"sh", "-c", `cat "$0" && rm -f "$0"`, dockerUsageFile}, nil, dockerResetTimeout)
+	if err != nil || usage.TimedOut {
+		log.Printf("⚠️ Failed to read resource usage in container %.12s", s.container.id)
+		return
+	}
+
+	var runToken string
+	var ticks, ticksPerSecond, peakKB, oomKills int64
+	if _, err := fmt.Sscan(usage.Stdout, &runToken, &ticks, &ticksPerSecond, &peakKB, &oomKills); err != nil || runToken != token || ticksPerSecond <= 0 {
+		log.Printf("⚠️ Resource usage in container %.12s was not written by the run: %q", s.container.id, usage.Stdout)
+		result.Stderr = strings.TrimSpace(result.Stderr + "\nResource usage was not recorded: the program interfered with the sandbox")
+		if result.ExitCode == 0 {
+			result.ExitCode = 1
+		}
+		return
+	}
+	result.CPUTime = time.Duration(ticks) * time.Second / time.Duration(ticksPerSecond)
+	result.PeakMemory = peakKB * 1024
+	result.OOMKilled = oomKills > 0 && result.ExitCode != 0
+}
+
+// usageToken - случайный токен запуска для dockerUsageScript
+func usageToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate run token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// exec выполняет команду в контейнере сессии. По таймауту, при превышении
+// лимита вывода или при отмене ctx убиваются все процессы контейнера
+func (s *dockerSession) exec(ctx context.Context, req dockerExecRequest) (*ExecutionResult, error) {
+	result, err := runDockerExec(ctx, s.client, s.container.id, req)
+	if ctx.Err() != nil {
+		s.kill()
+		log.Printf("🛑 Docker session execution cancelled: %v", ctx.Err())
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.TimedOut {
+		s.kill()
+		log.Printf("⏰ Docker session execution timeout (%v)", req.Timeout)
+	}
+	if result.OutputLimitExceeded() {
+		s.kill()
+		log.Printf("📛 Docker session execution stopped: output limit exceeded")
+	}
+	return result, nil
+}
+
+// kill убивает все процессы решения. Если контейнер не отвечает, он
+// останавливается целиком и после сессии не возвращается в пул
+func (s *dockerSession) kill() {
+	ctx, cancel := context.WithTimeout(context.Background(), dockerResetTimeout)
+	defer cancel()
+
+	result, err := dockerExec(ctx, s.client, s.container.id, []string{"sh", "-c", dockerKillScript}, nil, dockerResetTimeout)
+	if err == nil && !result.TimedOut {
+		return
+	}
+
+	s.container.broken = true
+	if err := s.client.ContainerKill(context.Background(), s.container.id, "KILL"); err != nil {
+		log.Printf("⚠️ Failed to kill container %.12s: %v", s.container.id, err)
+	}
+	s.stopped = true
+}
+
+// Close возвращает контейнер в пул: там он сбрасывается или удаляется
+func (s *dockerSession) Close() error {
+	s.pool.release(s.container)
+	return nil
+}
+
+// dockerExecRequest - команда для выполнения в контейнере
+type dockerExecRequest struct {
+	Cmd         []string
+	Env         []string
+	Stdin       []byte
+	StdinStream io.Reader // Интерактивный ввод, передается после Stdin (может быть nil)
+	Timeout     time.Duration
+	Stdout      io.Writer // Получатели вывода в реальном времени (могут быть nil)
+	Stderr      io.Writer
+
+	OutputLimits OutputLimits // Лимиты вывода; нулевые - служебная команда без лимитов
+}
+
+// dockerExec выполняет служебную команду в контейнере и собирает вывод
+func dockerExec(ctx context.Context, cli *client.Client, containerID string, cmd []string, stdin []byte, timeout time.Duration) (*ExecutionResult, error) {
+	return runDockerExec(ctx, cli, containerID, dockerExecRequest{Cmd: cmd, Stdin: stdin, Timeout: timeout})
+}
+
+// runDockerExec выполняет команду в контейнере и собирает вывод, по мере
+// появления копируя его в req.Stdout и req.Stderr. По таймауту или при
+// превышении лимита вывода возвращает результат с TimedOut или флагами
+// обрезки вывода, сами процессы при этом продолжают работать
+func runDockerExec(ctx context.Context, cli *client.Client, containerID string, req dockerExecRequest) (*ExecutionResult, error) {
+	execResp, err := cli.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:          req.Cmd,
+		Env:          req.Env,
+		WorkingDir:   dockerWorkDir,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	start := time.Now()
+	attach, err := cli.ContainerExecAttach(ctx, execResp.ID, types.ExecStartCheck{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach to exec: %w", err)
+	}
+	defer attach.Close()
+
+	// Отправляем входные данные и закрываем stdin
+	go func() {
+		if len(req.Stdin) > 0 {
+			if _, err := attach.Conn.Write(req.Stdin); err != nil {
+				log.Printf("⚠️ Failed to write input data: %v", err)
+			}
+		}
+		if req.StdinStream != nil {
+			// Ошибка записи означает, что программа завершилась и соединение закрыто
+			io.Copy(attach.Conn, req.StdinStream)
+		}
+		attach.CloseWrite()
+	}()
+
+	exceeded := make(chan struct{})
+	var exceededOnce sync.Once
+	onExceed := func() { exceededOnce.Do(func() { close(exceeded) }) }
+	stdout := newOutputBuffer(req.OutputLimits.Stdout, req.Stdout, onExceed)
+	stderr := newOutputBuffer(req.OutputLimits.Stderr, req.Stderr, onExceed)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(stdout, stderr, attach.Reader)
+		done <- err
+	}()
+
+	timer := time.NewTimer(req.Timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, fmt.Errorf("failed to read exec output: %w", err)
+		}
+	case <-timer.C:
+		attach.Close()
+		<-done
+		return &ExecutionResult{
+			Stdout:   stdout.String(),
+			Stderr:   fmt.Sprintf("Execution timeout (%v)", req.Timeout),
+			ExitCode: -1,
+			WallTime: time.Since(start),
+			TimedOut: true,
+		}, nil
+	case <-exceeded:
+		attach.Close()
+		<-done
+		return &ExecutionResult{
+			Stdout:          stdout.String(),
+			Stderr:          strings.TrimSpace(stderr.String() + "\n" + outputLimitMessage(req.OutputLimits, stdout.Truncated(), stderr.Truncated())),
+			ExitCode:        -1,
+			WallTime:        time.Since(start),
+			StdoutTruncated: stdout.Truncated(),
+			StderrTruncated: stderr.Truncated(),
+		}, nil
+	case <-ctx.Done():
+		attach.Close()
+		<-done
+		return nil, ctx.Err()
+	}
+	wallTime := time.Since(start)
+
+	inspect, err := cli.ContainerExecInspect(ctx, execResp.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect exec: %w", err)
+	}
+
+	return &ExecutionResult{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: inspect.ExitCode,
+		WallTime: wallTime,
+	}, nil
+}
@@ -1,11 +1,169 @@
 package executor
 
-// Executor интерфейс для выполнения кода
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+)
+
+// Executor интерфейс для выполнения кода.
+// Отмена ctx (разрыв соединения клиента, остановка сервера) должна убивать
+// запущенную программу; в этом случае Execute возвращает ctx.Err()
 type Executor interface {
-	Execute(code, language string, inputs []string) (map[string]interface{}, error)
+	Execute(ctx context.Context, req ExecutionRequest) (*ExecutionResult, error)
+}
+
+// Session - подготовленная (скомпилированная) программа, которую можно
+// запускать несколько раз с разными входными данными
+type Session interface {
+	// Run запускает программу с входными данными. Нулевой timeout - таймаут по умолчанию
+	Run(ctx context.Context, inputs []string, timeout time.Duration) (*ExecutionResult, error)
+	// Close освобождает ресурсы сессии (временные файлы, контейнер)
+	Close() error
+}
+
+// SessionExecutor - исполнитель, который умеет компилировать программу один раз
+// и запускать её на нескольких тестах
+type SessionExecutor interface {
+	Executor
+	// Prepare компилирует программу. Если компиляция не удалась (или не найден
+	// компилятор), возвращается nil-сессия и результат с описанием ошибки
+	Prepare(ctx context.Context, req ExecutionRequest) (Session, *ExecutionResult, error)
+}
+
+// PrepareSession готовит сессию на любом исполнителе. Для исполнителей без
+// поддержки сессий каждый Run выполняет Execute целиком
+func PrepareSession(ctx context.Context, ex Executor, req ExecutionRequest) (Session, *ExecutionResult, error) {
+	if sessionExecutor, ok := ex.(SessionExecutor); ok {
+		return sessionExecutor.Prepare(ctx, req)
+	}
+	return &executeSession{executor: ex, req: req}, nil, nil
+}
+
+// executeSession - сессия-адаптер поверх обычного Executor
+type executeSession struct {
+	executor Executor
+	req      ExecutionRequest
+}
+
+func (s *executeSession) Run(ctx context.Context, inputs []string, timeout time.Duration) (*ExecutionResult, error) {
+	req := s.req
+	req.Inputs = inputs
+	req.Timeout = timeout
+	return s.executor.Execute(ctx, req)
+}
+
+func (s *executeSession) Close() error {
+	return nil
 }
 
 // Cleaner интерфейс для очистки ресурсов
 type Cleaner interface {
 	Cleanup()
 }
+
+// ExecutionRequest - запрос на запуск программы
+type ExecutionRequest struct {
+	Code     string   // Исходный код файла с точкой входа
+	Language string   // Язык программирования (python, go, cpp, ...)
+	Inputs   []string // Строки, передаваемые в stdin
+
+	// Files - остальные файлы программы (модули, заголовки, вспомогательные
+	// классы) и файлы преподавателя, которые кладутся рядом с кодом. Исходники
+	// с тем же расширением, что и точка входа, компилируются вместе с ней
+	Files []File
+	// EntryPoint - имя файла с точкой входа. По умолчанию - имя файла языка
+	// (с учетом file_name_pattern). Если Code пуст, точка входа берется из Files
+	EntryPoint string
+
+	// Timeout ограничивает время выполнения программы (без учета компиляции).
+	// Нулевое значение - таймаут исполнителя по умолчанию
+	Timeout time.Duration
+
+	// Stdout и Stderr, если заданы, получают вывод программы по мере его появления
+	// (полный вывод все равно возвращается в ExecutionResult). Для сессии они
+	// действуют на все запуски. Stdout и Stderr могут вызываться из разных горутин
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Stdin, если задан, заменяет Inputs: программа читает ввод из него по мере
+	// поступления (интерактивный запуск). EOF закрывает stdin программы
+	Stdin io.Reader
+}
+
+// streaming сообщает, нужно ли передавать вывод по мере его появления
+func (r ExecutionRequest) streaming() bool {
+	return r.Stdout != nil || r.Stderr != nil || r.Stdin != nil
+}
+
+// streamingEnv - окружение запусков с выводом в реальном времени: без
+// буферизации вывод Python виден сразу, а не при завершении программы
+var streamingEnv = []string{"PYTHONUNBUFFERED=1"}
+
+// runStreams - ввод и вывод запуска в реальном времени (поля могут быть nil)
+type runStreams struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// streams возвращает потоки запуска из запроса
+func (r ExecutionRequest) streams() runStreams {
+	return runStreams{stdin: r.Stdin, stdout: r.Stdout, stderr: r.Stderr}
+}
+
+// ExecutionResult - результат запуска программы.
+// Ошибки окружения (нет компилятора, не удалось создать файл) тоже
+// возвращаются как результат с текстом в Stderr, а error из Execute
+// означает сбой самого исполнителя
+type ExecutionResult struct {
+	Stdout          string        `json:"stdout"`
+	Stderr          string        `json:"stderr"`
+	ExitCode        int           `json:"exit_code"`
+	CompileOutput   string        `json:"compile_output,omitempty"`   // Вывод компилятора
+	CompileFailed   bool          `json:"compile_failed,omitempty"`   // Программа не скомпилировалась и не запускалась
+	WallTime        time.Duration `json:"wall_time"`                  // Реальное время выполнения
+	CPUTime         time.Duration `json:"cpu_time"`                   // Процессорное время (user + sys)
+	PeakMemory      int64         `json:"peak_memory"`                // Пиковое потребление памяти в байтах
+	TimedOut        bool          `json:"timed_out,omitempty"`        // Программа остановлена по таймауту
+	OOMKilled       bool          `json:"oom_killed,omitempty"`       // Программа остановлена из-за лимита памяти
+	StdoutTruncated bool          `json:"stdout_truncated,omitempty"` // Stdout превысил лимит вывода и обрезан
+	StderrTruncated bool          `json:"stderr_truncated,omitempty"` // Stderr превысил лимит вывода и обрезан
+}
+
+// Success возвращает true, если программа скомпилировалась, уложилась в лимиты и завершилась с кодом 0
+func (r *ExecutionResult) Success() bool {
+	return !r.CompileFailed && !r.TimedOut && !r.OOMKilled && !r.OutputLimitExceeded() && r.ExitCode == 0
+}
+
+// OutputLimitExceeded возвращает true, если программа остановлена из-за лимита вывода
+func (r *ExecutionResult) OutputLimitExceeded() bool {
+	return r.StdoutTruncated || r.StderrTruncated
+}
+
+// ErrorMessage возвращает текст ошибки для пользователя: вывод компилятора
+// при ошибке компиляции, иначе stderr программы
+func (r *ExecutionResult) ErrorMessage() string {
+	if r.CompileFailed {
+		return "Compilation error: " + r.CompileOutput
+	}
+	return strings.TrimSpace(r.Stderr)
+}
+
+// errorResult формирует результат для ошибки окружения
+func errorResult(message string) *ExecutionResult {
+	return &ExecutionResult{
+		Stderr:   message,
+		ExitCode: 1,
+	}
+}
+
+// compileErrorResult формирует результат для ошибки компиляции
+func compileErrorResult(compileOutput string) *ExecutionResult {
+	return &ExecutionResult{
+		CompileOutput: compileOutput,
+		CompileFailed: true,
+		ExitCode:      1,
+	}
+}
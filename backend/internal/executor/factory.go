@@ -5,8 +5,46 @@ import (
 	"os"
 )
 
-// NewExecutor создает исполнитель кода
+// NewExecutor создает исполнитель кода с лимитом одновременных запусков
+// (LimiterConfigFromEnv). Режим выбирается переменной EXECUTOR_MODE: docker,
+// sandbox или local. Если она не задана, в продакшене используется Docker,
+// иначе - локальный запуск
 func NewExecutor() Executor {
+	ex := newBackend()
+
+	cfg := LimiterConfigFromEnv()
+	if cfg.MaxConcurrent == 0 {
+		log.Printf("⚠️ Executor concurrency limit is disabled")
+		return ex
+	}
+	log.Printf("🚦 Executor concurrency limit: %d total, %d per user, queue %d",
+		cfg.MaxConcurrent, cfg.MaxPerUser, cfg.QueueSize)
+	return NewLimitedExecutor(ex, cfg)
+}
+
+// newBackend создает исполнитель, выбранный в EXECUTOR_MODE
+func newBackend() Executor {
+	switch os.Getenv("EXECUTOR_MODE") {
+	case "docker":
+		dockerExecutor, err := NewDockerExecutor()
+		if err != nil {
+			log.Fatalf("❌ Docker executor requested but not available: %v", err)
+		}
+		log.Printf("✅ DockerExecutor initialized")
+		return dockerExecutor
+	case "sandbox":
+		// Без изоляции не запускаем: тихий откат на local был бы небезопасен
+		sandboxExecutor, err := NewSandboxExecutor(SandboxConfigFromEnv())
+		if err != nil {
+			log.Fatalf("❌ Sandbox executor requested but not available: %v", err)
+		}
+		log.Printf("✅ Sandboxed LocalExecutor initialized")
+		return sandboxExecutor
+	case "local":
+		log.Printf("🔄 Running in local execution mode")
+		return NewLocalExecutor()
+	}
+
 	// В продакшене используем Docker если доступен
 	if os.Getenv("ENVIRONMENT") == "production" {
 		dockerExecutor, err := NewDockerExecutor()
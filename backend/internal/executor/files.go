@@ -0,0 +1,150 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"backend/internal/languages"
+)
+
+// maxFiles ограничивает число файлов одного запуска
+const maxFiles = 64
+
+// fileNameRe - допустимое имя файла: латиница, цифры, '_', '-', '.' и '/' для
+// поддиректорий. Имена подставляются в shell-команды компиляции без кавычек
+var fileNameRe = regexp.MustCompile(`^[A-Za-z0-9_.\-/]+$`)
+
+// File - файл запуска рядом с основным кодом
+type File struct {
+	Name    string // Путь относительно рабочей директории ("utils.py", "lib/math.h")
+	Content string
+	// ReadOnly - файл преподавателя: решение не может подменить его своим
+	// файлом с тем же именем. Файл записывается с правами только на чтение
+	ReadOnly bool
+}
+
+// ValidateFileName проверяет, что имя файла - относительный путь внутри
+// рабочей директории из допустимых символов
+func ValidateFileName(name string) error {
+	if name == "" {
+		return fmt.Errorf("file name is required")
+	}
+	if !fileNameRe.MatchString(name) {
+		return fmt.Errorf("invalid file name %q: only latin letters, digits, '_', '-', '.' and '/' are allowed", name)
+	}
+	if strings.HasPrefix(name, "/") || path.Clean(name) != name {
+		return fmt.Errorf("invalid file name %q: must be a relative path", name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." || strings.HasPrefix(part, ".") {
+			return fmt.Errorf("invalid file name %q: hidden files and '..' are not allowed", name)
+		}
+	}
+	return nil
+}
+
+// resolveFiles приводит многофайловый запрос к виду, общему для исполнителей:
+// Code - содержимое файла с точкой входа, EntryPoint - его имя, Files -
+// остальные файлы. Если Code пуст, точка входа берется из Files (по EntryPoint
+// или имени файла языка по умолчанию). Ошибка описывает неверный запрос для пользователя
+func resolveFiles(req ExecutionRequest, lang *languages.Language) (ExecutionRequest, error) {
+	if len(req.Files) > maxFiles {
+		return req, fmt.Errorf("too many files: %d (max %d)", len(req.Files), maxFiles)
+	}
+
+	entry := req.EntryPoint
+	if entry == "" {
+		if req.Code != "" {
+			entry = lang.SourceFile(req.Code)
+		} else {
+			entry = lang.FileName
+		}
+	}
+	if err := ValidateFileName(entry); err != nil {
+		return req, fmt.Errorf("entry point: %w", err)
+	}
+	if strings.Contains(entry, "/") {
+		return req, fmt.Errorf("entry point %s must be in the root directory", entry)
+	}
+
+	readOnly := make(map[string]bool)
+	for _, file := range req.Files {
+		if file.ReadOnly {
+			readOnly[file.Name] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	var files []File
+	entryFound := req.Code != ""
+	for _, file := range req.Files {
+		if err := ValidateFileName(file.Name); err != nil {
+			return req, err
+		}
+		if seen[file.Name] {
+			if readOnly[file.Name] {
+				return req, fmt.Errorf("file %s is provided by the task and cannot be replaced", file.Name)
+			}
+			return req, fmt.Errorf("duplicate file %s", file.Name)
+		}
+		seen[file.Name] = true
+
+		if file.Name == entry {
+			if file.ReadOnly {
+				// Программа целиком от преподавателя, решение - остальные файлы
+				if req.Code != "" {
+					return req, fmt.Errorf("file %s is provided by the task and cannot be replaced", entry)
+				}
+			} else if req.Code != "" {
+				return req, fmt.Errorf("duplicate file %s", file.Name)
+			}
+			req.Code = file.Content
+			entryFound = true
+			continue
+		}
+		files = append(files, file)
+	}
+	if !entryFound {
+		return req, fmt.Errorf("entry point %s not found", entry)
+	}
+
+	req.EntryPoint = entry
+	req.Files = files
+	return req, nil
+}
+
+// sourceNames возвращает файл с точкой входа и остальные файлы с тем же
+// расширением - исходники, которые передаются компилятору
+func sourceNames(entry string, files []File) []string {
+	sources := []string{entry}
+	ext := filepath.Ext(entry)
+	for _, file := range files {
+		if ext != "" && filepath.Ext(file.Name) == ext {
+			sources = append(sources, file.Name)
+		}
+	}
+	return sources
+}
+
+// writeFiles записывает файлы запуска в dir, создавая поддиректории.
+// Файлы преподавателя доступны только для чтения
+func writeFiles(dir string, files []File) error {
+	for _, file := range files {
+		target := filepath.Join(dir, filepath.FromSlash(file.Name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		mode := os.FileMode(0644)
+		if file.ReadOnly {
+			mode = 0444
+		}
+		if err := os.WriteFile(target, []byte(file.Content), mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,359 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Ошибки переполнения лимитера. Исполнитель при этом программу не запускал
+var (
+	// ErrServerBusy - очередь запусков заполнена или ожидание в ней превысило лимит
+	ErrServerBusy = errors.New("executor is busy")
+	// ErrUserBusy - у пользователя слишком много запусков в очереди
+	ErrUserBusy = errors.New("too many executions for user")
+)
+
+// IsBusy сообщает, что запуск отклонен лимитером, а не завершился ошибкой
+func IsBusy(err error) bool {
+	return errors.Is(err, ErrServerBusy) || errors.Is(err, ErrUserBusy)
+}
+
+// LimiterConfig - ограничения одновременных запусков
+type LimiterConfig struct {
+	MaxConcurrent    int           // Программ одновременно на сервере (0 - без лимитера)
+	MaxPerUser       int           // Программ одновременно у одного пользователя
+	QueueSize        int           // Сколько запусков может ждать в очереди
+	MaxQueuedPerUser int           // Сколько запусков одного пользователя может ждать в очереди
+	QueueTimeout     time.Duration // Сколько запуск ждет в очереди, прежде чем получит ErrServerBusy
+}
+
+// DefaultLimiterConfig возвращает ограничения по умолчанию: по одной программе на ядро
+func DefaultLimiterConfig() LimiterConfig {
+	return LimiterConfig{
+		MaxConcurrent:    runtime.NumCPU(),
+		MaxPerUser:       2,
+		QueueSize:        100,
+		MaxQueuedPerUser: 3,
+		QueueTimeout:     60 * time.Second,
+	}
+}
+
+// LimiterConfigFromEnv читает ограничения из переменных окружения:
+// EXECUTOR_MAX_CONCURRENT, EXECUTOR_MAX_PER_USER, EXECUTOR_QUEUE_SIZE,
+// EXECUTOR_MAX_QUEUED_PER_USER, EXECUTOR_QUEUE_TIMEOUT_SECONDS
+func LimiterConfigFromEnv() LimiterConfig {
+	cfg := DefaultLimiterConfig()
+
+	// EXECUTOR_MAX_CONCURRENT=0 отключает лимитер, поэтому 0 отличаем от отсутствия переменной
+	if value, ok := os.LookupEnv("EXECUTOR_MAX_CONCURRENT"); ok {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			cfg.MaxConcurrent = n
+		}
+	}
+	if n := envInt("EXECUTOR_MAX_PER_USER"); n > 0 {
+		cfg.MaxPerUser = n
+	}
+	if n := envInt("EXECUTOR_QUEUE_SIZE"); n > 0 {
+		cfg.QueueSize = n
+	}
+	if n := envInt("EXECUTOR_MAX_QUEUED_PER_USER"); n > 0 {
+		cfg.MaxQueuedPerUser = n
+	}
+	if sec := envInt("EXECUTOR_QUEUE_TIMEOUT_SECONDS"); sec > 0 {
+		cfg.QueueTimeout = time.Duration(sec) * time.Second
+	}
+
+	return cfg
+}
+
+type (
+	userKey          struct{}
+	queueListenerKey struct{}
+	leaseKey         struct{}
+)
+
+// WithUser помечает запуски с ctx пользователем, на которого действуют
+// лимиты MaxPerUser и MaxQueuedPerUser. Без пользователя действует только общий лимит
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// WithQueueListener задает функцию, которую лимитер вызывает с местом запуска
+// в очереди (начиная с 1) каждый раз, когда оно меняется. Функция вызывается
+// только пока запуск ждет и не должна надолго блокироваться
+func WithQueueListener(ctx context.Context, listener func(position int)) context.Context {
+	return context.WithValue(ctx, queueListenerKey{}, listener)
+}
+
+// Acquire занимает место в лимитере исполнителя для нескольких запусков подряд
+// (проверка решения вместе с чекером). Запуски с возвращенным ctx новых мест
+// не занимают, поэтому не могут заблокировать друг друга. release нужно
+// вызвать после последнего запуска. Для исполнителей без лимитера Acquire ничего не делает
+func Acquire(ctx context.Context, ex Executor) (context.Context, func(), error) {
+	if limited, ok := ex.(*LimitedExecutor); ok {
+		return limited.limiter.acquire(ctx)
+	}
+	return ctx, func() {}, nil
+}
+
+// limiterWaiter - запуск, ожидающий в очереди
+type limiterWaiter struct {
+	user     string
+	ready    chan struct{} // Закрывается, когда запуску выдано место
+	granted  bool
+	position int
+	listener func(position int)
+}
+
+// limiter ограничивает число одновременных запусков. Свободное место получает
+// ожидающий запуск пользователя, у которого сейчас меньше всего программ,
+// а среди равных - пришедший раньше, поэтому один пользователь не может
+// занять сервер, отправив много запусков подряд
+type limiter struct {
+	config LimiterConfig
+
+	mu      sync.Mutex
+	running int
+	perUser map[string]int // Выполняющиеся запуски по пользователям
+	queued  map[string]int // Ожидающие запуски по пользователям
+	waiters []*limiterWaiter
+}
+
+func newLimiter(config LimiterConfig) *limiter {
+	if config.MaxPerUser <= 0 || config.MaxPerUser > config.MaxConcurrent {
+		config.MaxPerUser = config.MaxConcurrent
+	}
+	return &limiter{
+		config:  config,
+		perUser: make(map[string]int),
+		queued:  make(map[string]int),
+	}
+}
+
+// acquire ждет свободного места и возвращает ctx, запуски с которым места
+// больше не занимают, и функцию освобождения места
+func (l *limiter) acquire(ctx context.Context) (context.Context, func(), error) {
+	if ctx.Value(leaseKey{}) == l {
+		return ctx, func() {}, nil
+	}
+
+	user, _ := ctx.Value(userKey{}).(string)
+	listener, _ := ctx.Value(queueListenerKey{}).(func(int))
+	w := &limiterWaiter{user: user, ready: make(chan struct{}), listener: listener}
+
+	l.mu.Lock()
+	if len(l.waiters) >= l.config.QueueSize {
+		l.mu.Unlock()
+		log.Printf("🚦 Execution queue is full (%d waiting)", l.config.QueueSize)
+		return nil, nil, ErrServerBusy
+	}
+	if user != "" && l.queued[user] >= l.config.MaxQueuedPerUser {
+		l.mu.Unlock()
+		return nil, nil, ErrUserBusy
+	}
+	l.waiters = append(l.waiters, w)
+	l.queued[user]++
+	notify := l.dispatch()
+	l.mu.Unlock()
+	notifyWaiters(notify)
+
+	timer := time.NewTimer(l.config.QueueTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer.C:
+		err = ErrServerBusy
+	}
+
+	if err != nil {
+		l.mu.Lock()
+		if w.granted {
+			// Место выдано одновременно с отменой - возвращаем его
+			l.mu.Unlock()
+			l.release(user)
+		} else {
+			l.remove(w)
+			notify := l.dispatch()
+			l.mu.Unlock()
+			notifyWaiters(notify)
+		}
+		if err == ErrServerBusy {
+			log.Printf("🚦 Execution waited in queue longer than %v", l.config.QueueTimeout)
+		}
+		return nil, nil, err
+	}
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() { l.release(user) })
+	}
+	return context.WithValue(ctx, leaseKey{}, l), release, nil
+}
+
+// release освобождает место и передает его следующему в очереди
+func (l *limiter) release(user string) {
+	l.mu.Lock()
+	l.running--
+	if l.perUser[user]--; l.perUser[user] <= 0 {
+		delete(l.perUser, user)
+	}
+	notify := l.dispatch()
+	l.mu.Unlock()
+	notifyWaiters(notify)
+}
+
+// queueNotice - новое место запуска в очереди для его слушателя
+type queueNotice struct {
+	listener func(position int)
+	position int
+}
+
+// dispatch выдает свободные места ожидающим запускам и возвращает уведомления
+// для тех, чье место в очереди изменилось. Вызывается под l.mu
+func (l *limiter) dispatch() []queueNotice {
+	for l.running < l.config.MaxConcurrent {
+		next := -1
+		for i, w := range l.waiters {
+			// Без пользователя действует только общий лимит
+			if w.user != "" && l.perUser[w.user] >= l.config.MaxPerUser {
+				continue
+			}
+			if next < 0 || l.perUser[w.user] < l.perUser[l.waiters[next].user] {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+
+		w := l.waiters[next]
+		l.remove(w)
+		l.running++
+		l.perUser[w.user]++
+		w.granted = true
+		close(w.ready)
+	}
+
+	var notify []queueNotice
+	for i, w := range l.queueOrder() {
+		if w.position != i+1 {
+			w.position = i + 1
+			if w.listener != nil {
+				notify = append(notify, queueNotice{listener: w.listener, position: w.position})
+			}
+		}
+	}
+	return notify
+}
+
+// queueOrder возвращает ожидающие запуски в порядке, в котором dispatch выдаст
+// им места: каждое следующее место достается пользователю с наименьшим числом
+// выполняющихся и стоящих в этом порядке перед ним запусков, а среди равных -
+// пришедшему раньше. Вызывается под l.mu
+func (l *limiter) queueOrder() []*limiterWaiter {
+	counts := make(map[string]int, len(l.perUser))
+	for user, n := range l.perUser {
+		counts[user] = n
+	}
+	waiters := append([]*limiterWaiter(nil), l.waiters...)
+	order := make([]*limiterWaiter, 0, len(waiters))
+	for len(waiters) > 0 {
+		next := 0
+		for i, w := range waiters {
+			if counts[w.user] < counts[waiters[next].user] {
+				next = i
+			}
+		}
+		w := waiters[next]
+		order = append(order, w)
+		counts[w.user]++
+		waiters = append(waiters[:next], waiters[next+1:]...)
+	}
+	return order
+}
+
+// remove убирает запуск из очереди. Вызывается под l.mu
+func (l *limiter) remove(w *limiterWaiter) {
+	for i, other := range l.waiters {
+		if other == w {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			break
+		}
+	}
+	if l.queued[w.user]--; l.queued[w.user] <= 0 {
+		delete(l.queued, w.user)
+	}
+}
+
+// notifyWaiters сообщает запускам их место в очереди. Вызывается без l.mu:
+// слушатели отправляют данные клиентам
+func notifyWaiters(notices []queueNotice) {
+	for _, notice := range notices {
+		notice.listener(notice.position)
+	}
+}
+
+// LimitedExecutor ограничивает число одновременных запусков другого исполнителя.
+// Сессия занимает место от Prepare до Close
+type LimitedExecutor struct {
+	executor Executor
+	limiter  *limiter
+}
+
+// NewLimitedExecutor оборачивает исполнитель лимитером
+func NewLimitedExecutor(ex Executor, config LimiterConfig) *LimitedExecutor {
+	return &LimitedExecutor{executor: ex, limiter: newLimiter(config)}
+}
+
+// Execute ждет свободного места и выполняет код
+func (e *LimitedExecutor) Execute(ctx context.Context, req ExecutionRequest) (*ExecutionResult, error) {
+	ctx, release, err := e.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return e.executor.Execute(ctx, req)
+}
+
+// Prepare реализует SessionExecutor: место освобождается при закрытии сессии.
+// Результат обернутого исполнителя возвращается без изменений
+func (e *LimitedExecutor) Prepare(ctx context.Context, req ExecutionRequest) (Session, *ExecutionResult, error) {
+	ctx, release, err := e.limiter.acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	session, result, err := PrepareSession(ctx, e.executor, req)
+	if session == nil {
+		release()
+		return nil, result, err
+	}
+	return &limitedSession{Session: session, release: release}, result, err
+}
+
+// Cleanup освобождает ресурсы обернутого исполнителя
+func (e *LimitedExecutor) Cleanup() {
+	if cleaner, ok := e.executor.(Cleaner); ok {
+		cleaner.Cleanup()
+	}
+}
+
+// limitedSession освобождает место в лимитере при закрытии
+type limitedSession struct {
+	Session
+	release func()
+}
+
+func (s *limitedSession) Close() error {
+	defer s.release()
+	return s.Session.Close()
+}
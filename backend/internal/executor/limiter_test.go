@@ -0,0 +1,389 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// grant - место, выданное запуску из очереди
+type grant struct {
+	label   string
+	release func()
+}
+
+// acquireAs занимает место от имени пользователя - первой буквы label ("a1" - пользователь a)
+func acquireAs(l *limiter, label string) (func(), error) {
+	_, release, err := l.acquire(WithUser(context.Background(), label[:1]))
+	return release, err
+}
+
+// enqueue ставит запуск в очередь и ждет, пока он займет в ней место, чтобы
+// порядок прихода запусков был определен
+func enqueue(t *testing.T, l *limiter, label string, granted chan<- grant) {
+	t.Helper()
+	l.mu.Lock()
+	before := len(l.waiters)
+	l.mu.Unlock()
+
+	go func() {
+		release, err := acquireAs(l, label)
+		if err != nil {
+			t.Errorf("%s: %v", label, err)
+			return
+		}
+		granted <- grant{label: label, release: release}
+	}()
+
+	waitQueued(t, l, before+1)
+}
+
+// waitQueued ждет, пока в очереди окажется n запусков
+func waitQueued(t *testing.T, l *limiter, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		l.mu.Lock()
+		queued := len(l.waiters)
+		l.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d executions in queue, want %d", queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLimiterFairOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  LimiterConfig
+		running []string // Запуски, занявшие места до очереди
+		queued  []string // Очередь в порядке прихода
+		want    []string // Порядок выдачи мест, когда запуски по одному освобождают места
+	}{
+		{
+			name:    "one user in arrival order",
+			config:  LimiterConfig{MaxConcurrent: 1, MaxPerUser: 1},
+			running: []string{"a0"},
+			queued:  []string{"a1", "a2", "a3"},
+			want:    []string{"a1", "a2", "a3"},
+		},
+		{
+			name:    "user with fewer running programs goes first",
+			config:  LimiterConfig{MaxConcurrent: 2, MaxPerUser: 2},
+			running: []string{"a0", "a1"},
+			queued:  []string{"a2", "a3", "b1"},
+			want:    []string{"b1", "a2", "a3"},
+		},
+		{
+			name:    "users alternate",
+			config:  LimiterConfig{MaxConcurrent: 2, MaxPerUser: 2},
+			running: []string{"a0", "b0"},
+			queued:  []string{"a1", "a2", "a3", "b1", "b2"},
+			want:    []string{"a1", "b1", "a2", "b2", "a3"},
+		},
+		{
+			name:    "per user limit skips the head of the queue",
+			config:  LimiterConfig{MaxConcurrent: 2, MaxPerUser: 1},
+			running: []string{"a0", "c0"},
+			queued:  []string{"a1", "a2", "c1"},
+			want:    []string{"a1", "c1", "a2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.QueueSize = 100
+			tt.config.MaxQueuedPerUser = 100
+			tt.config.QueueTimeout = 10 * time.Second
+			l := newLimiter(tt.config)
+
+			var running []grant
+			for _, label := range tt.running {
+				release, err := acquireAs(l, label)
+				if err != nil {
+					t.Fatalf("%s: %v", label, err)
+				}
+				running = append(running, grant{label: label, release: release})
+			}
+
+			granted := make(chan grant, len(tt.queued))
+			for _, label := range tt.queued {
+				enqueue(t, l, label, granted)
+			}
+
+			// Места освобождаются в порядке их выдачи, каждое освобождение
+			// передает место ровно одному запуску
+			var order []string
+			for len(order) < len(tt.want) {
+				running[0].release()
+				running = running[1:]
+				select {
+				case g := <-granted:
+					order = append(order, g.label)
+					running = append(running, g)
+				case <-time.After(time.Second):
+					t.Fatalf("no execution got a slot, order so far %v", order)
+				}
+			}
+			for _, g := range running {
+				g.release()
+			}
+
+			for i := range tt.want {
+				if order[i] != tt.want[i] {
+					t.Fatalf("order = %v, want %v", order, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  LimiterConfig
+		queued  []string
+		label   string
+		wantErr error
+	}{
+		{
+			name:    "queue is full",
+			config:  LimiterConfig{MaxConcurrent: 1, QueueSize: 2, MaxQueuedPerUser: 10, QueueTimeout: 10 * time.Second},
+			queued:  []string{"a1", "b1"},
+			label:   "c1",
+			wantErr: ErrServerBusy,
+		},
+		{
+			name:    "too many queued for user",
+			config:  LimiterConfig{MaxConcurrent: 1, QueueSize: 10, MaxQueuedPerUser: 2, QueueTimeout: 10 * time.Second},
+			queued:  []string{"a1", "a2"},
+			label:   "a3",
+			wantErr: ErrUserBusy,
+		},
+		{
+			name:    "queue timeout",
+			config:  LimiterConfig{MaxConcurrent: 1, QueueSize: 10, MaxQueuedPerUser: 10, QueueTimeout: 20 * time.Millisecond},
+			label:   "a1",
+			wantErr: ErrServerBusy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLimiter(tt.config)
+			release, err := acquireAs(l, "z0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			granted := make(chan grant, len(tt.queued))
+			for _, label := range tt.queued {
+				enqueue(t, l, label, granted)
+			}
+
+			_, err = acquireAs(l, tt.label)
+			if !errors.Is(err, tt.wantErr) || !IsBusy(err) {
+				t.Errorf("acquire error = %v, want %v", err, tt.wantErr)
+			}
+
+			// Отклоненный запуск не остается в очереди
+			release()
+			for range tt.queued {
+				g := <-granted
+				g.release()
+			}
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.running != 0 || len(l.waiters) != 0 || len(l.queued) != 0 || len(l.perUser) != 0 {
+				t.Errorf("limiter is not empty: running=%d waiters=%d queued=%v perUser=%v",
+					l.running, len(l.waiters), l.queued, l.perUser)
+			}
+		})
+	}
+}
+
+func TestLimiterCancelWhileQueued(t *testing.T) {
+	l := newLimiter(LimiterConfig{MaxConcurrent: 1, QueueSize: 10, MaxQueuedPerUser: 10, QueueTimeout: 10 * time.Second})
+	release, err := acquireAs(l, "a0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(WithUser(context.Background(), "b"))
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := l.acquire(ctx)
+		done <- err
+	}()
+	waitQueued(t, l, 1)
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("acquire error = %v, want context.Canceled", err)
+	}
+
+	// Место после отмененного запуска достается следующему
+	granted := make(chan grant, 1)
+	enqueue(t, l, "c1", granted)
+	release()
+	select {
+	case g := <-granted:
+		g.release()
+	case <-time.After(time.Second):
+		t.Fatal("slot was not passed to the next execution")
+	}
+}
+
+func TestLimiterLeaseAndPositions(t *testing.T) {
+	l := newLimiter(LimiterConfig{MaxConcurrent: 1, QueueSize: 10, MaxQueuedPerUser: 10, QueueTimeout: 10 * time.Second})
+	ctx, release, err := l.acquire(WithUser(context.Background(), "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Запуски с ctx занятого места новых мест не ждут
+	_, nestedRelease, err := l.acquire(ctx)
+	if err != nil {
+		t.Fatalf("nested acquire: %v", err)
+	}
+	nestedRelease()
+
+	var mu sync.Mutex
+	var positions []int
+	listenerCtx := WithQueueListener(WithUser(context.Background(), "b"), func(position int) {
+		mu.Lock()
+		positions = append(positions, position)
+		mu.Unlock()
+	})
+
+	granted := make(chan grant, 1)
+	enqueue(t, l, "c1", granted)
+	done := make(chan func(), 1)
+	go func() {
+		_, release, err := l.acquire(listenerCtx)
+		if err != nil {
+			t.Error(err)
+			close(done)
+			return
+		}
+		done <- release
+	}()
+	waitQueued(t, l, 2)
+
+	release()
+	g := <-granted
+	g.release()
+	if release, ok := <-done; ok {
+		release()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(positions) != 2 || positions[0] != 2 || positions[1] != 1 {
+		t.Errorf("queue positions = %v, want [2 1]", positions)
+	}
+}
+
+func TestLimiterQueuePositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  LimiterConfig
+		running []string
+		queued  []string
+		want    map[string]int // Место в очереди после прихода всех запусков
+	}{
+		{
+			name:    "one user in arrival order",
+			config:  LimiterConfig{MaxConcurrent: 1, MaxPerUser: 1},
+			running: []string{"a0"},
+			queued:  []string{"a1", "a2"},
+			want:    map[string]int{"a1": 1, "a2": 2},
+		},
+		{
+			name:    "user without running programs goes first",
+			config:  LimiterConfig{MaxConcurrent: 1, MaxPerUser: 1},
+			running: []string{"a0"},
+			queued:  []string{"a1", "a2", "b1"},
+			want:    map[string]int{"b1": 1, "a1": 2, "a2": 3},
+		},
+		{
+			name:    "users alternate",
+			config:  LimiterConfig{MaxConcurrent: 2, MaxPerUser: 2},
+			running: []string{"a0", "b0"},
+			queued:  []string{"a1", "a2", "a3", "b1", "b2"},
+			want:    map[string]int{"a1": 1, "b1": 2, "a2": 3, "b2": 4, "a3": 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.QueueSize = 100
+			tt.config.MaxQueuedPerUser = 100
+			tt.config.QueueTimeout = 10 * time.Second
+			l := newLimiter(tt.config)
+
+			var releases []func()
+			for _, label := range tt.running {
+				release, err := acquireAs(l, label)
+				if err != nil {
+					t.Fatalf("%s: %v", label, err)
+				}
+				releases = append(releases, release)
+			}
+
+			var mu sync.Mutex
+			positions := make(map[string]int)
+			granted := make(chan func(), len(tt.queued))
+			for i, label := range tt.queued {
+				label := label
+				ctx := WithQueueListener(WithUser(context.Background(), label[:1]), func(position int) {
+					mu.Lock()
+					positions[label] = position
+					mu.Unlock()
+				})
+				go func() {
+					_, release, err := l.acquire(ctx)
+					if err != nil {
+						t.Errorf("%s: %v", label, err)
+						release = func() {}
+					}
+					granted <- release
+				}()
+				waitQueued(t, l, i+1)
+			}
+
+			// Слушатели вызываются после того, как запуск встал в очередь
+			deadline := time.Now().Add(time.Second)
+			for {
+				mu.Lock()
+				got := reflect.DeepEqual(positions, tt.want)
+				mu.Unlock()
+				if got {
+					break
+				}
+				if time.Now().After(deadline) {
+					mu.Lock()
+					t.Errorf("queue positions = %v, want %v", positions, tt.want)
+					mu.Unlock()
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			for _, release := range releases {
+				release()
+			}
+			for range tt.queued {
+				release := <-granted
+				release()
+			}
+		})
+	}
+}
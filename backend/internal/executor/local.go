@@ -2,432 +2,406 @@ package executor
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strings"
 	"time"
+
+	"backend/internal/languages"
+)
+
+const (
+	// compileTimeout ограничивает время работы компиляторов (g++, javac)
+	compileTimeout = 30 * time.Second
+	// defaultRunTimeout - таймаут выполнения, если в запросе он не задан
+	defaultRunTimeout = 15 * time.Second
 )
 
 type LocalExecutor struct {
 	tempDir string
+	sandbox *SandboxConfig // Ограничения песочницы, nil - запуск без изоляции
+	output  OutputLimits   // Лимиты размера вывода программ
 }
 
 func NewLocalExecutor() *LocalExecutor {
 	tempDir := filepath.Join(os.TempDir(), "code_executor")
 	os.MkdirAll(tempDir, 0755)
+
 	return &LocalExecutor{
 		tempDir: tempDir,
+		output:  OutputLimitsFromEnv(),
 	}
 }
 
-func (e *LocalExecutor) Execute(code, language string, inputs []string) (map[string]interface{}, error) {
-	log.Printf("🎯 LocalExecutor executing %s code, length: %d chars, inputs: %v", language, len(code), inputs)
-
-	switch strings.ToLower(language) {
-	case "go":
-		return e.executeGo(code, inputs)
-	case "python", "python3":
-		return e.executePython(code, inputs)
-	case "javascript", "node":
-		return e.executeJavaScript(code, inputs)
-	case "cpp", "c++":
-		return e.executeCpp()
-	case "java":
-		return e.executeJava()
-	default:
-		return map[string]interface{}{
-			"output":   "",
-			"error":    "Unsupported language: " + language,
-			"exitCode": 1,
-		}, nil
-	}
-}
+func (e *LocalExecutor) Execute(ctx context.Context, req ExecutionRequest) (*ExecutionResult, error) {
+	log.Printf("🎯 LocalExecutor executing %s code, length: %d chars, inputs: %v", req.Language, len(req.Code), req.Inputs)
 
-func (e *LocalExecutor) executePython(code string, inputs []string) (map[string]interface{}, error) {
-	log.Printf("🐍 Executing Python code, length: %d chars, inputs: %v", len(code), inputs)
-
-	// Определяем команду Python
-	cmdName := e.findPythonCommand()
-	if cmdName == "" {
-		errorMsg := "Python not found. Please install Python and make sure it's in PATH"
-		log.Printf("❌ %s", errorMsg)
-		return map[string]interface{}{
-			"output":   "",
-			"error":    errorMsg,
-			"exitCode": 1,
-		}, nil
+	session, result, err := e.Prepare(ctx, req)
+	if err != nil || result != nil {
+		return result, err
 	}
+	defer session.Close()
 
-	log.Printf("🔧 Using Python command: %s", cmdName)
-
-	// Создаем временный файл для Python кода
-	tmpFile := filepath.Join(e.tempDir, "script_"+fmt.Sprintf("%d", time.Now().UnixNano())+".py")
+	return session.Run(ctx, req.Inputs, req.Timeout)
+}
 
-	// Записываем код в файл
-	err := os.WriteFile(tmpFile, []byte(code), 0644)
+// Prepare реализует SessionExecutor: создает рабочую директорию и компилирует
+// программу, после чего её можно запускать на разных входных данных
+func (e *LocalExecutor) Prepare(ctx context.Context, req ExecutionRequest) (Session, *ExecutionResult, error) {
+	lang, ok := languages.Lookup(req.Language)
+	if !ok {
+		return nil, errorResult("Unsupported language: " + req.Language), nil
+	}
+	req, err := resolveFiles(req, lang)
 	if err != nil {
-		log.Printf("❌ Failed to write Python file: %v", err)
-		return map[string]interface{}{
-			"output":   "",
-			"error":    fmt.Sprintf("Error creating file: %v", err),
-			"exitCode": 1,
-		}, nil
+		return nil, errorResult(err.Error()), nil
 	}
-	defer func() {
-		if err := os.Remove(tmpFile); err != nil {
-			log.Printf("⚠️ Failed to remove temp file %s: %v", tmpFile, err)
-		}
-	}()
-
-	// Создаем команду для выполнения Python файла
-	cmd := exec.Command(cmdName, tmpFile)
 
-	// Подготавливаем входные данные
-	var stdin bytes.Buffer
-	if len(inputs) > 0 {
-		// Если inputs это массив строк, объединяем их с переносами строк
-		fullInput := strings.Join(inputs, "\n") + "\n"
-		stdin.WriteString(fullInput)
-		log.Printf("📥 Sending input to Python: %q", fullInput)
-	} else {
-		log.Printf("📥 No input provided for Python")
+	// Создаем рабочую директорию для запуска
+	runDir, err := e.createRunDir(lang.ID)
+	if err != nil {
+		return nil, errorResult(fmt.Sprintf("Error creating directory: %v", err)), nil
+	}
+	// Файл с точкой входа записывает подготовка языка, остальные файлы - общие
+	if err := writeFiles(runDir, req.Files); err != nil {
+		log.Printf("❌ Failed to write files: %v", err)
+		e.removeRunDir(runDir)
+		return nil, errorResult(fmt.Sprintf("Error creating file: %v", err)), nil
 	}
 
-	cmd.Stdin = &stdin
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	session, result, err := e.prepareConfigured(ctx, req, lang, runDir)
+	if session == nil {
+		e.removeRunDir(runDir)
+		return nil, result, err
+	}
+	session.defaultTimeout = lang.TimeLimit()
+	session.streams = req.streams()
+	if req.streaming() {
+		session.env = streamingEnv
+	}
+	return session, nil, nil
+}
 
-	// Устанавливаем таймаут выполнения (15 секунд)
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Run()
-	}()
+// prepareConfigured собирает программу командами компиляции и запуска из реестра
+// языков. От локального окружения зависят только поиск интерпретатора
+// (findCommand) и ограничение кучи node и java в песочнице. Компилятора или
+// интерпретатора нет - ошибка окружения, а не решения
+func (e *LocalExecutor) prepareConfigured(ctx context.Context, req ExecutionRequest, lang *languages.Language, runDir string) (*localSession, *ExecutionResult, error) {
+	log.Printf("🧩 Preparing %s code, length: %d chars", lang.Name, len(req.Code))
+
+	fileName := req.EntryPoint
+	if err := os.WriteFile(filepath.Join(runDir, fileName), []byte(lang.WrapCode(req.Code)), 0644); err != nil {
+		log.Printf("❌ Failed to write %s file: %v", lang.Name, err)
+		return nil, errorResult(fmt.Sprintf("Error creating file: %v", err)), nil
+	}
 
-	select {
-	case err := <-done:
-		exitCode := 0
+	// Скомпилированная программа ({dir}/program) появится после компиляции
+	command := lang.RunCommand(runDir, fileName)
+	if !filepath.IsAbs(command[0]) {
+		path, err := findCommand(command[0])
 		if err != nil {
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				exitCode = exitErr.ExitCode()
-			} else {
-				log.Printf("❌ Failed to start Python process: %v", err)
-				return map[string]interface{}{
-					"output":   "",
-					"error":    fmt.Sprintf("Failed to start Python: %v", err),
-					"exitCode": 1,
-				}, nil
-			}
-			log.Printf("⚠️ Python execution completed with exit code %d", exitCode)
-		} else {
-			log.Printf("✅ Python execution completed successfully")
+			return nil, nil, err
 		}
+		command[0] = path
+	}
 
-		outputStr := strings.TrimSpace(stdout.String())
-		errorStr := strings.TrimSpace(stderr.String())
-
-		result := map[string]interface{}{
-			"output":   outputStr,
-			"error":    errorStr,
-			"exitCode": exitCode,
+	var compileOutput string
+	if compileArgs := compileArgs(lang, runDir, fileName, sourceNames(fileName, req.Files)); compileArgs != nil {
+		compiler, err := findCommand(compileArgs[0])
+		if err != nil {
+			return nil, nil, err
 		}
-
-		log.Printf("📊 Python execution result - Output: %q", outputStr)
-		log.Printf("📊 Python execution result - Error: %q", errorStr)
-
-		return result, nil
-
-	case <-time.After(15 * time.Second):
-		// Таймаут - убиваем процесс
-		log.Printf("⏰ Python execution timeout (15 seconds)")
-		if cmd.Process != nil {
-			cmd.Process.Kill()
+		log.Printf("🔨 Compiling %s code: %s", lang.Name, strings.Join(compileArgs, " "))
+		output, ok, err := e.compile(ctx, lang.Name, runDir, compiler, compileArgs[1:]...)
+		if err != nil {
+			return nil, nil, err
 		}
-		return map[string]interface{}{
-			"output":   "",
-			"error":    "Execution timeout (15 seconds)",
-			"exitCode": 1,
-		}, nil
+		// Временная директория в сообщениях компилятора ученику не нужна
+		output = strings.ReplaceAll(output, runDir+string(filepath.Separator), "")
+		if !ok {
+			return nil, compileErrorResult(output), nil
+		}
+		compileOutput = output
 	}
-}
 
-// Остальные методы остаются без изменений
-func (e *LocalExecutor) executeGo(code string, inputs []string) (map[string]interface{}, error) {
-	log.Printf("🔵 Executing Go code, length: %d chars, inputs: %v", len(code), inputs)
-
-	// Создаем временный файл
-	tmpFile := filepath.Join(e.tempDir, "main_"+fmt.Sprintf("%d", time.Now().UnixNano())+".go")
+	args := append(e.heapLimitArgs(filepath.Base(command[0])), command[1:]...)
+	return &localSession{executor: e, langName: lang.Name, runDir: runDir, name: command[0], args: args, compileOutput: compileOutput}, nil, nil
+}
 
-	// Если код не содержит package main, добавляем его
-	fullCode := code
-	if !strings.Contains(code, "package main") {
-		fullCode = "package main\n\n" + code
+// compileArgs возвращает команду компиляции языка: компилятор с аргументами,
+// а если команде нужен shell - sh -c с командой. nil - язык не компилируется
+func compileArgs(lang *languages.Language, runDir, fileName string, sources []string) []string {
+	if args, ok := lang.CompileArgs(runDir, fileName, sources...); ok {
+		return args
 	}
-
-	// Если нет функции main, добавляем простую обертку
-	if !strings.Contains(code, "func main()") {
-		fullCode = fullCode + "\n\nfunc main() {\n\t// Ваш код будет выполнен здесь\n}"
+	if command := lang.CompileCommand(runDir, fileName, sources...); command != "" {
+		return []string{"sh", "-c", command}
 	}
+	return nil
+}
 
-	log.Printf("📝 Writing Go code to: %s", tmpFile)
-	err := os.WriteFile(tmpFile, []byte(fullCode), 0644)
-	if err != nil {
-		log.Printf("❌ Failed to write Go file: %v", err)
-		return map[string]interface{}{
-			"output":   "",
-			"error":    fmt.Sprintf("Error creating file: %v", err),
-			"exitCode": 1,
-		}, nil
-	}
-	defer func() {
-		if err := os.Remove(tmpFile); err != nil {
-			log.Printf("⚠️ Failed to remove temp file %s: %v", tmpFile, err)
-		}
-	}()
+// commandAlternatives - другие имена программ из реестра, которые ищутся, если
+// программы нет в PATH: например, python3 в Windows называется python или py
+var commandAlternatives = map[string][]string{
+	"python3": {"python", "py"},
+}
 
-	// Компилируем и запускаем
-	outputFile := tmpFile + ".exe"
-	if runtime.GOOS != "windows" {
-		outputFile = tmpFile + ".out"
+// findCommand ищет программу из реестра языков в PATH. Имена с альтернативами
+// проверяются запуском --version: в Windows python может оказаться заглушкой,
+// открывающей магазин приложений
+func findCommand(name string) (string, error) {
+	alternatives, verify := commandAlternatives[name]
+	for _, candidate := range append([]string{name}, alternatives...) {
+		path, err := exec.LookPath(candidate)
+		if err != nil {
+			continue
+		}
+		if verify {
+			versionCmd := exec.Command(path, "--version")
+			output, err := versionCmd.CombinedOutput()
+			if err != nil {
+				continue
+			}
+			log.Printf("✅ Found %s at %s: %s", candidate, path, strings.TrimSpace(string(output)))
+		}
+		return path, nil
 	}
+	log.Printf("❌ %s is not installed or not in PATH", name)
+	return "", fmt.Errorf("%s is not installed or not in PATH", name)
+}
 
-	// Компиляция
-	log.Printf("🔨 Compiling Go code...")
-	compileCmd := exec.Command("go", "build", "-o", outputFile, tmpFile)
-	var compileStdout, compileStderr bytes.Buffer
-	compileCmd.Stdout = &compileStdout
-	compileCmd.Stderr = &compileStderr
+// localSession - скомпилированная программа в рабочей директории запуска
+type localSession struct {
+	executor       *LocalExecutor
+	langName       string
+	runDir         string
+	name           string        // Программа или интерпретатор
+	args           []string      // Аргументы запуска
+	compileOutput  string        // Вывод компилятора (предупреждения)
+	env            []string      // Дополнительные переменные окружения запуска
+	defaultTimeout time.Duration // Лимит времени языка, если в запросе он не задан
+	streams        runStreams    // Ввод и вывод в реальном времени
+}
 
-	err = compileCmd.Run()
-	if err != nil {
-		log.Printf("❌ Go compilation failed: %v", err)
-		return map[string]interface{}{
-			"output":   "",
-			"error":    fmt.Sprintf("Compilation error: %s", compileStderr.String()),
-			"exitCode": 1,
-		}, nil
+// Run запускает подготовленную программу с входными данными
+func (s *localSession) Run(ctx context.Context, inputs []string, timeout time.Duration) (*ExecutionResult, error) {
+	log.Printf("🚀 Running %s program, inputs: %v", s.langName, inputs)
+	if timeout <= 0 {
+		timeout = s.defaultTimeout
 	}
-	defer func() {
-		if err := os.Remove(outputFile); err != nil {
-			log.Printf("⚠️ Failed to remove executable %s: %v", outputFile, err)
-		}
-	}()
-
-	// Выполнение
-	log.Printf("🚀 Running Go program...")
-	cmd := exec.Command(outputFile)
-
-	// Подготавливаем входные данные
-	var stdin bytes.Buffer
-	for _, input := range inputs {
-		stdin.WriteString(input + "\n")
+	result, err := s.executor.runWithInputs(ctx, inputs, timeout, s.streams, s.env, s.langName, s.runDir, s.name, s.args...)
+	if err != nil {
+		return nil, err
 	}
-	cmd.Stdin = &stdin
+	result.CompileOutput = s.compileOutput
+	return result, nil
+}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// Close удаляет рабочую директорию вместе со скомпилированной программой
+func (s *localSession) Close() error {
+	s.executor.removeRunDir(s.runDir)
+	return nil
+}
 
-	err = cmd.Run()
-	exitCode := 0
+// createRunDir создает отдельную рабочую директорию для одного запуска
+func (e *LocalExecutor) createRunDir(prefix string) (string, error) {
+	runDir, err := os.MkdirTemp(e.tempDir, prefix+"_")
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			exitCode = exitErr.ExitCode()
-		}
-		log.Printf("⚠️ Go execution completed with exit code %d", exitCode)
-	} else {
-		log.Printf("✅ Go execution completed successfully")
+		log.Printf("❌ Failed to create run directory: %v", err)
+		return "", err
 	}
+	return runDir, nil
+}
 
-	result := map[string]interface{}{
-		"output":   strings.TrimSpace(stdout.String()),
-		"error":    strings.TrimSpace(stderr.String()),
-		"exitCode": exitCode,
+// removeRunDir удаляет рабочую директорию запуска
+func (e *LocalExecutor) removeRunDir(runDir string) {
+	if err := os.RemoveAll(runDir); err != nil {
+		log.Printf("⚠️ Failed to remove temp directory %s: %v", runDir, err)
 	}
-
-	return result, nil
 }
 
-func (e *LocalExecutor) executeJavaScript(code string, inputs []string) (map[string]interface{}, error) {
-	log.Printf("🔵 Executing JavaScript code, length: %d chars, inputs: %v", len(code), inputs)
-
-	// Проверяем доступность Node.js
-	cmdName := "node"
-	if _, err := exec.LookPath(cmdName); err != nil {
-		errorMsg := "Node.js is not installed or not in PATH"
-		log.Printf("❌ %s", errorMsg)
-		return map[string]interface{}{
-			"output":   "",
-			"error":    errorMsg,
-			"exitCode": 1,
-		}, nil
+// compile запускает компилятор в директории dir и возвращает его вывод и признак успеха.
+// Ошибка возвращается только если запрос был отменен
+func (e *LocalExecutor) compile(ctx context.Context, langName, dir, name string, args ...string) (string, bool, error) {
+	compileCtx, cancel := context.WithTimeout(ctx, compileTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(compileCtx, name, args...)
+	cmd.Dir = dir
+	// Компиляторы запускают дочерние процессы (cc1plus, as, ld), убиваем всю группу
+	setProcessGroup(cmd)
+	cmd.WaitDelay = time.Second
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		log.Printf("🛑 %s compilation cancelled: %v", langName, ctx.Err())
+		return "", false, ctx.Err()
+	}
+	if compileCtx.Err() == context.DeadlineExceeded {
+		log.Printf("⏰ %s compilation timeout (%v)", langName, compileTimeout)
+		return fmt.Sprintf("Compilation timeout (%v)", compileTimeout), false, nil
+	}
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			log.Printf("❌ Failed to start %s compiler: %v", langName, err)
+			return fmt.Sprintf("Failed to start compiler: %v", err), false, nil
+		}
+		log.Printf("❌ %s compilation failed: %v", langName, err)
+		return strings.TrimSpace(output.String()), false, nil
 	}
 
-	log.Printf("🔧 Using Node.js command: %s", cmdName)
-
-	// Создаем временный файл для JavaScript кода
-	tmpFile := filepath.Join(e.tempDir, "script_"+fmt.Sprintf("%d", time.Now().UnixNano())+".js")
-
-	// Создаем обернутый код для Node.js с поддержкой ввода
-	wrappedCode := e.createJavaScriptWrapper(code)
+	log.Printf("✅ %s compilation completed successfully", langName)
+	return strings.TrimSpace(output.String()), true, nil
+}
 
-	err := os.WriteFile(tmpFile, []byte(wrappedCode), 0644)
-	if err != nil {
-		log.Printf("❌ Failed to write JavaScript file: %v", err)
-		return map[string]interface{}{
-			"output":   "",
-			"error":    fmt.Sprintf("Error creating file: %v", err),
-			"exitCode": 1,
-		}, nil
+// runWithInputs выполняет программу, передавая входные данные в stdin.
+// Если в streams задан stdin, ввод читается из него вместо inputs, а вывод
+// дополнительно копируется в потоки streams. env дополняет окружение программы.
+// Программа убивается вместе со всеми дочерними процессами по таймауту
+// запроса, при превышении лимита вывода или при отмене ctx; в последнем
+// случае возвращается ctx.Err()
+func (e *LocalExecutor) runWithInputs(ctx context.Context, inputs []string, timeout time.Duration, streams runStreams, env []string, langName, runDir, name string, args ...string) (*ExecutionResult, error) {
+	if timeout <= 0 {
+		timeout = defaultRunTimeout
 	}
-	defer func() {
-		if err := os.Remove(tmpFile); err != nil {
-			log.Printf("⚠️ Failed to remove temp file %s: %v", tmpFile, err)
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, name, args...)
+	cmd.Dir = runDir
+	setProcessGroup(cmd)
+	var cpuSeconds uint64
+	if e.sandbox != nil {
+		cpuSeconds = e.sandboxCPUSeconds(timeout)
+		if err := e.wrapSandboxCommand(cmd, runDir, cpuSeconds, env); err != nil {
+			return nil, fmt.Errorf("failed to prepare sandbox: %w", err)
 		}
-	}()
-
-	// Выполняем код через файл
-	cmd := exec.Command(cmdName, tmpFile)
+	} else if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	// Подготавливаем входные данные
 	var stdin bytes.Buffer
-	if len(inputs) > 0 {
+	if streams.stdin != nil {
+		// Копируем ввод сами: exec.Cmd ждал бы конца копирования после завершения программы
+		stdinPipe, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+		}
+		go func() {
+			io.Copy(stdinPipe, streams.stdin)
+			stdinPipe.Close()
+		}()
+		log.Printf("📥 Interactive input for %s", langName)
+	} else if len(inputs) > 0 {
+		// Если inputs это массив строк, объединяем их с переносами строк
 		fullInput := strings.Join(inputs, "\n") + "\n"
 		stdin.WriteString(fullInput)
-		log.Printf("📥 Sending input to JavaScript: %q", fullInput)
+		log.Printf("📥 Sending input to %s: %q", langName, fullInput)
+		cmd.Stdin = &stdin
 	} else {
-		log.Printf("📥 No input provided for JavaScript")
+		log.Printf("📥 No input provided for %s", langName)
+		cmd.Stdin = &stdin
 	}
-	cmd.Stdin = &stdin
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	// Устанавливаем таймаут выполнения (15 секунд)
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Run()
-	}()
+	// Превышение лимита вывода останавливает программу так же, как таймаут
+	stdout := newOutputBuffer(e.output.Stdout, streams.stdout, cancel)
+	stderr := newOutputBuffer(e.output.Stderr, streams.stderr, cancel)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Не ждем бесконечно дочерние процессы, которые держат открытыми stdout/stderr
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	wallTime := time.Since(start)
+	// В песочнице в rusage попадает и вспомогательный процесс до exec программы (несколько МБ)
+	cpuTime, peakMemory := processUsage(cmd.ProcessState)
+
+	if ctx.Err() != nil {
+		log.Printf("🛑 %s execution cancelled: %v", langName, ctx.Err())
+		return nil, ctx.Err()
+	}
+	if runCtx.Err() == context.DeadlineExceeded {
+		// Таймаут - процесс уже убит
+		log.Printf("⏰ %s execution timeout (%v)", langName, timeout)
+		return &ExecutionResult{
+			Stdout:     stdout.String(),
+			Stderr:     fmt.Sprintf("Execution timeout (%v)", timeout),
+			ExitCode:   -1,
+			WallTime:   wallTime,
+			CPUTime:    cpuTime,
+			PeakMemory: peakMemory,
+			TimedOut:   true,
+		}, nil
+	}
+	if stdout.Truncated() || stderr.Truncated() {
+		message := outputLimitMessage(e.output, stdout.Truncated(), stderr.Truncated())
+		log.Printf("📛 %s execution stopped: %s", langName, message)
+		return &ExecutionResult{
+			Stdout:          stdout.String(),
+			Stderr:          strings.TrimSpace(stderr.String() + "\n" + message),
+			ExitCode:        -1,
+			WallTime:        wallTime,
+			CPUTime:         cpuTime,
+			PeakMemory:      peakMemory,
+			StdoutTruncated: stdout.Truncated(),
+			StderrTruncated: stderr.Truncated(),
+		}, nil
+	}
 
-	select {
-	case err := <-done:
-		exitCode := 0
-		if err != nil {
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				exitCode = exitErr.ExitCode()
-			}
-			log.Printf("⚠️ JavaScript execution completed with exit code %d", exitCode)
+	exitCode := 0
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
 		} else {
-			log.Printf("✅ JavaScript execution completed successfully")
+			log.Printf("❌ Failed to start %s process: %v", langName, err)
+			return errorResult(fmt.Sprintf("Failed to start %s: %v", langName, err)), nil
 		}
-
-		result := map[string]interface{}{
-			"output":   strings.TrimSpace(stdout.String()),
-			"error":    strings.TrimSpace(stderr.String()),
-			"exitCode": exitCode,
+		// Песочница не запустилась - это сбой сервера, а не ошибка программы
+		if e.sandbox != nil && exitCode == sandboxSetupFailedExitCode && strings.HasPrefix(stderr.String(), sandboxErrorPrefix) {
+			log.Printf("❌ %s sandbox setup failed: %s", langName, strings.TrimSpace(stderr.String()))
+			return nil, fmt.Errorf("sandbox setup failed: %s", strings.TrimSpace(strings.TrimPrefix(stderr.String(), sandboxErrorPrefix)))
 		}
-
-		return result, nil
-
-	case <-time.After(15 * time.Second):
-		// Таймаут - убиваем процесс
-		log.Printf("⏰ JavaScript execution timeout (15 seconds)")
-		if cmd.Process != nil {
-			cmd.Process.Kill()
-		}
-		return map[string]interface{}{
-			"output":   "",
-			"error":    "Execution timeout (15 seconds)",
-			"exitCode": 1,
-		}, nil
+		log.Printf("⚠️ %s execution completed with exit code %d", langName, exitCode)
+	} else {
+		log.Printf("✅ %s execution completed successfully", langName)
 	}
-}
-
-func (e *LocalExecutor) executeCpp() (map[string]interface{}, error) {
-	// ... (реализация как в предыдущей версии)
-	return map[string]interface{}{
-		"output":   "",
-		"error":    "C++ execution not implemented",
-		"exitCode": 1,
-	}, nil
-}
-
-func (e *LocalExecutor) executeJava() (map[string]interface{}, error) {
-	// ... (реализация как в предыдущей версии)
-	return map[string]interface{}{
-		"output":   "",
-		"error":    "Java execution not implemented",
-		"exitCode": 1,
-	}, nil
-}
-
-// createJavaScriptWrapper создает обертку для JavaScript кода с поддержкой ввода
-func (e *LocalExecutor) createJavaScriptWrapper(code string) string {
-	return `
-const readline = require('readline');
-
-async function main() {
-    const rl = readline.createInterface({
-        input: process.stdin,
-        output: process.stdout,
-        terminal: false
-    });
-
-    let inputLines = [];
-    for await (const line of rl) {
-        inputLines.push(line);
-    }
-
-    let inputIndex = 0;
-    const input = () => {
-        if (inputIndex < inputLines.length) {
-            return inputLines[inputIndex++];
-        }
-        return "";
-    };
-
-    // Заменяем глобальные функции ввода
-    global.prompt = input;
-    global.input = input;
-
-    try {
-        ` + code + `
-    } catch (error) {
-        console.error(error);
-    }
-}
 
-main().catch(console.error);
-`
-}
-
-// findPythonCommand ищет доступную команду Python в системе
-func (e *LocalExecutor) findPythonCommand() string {
-	// Список возможных команд Python в порядке предпочтения
-	possibleCommands := []string{"python3", "python", "py"}
-
-	for _, cmd := range possibleCommands {
-		if path, err := exec.LookPath(cmd); err == nil {
-			// Проверяем версию Python
-			versionCmd := exec.Command(path, "--version")
-			var versionOut bytes.Buffer
-			versionCmd.Stdout = &versionOut
-			versionCmd.Stderr = &versionOut
+	result := &ExecutionResult{
+		Stdout:     stdout.String(),
+		Stderr:     stderr.String(),
+		ExitCode:   exitCode,
+		WallTime:   wallTime,
+		CPUTime:    cpuTime,
+		PeakMemory: peakMemory,
+	}
 
-			if err := versionCmd.Run(); err == nil {
-				log.Printf("✅ Found %s at %s: %s", cmd, path, strings.TrimSpace(versionOut.String()))
-				return path
-			}
+	if e.sandbox != nil && exitCode != 0 {
+		cpuLimit := time.Duration(cpuSeconds) * time.Second
+		if killedByCPULimit(cmd.ProcessState, cpuLimit) {
+			log.Printf("⏰ %s execution exceeded CPU limit (%v)", langName, cpuLimit)
+			result.TimedOut = true
+			result.Stderr = strings.TrimSpace(result.Stderr + fmt.Sprintf("\nCPU time limit exceeded (%v)", cpuLimit))
+		} else if isOutOfMemory(result.Stderr) {
+			log.Printf("💾 %s execution exceeded memory limit", langName)
+			result.OOMKilled = true
 		}
 	}
 
-	return ""
+	log.Printf("📊 %s execution result - Output: %d chars, Error: %d chars",
+		langName, len(result.Stdout), len(result.Stderr))
+
+	return result, nil
 }
 
 // Cleanup удаляет временную директорию
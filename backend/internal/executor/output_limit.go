@@ -0,0 +1,107 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// OutputLimits - лимиты размера вывода программы в байтах. Программа,
+// превысившая лимит, останавливается, а вывод обрезается. Ноль - без лимита
+type OutputLimits struct {
+	Stdout int64
+	Stderr int64
+}
+
+// DefaultOutputLimits возвращает лимиты вывода по умолчанию
+func DefaultOutputLimits() OutputLimits {
+	return OutputLimits{
+		Stdout: 8 * 1024 * 1024,
+		Stderr: 1024 * 1024,
+	}
+}
+
+// OutputLimitsFromEnv читает лимиты вывода из переменных окружения:
+// OUTPUT_LIMIT_STDOUT_KB, OUTPUT_LIMIT_STDERR_KB
+func OutputLimitsFromEnv() OutputLimits {
+	limits := DefaultOutputLimits()
+
+	if kb := envInt("OUTPUT_LIMIT_STDOUT_KB"); kb > 0 {
+		limits.Stdout = int64(kb) * 1024
+	}
+	if kb := envInt("OUTPUT_LIMIT_STDERR_KB"); kb > 0 {
+		limits.Stderr = int64(kb) * 1024
+	}
+
+	return limits
+}
+
+// outputBuffer собирает вывод программы и копирует его в stream, если он задан.
+// После limit байт вывод отбрасывается, а onExceed вызывается один раз, чтобы
+// остановить программу. Запись не возвращает ошибок: иначе программа
+// заблокировалась бы на записи в pipe до того, как ее убьют
+type outputBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	stream    io.Writer
+	limit     int64
+	onExceed  func()
+	truncated bool
+}
+
+func newOutputBuffer(limit int64, stream io.Writer, onExceed func()) *outputBuffer {
+	return &outputBuffer{limit: limit, stream: stream, onExceed: onExceed}
+}
+
+// Write реализует io.Writer
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	if b.truncated {
+		b.mu.Unlock()
+		return len(p), nil
+	}
+
+	data := p
+	exceeded := false
+	if b.limit > 0 && int64(b.buf.Len())+int64(len(p)) > b.limit {
+		data = p[:b.limit-int64(b.buf.Len())]
+		b.truncated = true
+		exceeded = true
+	}
+	b.buf.Write(data)
+	if b.stream != nil && len(data) > 0 {
+		b.stream.Write(data)
+	}
+	b.mu.Unlock()
+
+	if exceeded && b.onExceed != nil {
+		b.onExceed()
+	}
+	return len(p), nil
+}
+
+// String возвращает собранный вывод
+func (b *outputBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// Truncated сообщает, что вывод превысил лимит
+func (b *outputBuffer) Truncated() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.truncated
+}
+
+// outputLimitMessage возвращает текст ошибки для превышенного лимита вывода
+func outputLimitMessage(limits OutputLimits, stdoutTruncated, stderrTruncated bool) string {
+	if stdoutTruncated {
+		return fmt.Sprintf("Output limit exceeded (stdout > %d bytes)", limits.Stdout)
+	}
+	if stderrTruncated {
+		return fmt.Sprintf("Output limit exceeded (stderr > %d bytes)", limits.Stderr)
+	}
+	return ""
+}
@@ -0,0 +1,30 @@
+//go:build !unix
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"time"
+)
+
+// setProcessGroup на платформах без групп процессов убивает только сам процесс
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		return cmd.Process.Kill()
+	}
+}
+
+// killedByCPULimit: RLIMIT_CPU на этих платформах не используется
+func killedByCPULimit(state *os.ProcessState, limit time.Duration) bool {
+	return false
+}
+
+// processUsage возвращает процессорное время процесса; пиковая память на этих
+// платформах не измеряется
+func processUsage(state *os.ProcessState) (cpuTime time.Duration, peakMemory int64) {
+	if state == nil {
+		return 0, 0
+	}
+	return state.UserTime() + state.SystemTime(), 0
+}
@@ -0,0 +1,59 @@
+//go:build unix
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// setProcessGroup запускает команду в отдельной группе процессов, чтобы при
+// отмене контекста убить не только саму программу, но и все её дочерние процессы
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// killedByCPULimit проверяет, остановлен ли процесс по RLIMIT_CPU: мягкий лимит
+// присылает SIGXCPU, а если он проигнорирован (init в PID namespace) - SIGKILL
+func killedByCPULimit(state *os.ProcessState, limit time.Duration) bool {
+	if state == nil || limit <= 0 {
+		return false
+	}
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return false
+	}
+	switch status.Signal() {
+	case syscall.SIGXCPU:
+		return true
+	case syscall.SIGKILL:
+		return state.UserTime()+state.SystemTime() >= limit
+	}
+	return false
+}
+
+// processUsage возвращает процессорное время (user + sys) и пиковый RSS
+// завершившегося процесса из rusage. ru_maxrss в Linux и BSD - в килобайтах,
+// в macOS - в байтах
+func processUsage(state *os.ProcessState) (cpuTime time.Duration, peakMemory int64) {
+	if state == nil {
+		return 0, 0
+	}
+	cpuTime = state.UserTime() + state.SystemTime()
+	if rusage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		peakMemory = int64(rusage.Maxrss)
+		if runtime.GOOS != "darwin" {
+			peakMemory *= 1024
+		}
+	}
+	return cpuTime, peakMemory
+}
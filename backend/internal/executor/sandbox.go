@@ -0,0 +1,163 @@
+package executor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sandboxInitArg - первый аргумент, с которым сервер перезапускает сам себя
+// внутри новых namespace'ов, чтобы подготовить песочницу и выполнить exec программы
+const sandboxInitArg = "__sandbox_init"
+
+// sandboxSetupFailedExitCode - код возврата, если песочницу не удалось подготовить.
+// Сообщение об ошибке при этом пишется в stderr с префиксом sandboxErrorPrefix
+const (
+	sandboxSetupFailedExitCode = 125
+	sandboxErrorPrefix         = "sandbox: "
+)
+
+// SandboxConfig ограничения для запуска программ в песочнице
+type SandboxConfig struct {
+	MemoryLimit  int64         // RLIMIT_DATA в байтах
+	CPUTime      time.Duration // RLIMIT_CPU; ноль - таймаут запроса
+	FileSizeMax  int64         // RLIMIT_FSIZE в байтах
+	MaxProcesses uint64        // RLIMIT_NPROC (считается для всех процессов пользователя сервера)
+	TmpfsSize    int64         // Размер приватного tmpfs с рабочей директорией
+	HiddenPaths  []string      // Дополнительные директории, скрываемые от программы
+}
+
+// DefaultSandboxConfig возвращает ограничения песочницы по умолчанию
+func DefaultSandboxConfig() SandboxConfig {
+	return SandboxConfig{
+		MemoryLimit:  512 * 1024 * 1024,
+		FileSizeMax:  16 * 1024 * 1024,
+		MaxProcesses: 256,
+		TmpfsSize:    64 * 1024 * 1024,
+	}
+}
+
+// SandboxConfigFromEnv читает ограничения песочницы из переменных окружения:
+// SANDBOX_MEMORY_MB, SANDBOX_CPU_SECONDS, SANDBOX_FILE_SIZE_MB,
+// SANDBOX_MAX_PROCESSES, SANDBOX_TMPFS_MB, SANDBOX_HIDDEN_PATHS (через ':')
+func SandboxConfigFromEnv() SandboxConfig {
+	cfg := DefaultSandboxConfig()
+
+	if mb := envInt("SANDBOX_MEMORY_MB"); mb > 0 {
+		cfg.MemoryLimit = int64(mb) * 1024 * 1024
+	}
+	if sec := envInt("SANDBOX_CPU_SECONDS"); sec > 0 {
+		cfg.CPUTime = time.Duration(sec) * time.Second
+	}
+	if mb := envInt("SANDBOX_FILE_SIZE_MB"); mb > 0 {
+		cfg.FileSizeMax = int64(mb) * 1024 * 1024
+	}
+	if n := envInt("SANDBOX_MAX_PROCESSES"); n > 0 {
+		cfg.MaxProcesses = uint64(n)
+	}
+	if mb := envInt("SANDBOX_TMPFS_MB"); mb > 0 {
+		cfg.TmpfsSize = int64(mb) * 1024 * 1024
+	}
+	for _, path := range strings.Split(os.Getenv("SANDBOX_HIDDEN_PATHS"), ":") {
+		if path != "" {
+			cfg.HiddenPaths = append(cfg.HiddenPaths, path)
+		}
+	}
+
+	return cfg
+}
+
+// NewSandboxExecutor создает LocalExecutor, который запускает программы в
+// песочнице: отдельные user/pid/net/mount namespace'ы, rlimit'ы, приватный
+// tmpfs и seccomp-фильтр. Компиляторы запускаются вне песочницы
+func NewSandboxExecutor(cfg SandboxConfig) (*LocalExecutor, error) {
+	if err := checkSandboxSupport(); err != nil {
+		return nil, fmt.Errorf("sandbox is not supported: %w", err)
+	}
+
+	e := NewLocalExecutor()
+	e.sandbox = &cfg
+	log.Printf("🔒 Sandbox enabled: memory=%dMB, fsize=%dMB, nproc=%d, tmpfs=%dMB",
+		cfg.MemoryLimit/1024/1024, cfg.FileSizeMax/1024/1024, cfg.MaxProcesses, cfg.TmpfsSize/1024/1024)
+	return e, nil
+}
+
+// sandboxCPUSeconds возвращает RLIMIT_CPU для запуска: значение из конфигурации
+// или таймаут запроса с округлением вверх
+func (e *LocalExecutor) sandboxCPUSeconds(timeout time.Duration) uint64 {
+	if e.sandbox.CPUTime > 0 {
+		return uint64((e.sandbox.CPUTime + time.Second - 1) / time.Second)
+	}
+	return uint64((timeout + time.Second - 1) / time.Second)
+}
+
+// outOfMemoryMarkers - сообщения сред выполнения о нехватке памяти
+var outOfMemoryMarkers = []string{
+	"MemoryError",                   // Python
+	"std::bad_alloc",                // C++
+	"JavaScript heap out of memory", // Node.js
+	"java.lang.OutOfMemoryError",    // Java
+	"runtime: out of memory",        // Go
+	"Fatal process out of memory",   // V8 вне кучи
+	"Fatal process OOM",             // V8 при резервировании памяти
+}
+
+// isOutOfMemory проверяет, упала ли программа из-за лимита памяти песочницы.
+// Под RLIMIT_DATA ядро не убивает процесс, а отказывает в выделении памяти,
+// поэтому превышение лимита распознается по сообщению среды выполнения
+func isOutOfMemory(stderr string) bool {
+	for _, marker := range outOfMemoryMarkers {
+		if strings.Contains(stderr, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// heapLimitArgs возвращает флаги ограничения кучи для node/java в песочнице
+func (e *LocalExecutor) heapLimitArgs(runtimeName string) []string {
+	if e.sandbox == nil || e.sandbox.MemoryLimit <= 0 {
+		return nil
+	}
+	mb := e.sandbox.MemoryLimit / 1024 / 1024
+	switch runtimeName {
+	case "node":
+		return []string{fmt.Sprintf("--max-old-space-size=%d", mb)}
+	case "java":
+		return []string{fmt.Sprintf("-Xmx%dm", mb)}
+	}
+	return nil
+}
+
+// sandboxSpec передается вспомогательному процессу песочницы через аргументы
+type sandboxSpec struct {
+	WorkDir     string   `json:"work_dir"`     // Рабочая директория запуска, копируется в tmpfs
+	TmpfsMounts []string `json:"tmpfs_mounts"` // Куда смонтировать пустой tmpfs (скрывает другие запуски)
+	HiddenPaths []string `json:"hidden_paths"` // Директории, скрываемые пустым tmpfs (например, каталог сервера)
+	TmpfsSize   int64    `json:"tmpfs_size"`
+	MemoryLimit int64    `json:"memory_limit"`
+	CPUSeconds  uint64   `json:"cpu_seconds"`
+	FileSizeMax int64    `json:"file_size_max"`
+	MaxProcs    uint64   `json:"max_procs"`
+	Env         []string `json:"env"`
+}
+
+func init() {
+	// Вспомогательный процесс песочницы: настраивает окружение и заменяет себя
+	// программой пользователя. Выполняется до init() остальных пакетов сервера
+	if len(os.Args) > 1 && os.Args[1] == sandboxInitArg {
+		runSandboxInit()
+	}
+}
+
+// envInt читает целое число из переменной окружения, 0 если не задано
+func envInt(key string) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return 0
+	}
+	return value
+}
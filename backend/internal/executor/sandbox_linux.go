@@ -0,0 +1,483 @@
+//go:build linux
+
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Значения seccomp, отсутствующие в golang.org/x/sys/unix
+const (
+	seccompRetKillProcess = 0x80000000
+	seccompRetErrno       = 0x00050000
+	seccompRetAllow       = 0x7fff0000
+
+	// Смещения полей struct seccomp_data
+	seccompDataNrOffset   = 0
+	seccompDataArchOffset = 4
+	seccompDataArg0Offset = 16 // Младшие 32 бита первого аргумента (little-endian)
+
+	// x32 ABI на amd64 - системные вызовы с этим битом запрещаем целиком
+	x32SyscallBit = 0x40000000
+
+	// securebits: uid 0 внутри namespace не получает capabilities после exec
+	secbitNoroot         = 1 << 0
+	secbitNorootLocked   = 1 << 1
+	secbitNoSetuidFixup  = 1 << 2
+	secbitNoSetuidLocked = 1 << 3
+	secbitKeepCapsLocked = 1 << 5
+)
+
+// sandboxDeniedSyscalls - системные вызовы, которые программе запрещены (EPERM)
+var sandboxDeniedSyscalls = []uintptr{
+	unix.SYS_PTRACE,
+	unix.SYS_MOUNT,
+	unix.SYS_UMOUNT2,
+	unix.SYS_PIVOT_ROOT,
+	unix.SYS_CHROOT,
+	unix.SYS_UNSHARE,
+	unix.SYS_SETNS,
+	unix.SYS_REBOOT,
+	unix.SYS_KEXEC_LOAD,
+	unix.SYS_INIT_MODULE,
+	unix.SYS_FINIT_MODULE,
+	unix.SYS_DELETE_MODULE,
+	unix.SYS_SWAPON,
+	unix.SYS_SWAPOFF,
+	unix.SYS_BPF,
+	unix.SYS_PERF_EVENT_OPEN,
+	unix.SYS_KEYCTL,
+	unix.SYS_ADD_KEY,
+	unix.SYS_REQUEST_KEY,
+	unix.SYS_ACCT,
+	unix.SYS_SETTIMEOFDAY,
+	unix.SYS_CLOCK_SETTIME,
+	unix.SYS_SETHOSTNAME,
+	unix.SYS_SETDOMAINNAME,
+	unix.SYS_USERFAULTFD,
+	unix.SYS_PROCESS_VM_READV,
+	unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_IO_URING_SETUP,
+	unix.SYS_FANOTIFY_INIT,
+	unix.SYS_NAME_TO_HANDLE_AT,
+	unix.SYS_OPEN_BY_HANDLE_AT,
+}
+
+// sandboxCloneNamespaceFlags - флаги clone, создающие новые namespace. Вместе
+// с запретом unshare и setns программа не может выйти из namespace песочницы
+const sandboxCloneNamespaceFlags = unix.CLONE_NEWNS | unix.CLONE_NEWCGROUP | unix.CLONE_NEWUTS |
+	unix.CLONE_NEWIPC | unix.CLONE_NEWUSER | unix.CLONE_NEWPID | unix.CLONE_NEWNET | unix.CLONE_NEWTIME
+
+// checkSandboxSupport проверяет, что ядро позволяет создавать user namespace
+// без привилегий. Проверка запускает /bin/true, а не сам сервер
+func checkSandboxSupport() error {
+	if _, err := os.Stat("/proc/self/exe"); err != nil {
+		return fmt.Errorf("/proc is not available: %w", err)
+	}
+
+	truePath, err := exec.LookPath("true")
+	if err != nil {
+		return fmt.Errorf("true not found: %w", err)
+	}
+
+	cmd := exec.Command(truePath)
+	cmd.SysProcAttr = &syscall.SysProcAttr{}
+	setSandboxNamespaces(cmd.SysProcAttr)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("cannot create namespaces: %w", err)
+	}
+	return nil
+}
+
+// setSandboxNamespaces настраивает создание новых namespace'ов для процесса.
+// Единственный uid/gid внутри - 0, он отображается на пользователя сервера
+func setSandboxNamespaces(attr *syscall.SysProcAttr) {
+	attr.Cloneflags = syscall.CLONE_NEWUSER | syscall.CLONE_NEWPID | syscall.CLONE_NEWNET |
+		syscall.CLONE_NEWNS | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}}
+	attr.GidMappingsEnableSetgroups = false
+	attr.Pdeathsig = syscall.SIGKILL
+}
+
+// wrapSandboxCommand превращает запуск name args... в запуск вспомогательного
+// процесса песочницы, который выполнит программу внутри namespace'ов. env
+// дополняет минимальное окружение песочницы
+func (e *LocalExecutor) wrapSandboxCommand(cmd *exec.Cmd, workDir string, cpuSeconds uint64, env []string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate server executable: %w", err)
+	}
+
+	// Путь к программе разрешаем заранее: внутри песочницы PATH минимальный
+	program := cmd.Path
+	if !filepath.IsAbs(program) {
+		if program, err = exec.LookPath(program); err != nil {
+			return err
+		}
+	}
+
+	tmpfsMounts := []string{os.TempDir()}
+	if rel, err := filepath.Rel(os.TempDir(), e.tempDir); err != nil || strings.HasPrefix(rel, "..") {
+		tmpfsMounts = append(tmpfsMounts, e.tempDir)
+	}
+
+	spec := sandboxSpec{
+		WorkDir:     workDir,
+		TmpfsMounts: tmpfsMounts,
+		HiddenPaths: e.sandboxHiddenPaths(program, workDir, tmpfsMounts),
+		TmpfsSize:   e.sandbox.TmpfsSize,
+		MemoryLimit: e.sandbox.MemoryLimit,
+		CPUSeconds:  cpuSeconds,
+		FileSizeMax: e.sandbox.FileSizeMax,
+		MaxProcs:    e.sandbox.MaxProcesses,
+		Env: []string{
+			"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"HOME=" + workDir,
+			"TMPDIR=" + workDir,
+			"LANG=C.UTF-8",
+		},
+	}
+	spec.Env = append(spec.Env, env...)
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+
+	cmd.Path = self
+	cmd.Args = append([]string{self, sandboxInitArg, string(specJSON), program}, cmd.Args[1:]...)
+	cmd.Dir = workDir
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	setSandboxNamespaces(cmd.SysProcAttr)
+	return nil
+}
+
+// sandboxHiddenPaths возвращает директории, которые скрываются от программы:
+// каталог сервера и его git-репозиторий (.env с секретами), домашнюю директорию
+// пользователя сервера и SandboxConfig.HiddenPaths. Директория, в которой лежит
+// сама программа (например, интерпретатор из ~/.pyenv) или рабочая директория,
+// не скрывается: без неё запуск невозможен
+func (e *LocalExecutor) sandboxHiddenPaths(program, workDir string, tmpfsMounts []string) []string {
+	candidates := append([]string(nil), e.sandbox.HiddenPaths...)
+	if cwd, err := os.Getwd(); err == nil {
+		candidates = append(candidates, cwd)
+		if root := gitRoot(cwd); root != "" {
+			candidates = append(candidates, root)
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, home)
+	}
+
+	needed := append([]string{program, workDir}, tmpfsMounts...)
+	if resolved, err := filepath.EvalSymlinks(program); err == nil {
+		needed = append(needed, resolved)
+	}
+
+	var hidden []string
+	seen := make(map[string]bool)
+	for _, dir := range candidates {
+		dir = filepath.Clean(dir)
+		if !filepath.IsAbs(dir) || dir == "/" || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if containsPath(dir, needed) {
+			if _, logged := sandboxVisibleLogged.LoadOrStore(dir, true); !logged {
+				log.Printf("⚠️ Sandbox: %s is not hidden, the program needs it", dir)
+			}
+			continue
+		}
+		hidden = append(hidden, dir)
+	}
+	return hidden
+}
+
+// sandboxVisibleLogged - директории, о которых уже предупредили, что они не скрыты
+var sandboxVisibleLogged sync.Map
+
+// gitRoot возвращает корень git-репозитория, в котором лежит dir ("" - не в репозитории)
+func gitRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// containsPath сообщает, лежит ли внутри dir (или совпадает с ним) один из paths
+func containsPath(dir string, paths []string) bool {
+	for _, path := range paths {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			return true
+		}
+	}
+	return false
+}
+
+// runSandboxInit выполняется во вспомогательном процессе внутри новых
+// namespace'ов. При успехе не возвращается: процесс заменяется программой
+func runSandboxInit() {
+	// securebits и seccomp действуют на поток, exec должен быть из того же потока
+	runtime.LockOSThread()
+
+	if len(os.Args) < 4 {
+		sandboxFail(fmt.Errorf("invalid arguments"))
+	}
+
+	var spec sandboxSpec
+	if err := json.Unmarshal([]byte(os.Args[2]), &spec); err != nil {
+		sandboxFail(fmt.Errorf("invalid spec: %w", err))
+	}
+	argv := os.Args[3:]
+
+	if err := setupSandboxFS(spec); err != nil {
+		sandboxFail(err)
+	}
+	if err := os.Chdir(spec.WorkDir); err != nil {
+		sandboxFail(err)
+	}
+	if err := applySandboxRlimits(spec); err != nil {
+		sandboxFail(err)
+	}
+	if err := dropSandboxPrivileges(); err != nil {
+		sandboxFail(err)
+	}
+	if err := installSeccompFilter(); err != nil {
+		sandboxFail(err)
+	}
+
+	err := syscall.Exec(argv[0], argv, spec.Env)
+	sandboxFail(fmt.Errorf("exec %s: %w", argv[0], err))
+}
+
+// setupSandboxFS изолирует файловую систему: рабочая директория переносится
+// в приватный tmpfs, остальные временные файлы и каталог сервера скрываются
+func setupSandboxFS(spec sandboxSpec) error {
+	// Изменения монтирования не должны попадать в namespace сервера
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+
+	// Файлы запуска читаем до того, как tmpfs скроет исходную директорию
+	files, err := readWorkDir(spec.WorkDir)
+	if err != nil {
+		return err
+	}
+
+	// Корень только для чтения. Заблокированные в user namespace флаги исходного
+	// монтирования нужно сохранить, иначе ядро откажет в перемонтировании
+	var st unix.Statfs_t
+	if err := unix.Statfs("/", &st); err != nil {
+		return fmt.Errorf("statfs /: %w", err)
+	}
+	flags := uintptr(unix.MS_REMOUNT|unix.MS_BIND|unix.MS_RDONLY) | uintptr(st.Flags&(unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC|unix.MS_NOATIME|unix.MS_NODIRATIME|unix.MS_RELATIME))
+	if err := unix.Mount("", "/", "", flags, ""); err != nil {
+		return fmt.Errorf("remount / read-only: %w", err)
+	}
+
+	tmpfsOptions := fmt.Sprintf("size=%d,mode=0755", spec.TmpfsSize)
+	for _, target := range spec.TmpfsMounts {
+		if err := unix.Mount("tmpfs", target, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, tmpfsOptions); err != nil {
+			return fmt.Errorf("mount tmpfs on %s: %w", target, err)
+		}
+	}
+	for _, target := range spec.HiddenPaths {
+		if _, err := os.Stat(target); err != nil {
+			continue
+		}
+		if err := unix.Mount("tmpfs", target, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_RDONLY, "size=4k,mode=0555"); err != nil {
+			return fmt.Errorf("hide %s: %w", target, err)
+		}
+	}
+
+	// /proc нового pid namespace - программа не видит процессы хоста
+	if err := unix.Mount("proc", "/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("mount /proc: %w", err)
+	}
+
+	for _, f := range files {
+		path := filepath.Join(spec.WorkDir, f.rel)
+		if f.mode.IsDir() {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, f.data, f.mode.Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type sandboxFile struct {
+	rel  string
+	mode fs.FileMode
+	data []byte
+}
+
+// readWorkDir читает содержимое рабочей директории в память
+func readWorkDir(root string) ([]sandboxFile, error) {
+	files := []sandboxFile{{rel: ".", mode: fs.ModeDir | 0755}}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			files = append(files, sandboxFile{rel: rel, mode: info.Mode()})
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, sandboxFile{rel: rel, mode: info.Mode(), data: data})
+		return nil
+	})
+	return files, err
+}
+
+// applySandboxRlimits устанавливает ограничения ресурсов процесса
+func applySandboxRlimits(spec sandboxSpec) error {
+	limits := []struct {
+		resource int
+		soft     uint64
+		hard     uint64
+	}{
+		// RLIMIT_DATA, а не RLIMIT_AS: V8, JVM и рантайм Go резервируют гигабайты
+		// адресного пространства без доступа к нему, это не должно считаться памятью
+		{unix.RLIMIT_DATA, uint64(spec.MemoryLimit), uint64(spec.MemoryLimit)},
+		// Мягкий лимит присылает SIGXCPU, жесткий через секунду - SIGKILL
+		{unix.RLIMIT_CPU, spec.CPUSeconds, spec.CPUSeconds + 1},
+		{unix.RLIMIT_FSIZE, uint64(spec.FileSizeMax), uint64(spec.FileSizeMax)},
+		{unix.RLIMIT_NPROC, spec.MaxProcs, spec.MaxProcs},
+		{unix.RLIMIT_CORE, 0, 0},
+	}
+
+	for _, l := range limits {
+		if l.hard == 0 && l.resource != unix.RLIMIT_CORE {
+			continue
+		}
+		if err := unix.Setrlimit(l.resource, &unix.Rlimit{Cur: l.soft, Max: l.hard}); err != nil {
+			return fmt.Errorf("setrlimit %d: %w", l.resource, err)
+		}
+	}
+	return nil
+}
+
+// dropSandboxPrivileges запрещает получение capabilities через exec и setuid
+func dropSandboxPrivileges() error {
+	bits := secbitNoroot | secbitNorootLocked | secbitNoSetuidFixup | secbitNoSetuidLocked | secbitKeepCapsLocked
+	if err := unix.Prctl(unix.PR_SET_SECUREBITS, uintptr(bits), 0, 0, 0); err != nil {
+		return fmt.Errorf("set securebits: %w", err)
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("set no_new_privs: %w", err)
+	}
+	return nil
+}
+
+// installSeccompFilter устанавливает BPF-фильтр, возвращающий EPERM для
+// системных вызовов из sandboxDeniedSyscalls и для clone с флагами новых
+// namespace и убивающий процесс при чужой архитектуре. Флаги clone3 лежат в
+// структуре в памяти, которую seccomp проверить не может, поэтому clone3
+// возвращает ENOSYS, и libc переходит на clone
+func installSeccompFilter() error {
+	var arch uint32
+	switch runtime.GOARCH {
+	case "amd64":
+		arch = unix.AUDIT_ARCH_X86_64
+	case "arm64":
+		arch = unix.AUDIT_ARCH_AARCH64
+	default:
+		return fmt.Errorf("seccomp filter is not available for %s", runtime.GOARCH)
+	}
+
+	deny := uint32(seccompRetErrno | uint32(unix.EPERM))
+	filter := []unix.SockFilter{
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataArchOffset),
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, arch, 1, 0),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, seccompRetKillProcess),
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataNrOffset),
+	}
+	if runtime.GOARCH == "amd64" {
+		filter = append(filter,
+			bpfJump(unix.BPF_JMP|unix.BPF_JGE|unix.BPF_K, x32SyscallBit, 0, 1),
+			bpfStmt(unix.BPF_RET|unix.BPF_K, deny),
+		)
+	}
+	for _, nr := range sandboxDeniedSyscalls {
+		filter = append(filter,
+			bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, uint32(nr), 0, 1),
+			bpfStmt(unix.BPF_RET|unix.BPF_K, deny),
+		)
+	}
+	filter = append(filter,
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, unix.SYS_CLONE3, 0, 1),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, uint32(seccompRetErrno|uint32(unix.ENOSYS))),
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, unix.SYS_CLONE, 0, 3),
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataArg0Offset),
+		bpfJump(unix.BPF_JMP|unix.BPF_JSET|unix.BPF_K, sandboxCloneNamespaceFlags, 0, 1),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, deny),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, seccompRetAllow),
+	)
+
+	prog := unix.SockFprog{
+		Len:    uint16(len(filter)),
+		Filter: &filter[0],
+	}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0); err != nil {
+		return fmt.Errorf("install seccomp filter: %w", err)
+	}
+	return nil
+}
+
+func bpfStmt(code uint16, k uint32) unix.SockFilter {
+	return unix.SockFilter{Code: code, K: k}
+}
+
+func bpfJump(code uint16, k uint32, jt, jf uint8) unix.SockFilter {
+	return unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+}
+
+// sandboxFail сообщает об ошибке подготовки песочницы и завершает процесс
+func sandboxFail(err error) {
+	fmt.Fprintf(os.Stderr, "%s%v\n", sandboxErrorPrefix, err)
+	os.Exit(sandboxSetupFailedExitCode)
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// checkSandboxSupport - песочница на namespace'ах доступна только в Linux
+func checkSandboxSupport() error {
+	return errors.New("linux namespaces are required")
+}
+
+func (e *LocalExecutor) wrapSandboxCommand(cmd *exec.Cmd, workDir string, cpuSeconds uint64, env []string) error {
+	return errors.New("sandbox is not supported on this platform")
+}
+
+func runSandboxInit() {
+	fmt.Fprintf(os.Stderr, "%ssandbox is not supported on this platform\n", sandboxErrorPrefix)
+	os.Exit(sandboxSetupFailedExitCode)
+}
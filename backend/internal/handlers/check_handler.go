@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"backend/internal/database"
+	"backend/internal/executor"
 	"backend/internal/models"
 	"database/sql"
 	"encoding/json"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // CheckHandler обрабатывает проверку кода на соответствие тестам
@@ -27,7 +29,7 @@ func CheckHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Валидация
-	if req.Code == "" {
+	if req.Code == "" && len(req.Files) == 0 {
 		http.Error(w, `{"success": false, "message": "Code is required"}`, http.StatusBadRequest)
 		return
 	}
@@ -46,14 +48,10 @@ func CheckHandler(w http.ResponseWriter, r *http.Request) {
 	log.Printf("🔍 Checking code for task: %s, language: %s, code length: %d", taskID, req.Language, len(req.Code))
 
 	// Получаем задачу из БД
-	task, err := getTaskFromDB(req.Language, taskID)
-	if err != nil {
-		// Если не найдено в БД, пробуем получить встроенную задачу
-		task = getBuiltInTask(req.Language, taskID)
-		if task.ID == "" {
-			http.Error(w, `{"success": false, "message": "Task not found"}`, http.StatusNotFound)
-			return
-		}
+	task, ok := findTask(req.Language, taskID)
+	if !ok {
+		http.Error(w, `{"success": false, "message": "Task not found"}`, http.StatusNotFound)
+		return
 	}
 
 	// Используем тесты из задачи, если не предоставлены в запросе
@@ -67,95 +65,41 @@ func CheckHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Выполняем код с тестами
-	allTestsPassed := true
-	var testResults []models.TestResult
-
-	for i, test := range testsToRun {
-		// Подготавливаем входные данные если есть
-		var inputs []string
-		if test.Input != "" {
-			// Разделяем многострочный ввод
-			inputs = strings.Split(test.Input, "\n")
+	response, err := judgeSolution(executionContext(r), req.Code, req.Language, req.Files, testsToRun, task)
+	if err != nil {
+		if executor.IsBusy(err) {
+			log.Printf("🚦 Check rejected: %v", err)
+			writeBusyError(w, err)
+			return
 		}
+		// Клиент отключился - остальные тесты запускать бессмысленно
+		log.Printf("🛑 Check cancelled: %v", err)
+		return
+	}
+	if len(req.Tests) == 0 {
+		response.TestSetVersion = task.TestSetVersion
+	}
 
-		// Выполняем код с текущим тестом
-		result, err := codeExecutor.Execute(req.Code, req.Language, inputs)
+	// Стресс-тестирование по запросу: ищем тест, на котором решение расходится с эталонным
+	if req.Stress && !response.Success && stressApplicable(response.Verdict) {
+		http.NewResponseController(w).SetWriteDeadline(time.Now().Add(stressTimeout() + 15*time.Second))
+		response.Stress, err = stressTest(executionContext(r), req.Code, req.Language, req.Files, task)
 		if err != nil {
-			log.Printf("❌ Test %d execution error: %v", i+1, err)
-			allTestsPassed = false
-			testResults = append(testResults, models.TestResult{
-				TestNumber: i + 1,
-				Passed:     false,
-				Output:     "",
-				Error:      err.Error(),
-				Expected:   test.ExpectedOutput,
-				Actual:     "",
-			})
-			continue
-		}
-
-		// Получаем вывод
-		output := ""
-		if out, ok := result["output"].(string); ok {
-			output = out
-		}
-
-		// Нормализуем вывод для сравнения
-		normalizedOutput := normalizeOutput(output)
-		normalizedExpected := normalizeOutput(test.ExpectedOutput)
-
-		// Проверяем соответствие ожидаемому результату
-		passed := normalizedOutput == normalizedExpected
-
-		if !passed {
-			allTestsPassed = false
+			if r.Context().Err() != nil {
+				log.Printf("🛑 Stress test cancelled: %v", err)
+				return
+			}
+			response.Stress = &models.StressResult{Status: models.StressUnavailable, Message: busyMessage(err)}
 		}
-
-		testResults = append(testResults, models.TestResult{
-			TestNumber: i + 1,
-			Passed:     passed,
-			Output:     output,
-			Expected:   test.ExpectedOutput,
-			Actual:     normalizedOutput,
-		})
-
-		log.Printf("🧪 Test %d: passed=%t, output='%s', expected='%s'",
-			i+1, passed, normalizedOutput, normalizedExpected)
-	}
-
-	// Формируем ответ
-	message := "✅ Все тесты пройдены!"
-	if !allTestsPassed {
-		message = "❌ Некоторые тесты не пройдены"
 	}
 
-	response := models.CheckResponse{
-		Success:     allTestsPassed,
-		Message:     message,
-		TestResults: testResults,
-		TotalTests:  len(testsToRun),
-		PassedTests: countPassedTests(testResults),
+	// Сохраняем попытку в историю, если пользователь авторизован
+	if userID, ok := optionalUserID(r); ok {
+		recordSubmission(userID, taskID, req.Language, req.Code, req.Files, req.Tests, response)
 	}
 
-	log.Printf("📊 Check completed - Success: %t, Passed: %d/%d",
-		allTestsPassed, response.PassedTests, response.TotalTests)
-
-	// Сохраняем решение в БД, если пользователь авторизован
-	auth := r.Header.Get("Authorization")
-	if auth != "" {
-		parts := strings.Fields(auth)
-		if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
-			// Пытаемся получить user_id из токена
-			claims, err := ParseTokenFromRequest(r)
-			if err == nil {
-				if userIDFloat, ok := claims["sub"].(float64); ok {
-					userID := int64(userIDFloat)
-					saveTaskSolution(userID, taskID, req.Language, req.Code, allTestsPassed, response.PassedTests, response.TotalTests)
-				}
-			}
-		}
-	}
+	// Для скрытых тестов оставляем только вердикт
+	hideResultForStudent(response)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -165,18 +109,45 @@ func CheckHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// findTask ищет опубликованную задачу в БД, а затем среди встроенных
+func findTask(language, taskID string) (models.Task, bool) {
+	task, err := getTaskFromDB(language, taskID)
+	if err == nil {
+		return task, true
+	}
+	task = getBuiltInTask(language, taskID)
+	return task, task.ID != ""
+}
+
+// optionalUserID возвращает id пользователя, если запрос содержит валидный токен
+func optionalUserID(r *http.Request) (int64, bool) {
+	claims, err := ParseTokenFromRequest(r)
+	if err != nil {
+		return 0, false
+	}
+	userIDFloat, ok := claims["sub"].(float64)
+	if !ok {
+		return 0, false
+	}
+	return int64(userIDFloat), true
+}
+
 // getTaskFromDB получает задачу из базы данных
 func getTaskFromDB(language, taskID string) (models.Task, error) {
 	var task models.Task
 
+	// К тестам задачи добавляются тесты активной версии генератора
 	query := `
-		SELECT id::text, title, description, language, template, 
-		       starter_code, tests, created_at, updated_at
-		FROM tasks 
-		WHERE language = $1 AND id::text = $2 AND is_published = true
+		SELECT t.id::text, t.title, t.description, t.language, t.template, 
+		       t.starter_code, t.tests, t.checker, t.function_signature, t.created_at, t.updated_at,
+		       COALESCE(t.entry_point, ''), t.support_files,
+		       COALESCE(t.test_set_version, 0), ts.tests
+		FROM tasks t
+		LEFT JOIN task_test_sets ts ON ts.task_id = t.id AND ts.version = t.test_set_version
+		WHERE t.language = $1 AND t.id::text = $2 AND t.is_published = true
 	`
 
-	var testsJSON []byte
+	var testsJSON, checkerJSON, functionJSON, supportJSON, generatedJSON []byte
 	var createdAt, updatedAt string // Используем string для временных меток
 	var starterCode, template sql.NullString
 
@@ -188,8 +159,14 @@ func getTaskFromDB(language, taskID string) (models.Task, error) {
 		&template,
 		&starterCode,
 		&testsJSON,
+		&checkerJSON,
+		&functionJSON,
 		&createdAt,
 		&updatedAt,
+		&task.EntryPoint,
+		&supportJSON,
+		&task.TestSetVersion,
+		&generatedJSON,
 	)
 
 	if err != nil {
@@ -210,6 +187,30 @@ func getTaskFromDB(language, taskID string) (models.Task, error) {
 		// Возвращаем задачу без тестов
 		task.Tests = []models.Test{}
 	}
+	if len(generatedJSON) > 0 {
+		var generated []models.Test
+		if err := json.Unmarshal(generatedJSON, &generated); err != nil {
+			log.Printf("Error parsing generated tests JSON: %v", err)
+		}
+		task.Tests = append(task.Tests, generated...)
+	}
+
+	// Без настроек чекера ответ сравнивается точно
+	if len(checkerJSON) > 0 {
+		if err := json.Unmarshal(checkerJSON, &task.Checker); err != nil {
+			log.Printf("Error parsing checker JSON: %v", err)
+		}
+	}
+	if len(functionJSON) > 0 {
+		if err := json.Unmarshal(functionJSON, &task.Function); err != nil {
+			log.Printf("Error parsing function JSON: %v", err)
+		}
+	}
+	if len(supportJSON) > 0 {
+		if err := json.Unmarshal(supportJSON, &task.SupportFiles); err != nil {
+			log.Printf("Error parsing support files JSON: %v", err)
+		}
+	}
 
 	return task, nil
 }
@@ -286,27 +287,6 @@ print(factorial(5))`,
 	return models.Task{} // Пустая задача если не найдено
 }
 
-// saveTaskSolution сохраняет решение задачи в БД
-func saveTaskSolution(userID int64, taskID, language, code string, success bool, passedTests, totalTests int) {
-	query := `
-	INSERT INTO task_solutions (user_id, task_id, language, code, success, passed_tests, total_tests)
-	VALUES ($1, $2, $3, $4, $5, $6, $7)
-	ON CONFLICT (user_id, task_id, language) 
-	DO UPDATE SET 
-		code = EXCLUDED.code,
-		success = EXCLUDED.success,
-		passed_tests = EXCLUDED.passed_tests,
-		total_tests = EXCLUDED.total_tests,
-		created_at = CURRENT_TIMESTAMP
-	`
-	_, err := database.DB.Exec(query, userID, taskID, language, code, success, passedTests, totalTests)
-	if err != nil {
-		log.Printf("⚠️ Ошибка при сохранении решения задачи: %v", err)
-	} else {
-		log.Printf("✅ Решение задачи сохранено: user_id=%d, task_id=%s, language=%s", userID, taskID, language)
-	}
-}
-
 // convertTaskIDToString конвертирует TaskID в строку
 func convertTaskIDToString(taskID interface{}) string {
 	switch v := taskID.(type) {
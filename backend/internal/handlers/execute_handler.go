@@ -3,8 +3,11 @@ package handlers
 import (
 	"backend/internal/executor"
 	"backend/internal/models"
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"strings"
 )
@@ -15,6 +18,69 @@ func init() {
 	codeExecutor = executor.NewExecutor()
 }
 
+// CleanupExecutor освобождает ресурсы исполнителя кода (прогретые контейнеры и т.п.)
+func CleanupExecutor() {
+	if cleaner, ok := codeExecutor.(executor.Cleaner); ok {
+		cleaner.Cleanup()
+	}
+}
+
+// executionContext помечает запуски запроса пользователем для лимитов исполнителя:
+// авторизованные пользователи различаются по id, остальные - по IP-адресу
+func executionContext(r *http.Request) context.Context {
+	if userID, ok := optionalUserID(r); ok {
+		return executor.WithUser(r.Context(), fmt.Sprintf("user:%d", userID))
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return executor.WithUser(r.Context(), "ip:"+host)
+}
+
+// executionRequest переводит запрос на запуск в запрос исполнителю. Если указана
+// задача, рядом с кодом кладутся ее открытые файлы поддержки, а точка входа по
+// умолчанию берется из задачи. Скрытые файлы нужны только при проверке: программа
+// студента могла бы их прочитать и вывести
+func executionRequest(req models.ExecutionRequest) executor.ExecutionRequest {
+	var supportFiles []models.SourceFile
+	entryPoint := req.EntryPoint
+	if req.TaskID != nil {
+		if task, ok := findTask(req.Language, convertTaskIDToString(req.TaskID)); ok {
+			supportFiles = models.PublicFiles(task.SupportFiles)
+			if entryPoint == "" {
+				entryPoint = task.EntryPoint
+			}
+		}
+	}
+
+	return executor.ExecutionRequest{
+		Code:       req.Code,
+		Language:   req.Language,
+		Inputs:     req.Inputs,
+		Files:      executionFiles(req.Files, supportFiles),
+		EntryPoint: entryPoint,
+	}
+}
+
+// busyMessage возвращает сообщение для пользователя о запуске, отклоненном лимитером
+func busyMessage(err error) string {
+	if err == executor.ErrUserBusy {
+		return "Слишком много одновременных запусков, дождитесь завершения предыдущих"
+	}
+	return "Сервер перегружен, попробуйте через несколько секунд"
+}
+
+// writeBusyError отвечает, что запуск отклонен лимитером исполнителя
+func writeBusyError(w http.ResponseWriter, err error) {
+	status := http.StatusServiceUnavailable
+	if err == executor.ErrUserBusy {
+		status = http.StatusTooManyRequests
+	}
+	w.Header().Set("Retry-After", "5")
+	http.Error(w, `{"success": false, "busy": true, "message": "`+busyMessage(err)+`"}`, status)
+}
+
 func ExecuteHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		http.Error(w, `{"success": false, "message": "Only POST method allowed"}`, http.StatusMethodNotAllowed)
@@ -22,12 +88,7 @@ func ExecuteHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Парсинг JSON
-	var req struct {
-		Code     string   `json:"code"`
-		Language string   `json:"language"`
-		Inputs   []string `json:"inputs"`
-	}
-
+	var req models.ExecutionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("❌ Failed to parse execute request: %v", err)
 		http.Error(w, `{"success": false, "message": "Invalid JSON"}`, http.StatusBadRequest)
@@ -35,7 +96,7 @@ func ExecuteHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Валидация
-	if req.Code == "" {
+	if req.Code == "" && len(req.Files) == 0 {
 		http.Error(w, `{"success": false, "message": "Code is required"}`, http.StatusBadRequest)
 		return
 	}
@@ -47,30 +108,27 @@ func ExecuteHandler(w http.ResponseWriter, r *http.Request) {
 	log.Printf("🔧 Executing code for language: %s, code length: %d, inputs: %v", req.Language, len(req.Code), req.Inputs)
 
 	// Выполняем код через выбранный executor
-	result, err := codeExecutor.Execute(req.Code, req.Language, req.Inputs)
+	result, err := codeExecutor.Execute(executionContext(r), executionRequest(req))
 	if err != nil {
+		// Клиент отключился или сервер останавливается - программа уже остановлена
+		if r.Context().Err() != nil {
+			log.Printf("🛑 Execution cancelled: %v", err)
+			return
+		}
+		if executor.IsBusy(err) {
+			log.Printf("🚦 Execution rejected: %v", err)
+			writeBusyError(w, err)
+			return
+		}
 		log.Printf("❌ Execution error: %v", err)
 		http.Error(w, `{"success": false, "message": "Execution failed: `+err.Error()+`"}`, http.StatusInternalServerError)
 		return
 	}
 
 	// Форматируем ответ
-	success := false
-	if s, ok := result["success"].(bool); ok {
-		success = s
-	} else if ec, ok := result["exitCode"].(int); ok {
-		success = ec == 0
-	}
-
-	output := ""
-	if out, ok := result["output"].(string); ok {
-		output = out
-	}
-
-	errorMsg := ""
-	if err, ok := result["error"].(string); ok {
-		errorMsg = err
-	}
+	success := result.Success()
+	output := result.Stdout
+	errorMsg := result.ErrorMessage()
 
 	// Комбинируем output и error если нужно
 	finalOutput := output
@@ -85,14 +143,19 @@ func ExecuteHandler(w http.ResponseWriter, r *http.Request) {
 	finalOutput = strings.TrimSpace(finalOutput)
 
 	message := "✅ Код выполнен успешно"
-	if !success {
+	if result.OutputLimitExceeded() {
+		message = "❌ Превышен лимит вывода"
+	} else if !success {
 		message = "❌ Ошибка выполнения кода"
 	}
 
 	response := models.ExecutionResponse{
-		Success: success,
-		Message: message,
-		Output:  finalOutput,
+		Success:   success,
+		Message:   message,
+		Output:    finalOutput,
+		TimeMs:    result.WallTime.Milliseconds(),
+		CPUTimeMs: result.CPUTime.Milliseconds(),
+		MemoryKB:  result.PeakMemory / 1024,
 	}
 
 	log.Printf("📊 Execution completed - Success: %t, Output length: %d", success, len(finalOutput))
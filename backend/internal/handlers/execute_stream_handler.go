@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"backend/internal/executor"
+	"backend/internal/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// sseKeepAliveInterval - период комментариев-пингов, чтобы прокси не закрывали
+// соединение, пока программа ничего не выводит
+const sseKeepAliveInterval = 15 * time.Second
+
+// ExecuteStreamHandler запускает код как ExecuteHandler, но передает вывод
+// программы по мере его появления через Server-Sent Events:
+//
+//	event: queued          - {"position": N} - запуск ждет в очереди исполнителя
+//	event: stdout / stderr - {"data": "..."} - очередной фрагмент вывода
+//	event: exit            - итог запуска (успех, код возврата, ошибка компиляции, время)
+//	event: error           - сбой исполнителя или сервер перегружен ("busy": true)
+//
+// Клиент отправляет POST с тем же JSON, что и в /api/execute, и читает ответ
+// потоком. Разрыв соединения останавливает программу
+func ExecuteStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, `{"success": false, "message": "Only POST method allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.ExecutionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ Failed to parse execute stream request: %v", err)
+		http.Error(w, `{"success": false, "message": "Invalid JSON"}`, http.StatusBadRequest)
+		return
+	}
+	if req.Code == "" && len(req.Files) == 0 {
+		http.Error(w, `{"success": false, "message": "Code is required"}`, http.StatusBadRequest)
+		return
+	}
+	if req.Language == "" {
+		http.Error(w, `{"success": false, "message": "Language is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	events, err := newSSEWriter(w)
+	if err != nil {
+		log.Printf("❌ Streaming is not supported: %v", err)
+		http.Error(w, `{"success": false, "message": "Streaming is not supported"}`, http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("📡 Streaming execution for language: %s, code length: %d", req.Language, len(req.Code))
+
+	stdout := newOutputChunkWriter(func(data string) {
+		events.send("stdout", map[string]string{"data": data})
+	})
+	stderr := newOutputChunkWriter(func(data string) {
+		events.send("stderr", map[string]string{"data": data})
+	})
+
+	ctx := executor.WithQueueListener(executionContext(r), func(position int) {
+		events.send("queued", map[string]int{"position": position})
+	})
+
+	stopKeepAlive := events.keepAlive(sseKeepAliveInterval)
+	execReq := executionRequest(req)
+	execReq.Stdout = stdout
+	execReq.Stderr = stderr
+	result, err := codeExecutor.Execute(ctx, execReq)
+	stopKeepAlive()
+
+	if err != nil {
+		if r.Context().Err() != nil {
+			log.Printf("🛑 Streaming execution cancelled: %v", err)
+			return
+		}
+		if executor.IsBusy(err) {
+			log.Printf("🚦 Streaming execution rejected: %v", err)
+			events.send("error", map[string]interface{}{
+				"success": false,
+				"busy":    true,
+				"message": busyMessage(err),
+			})
+			return
+		}
+		log.Printf("❌ Streaming execution error: %v", err)
+		events.send("error", map[string]interface{}{
+			"success": false,
+			"message": "Execution failed: " + err.Error(),
+		})
+		return
+	}
+	stdout.flush()
+	stderr.flush()
+
+	summary := executionSummary(result)
+	events.send("exit", summary)
+
+	log.Printf("📊 Streaming execution completed - Success: %t", result.Success())
+}
+
+// sseWriter отправляет события Server-Sent Events. Вывод программы приходит
+// из разных горутин, поэтому запись в ответ защищена мьютексом
+type sseWriter struct {
+	mu         sync.Mutex
+	w          http.ResponseWriter
+	controller *http.ResponseController
+}
+
+// newSSEWriter отправляет заголовки потока событий
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, error) {
+	controller := http.NewResponseController(w)
+	// Программа может работать дольше WriteTimeout сервера
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Отключает буферизацию в nginx
+	w.WriteHeader(http.StatusOK)
+	if err := controller.Flush(); err != nil {
+		return nil, err
+	}
+	return &sseWriter{w: w, controller: controller}, nil
+}
+
+// send отправляет событие с JSON-данными
+func (s *sseWriter) send(event string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("❌ Failed to encode %s event: %v", event, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Ошибка записи означает разрыв соединения, программу остановит отмена контекста запроса
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return
+	}
+	s.controller.Flush()
+}
+
+// keepAlive периодически отправляет комментарий до вызова возвращенной функции
+func (s *sseWriter) keepAlive(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				if _, err := fmt.Fprint(s.w, ": ping\n\n"); err == nil {
+					s.controller.Flush()
+				}
+				s.mu.Unlock()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// executionSummary - итог запуска для потоковых ответов. Вывод к этому моменту
+// уже передан потоком, поэтому в error только ошибки, которых в нем не было
+func executionSummary(result *executor.ExecutionResult) map[string]interface{} {
+	success := result.Success()
+	message := "✅ Код выполнен успешно"
+	if !success {
+		message = "❌ Ошибка выполнения кода"
+	}
+
+	errorMessage := ""
+	switch {
+	case result.CompileFailed:
+		errorMessage = result.ErrorMessage()
+	case result.TimedOut:
+		errorMessage = result.Stderr
+	case result.OOMKilled:
+		errorMessage = "Memory limit exceeded"
+	case result.OutputLimitExceeded():
+		errorMessage = "Output limit exceeded"
+	}
+
+	return map[string]interface{}{
+		"success":        success,
+		"message":        message,
+		"exit_code":      result.ExitCode,
+		"error":          errorMessage,
+		"compile_output": result.CompileOutput,
+		"compile_failed": result.CompileFailed,
+		"timed_out":      result.TimedOut,
+		"oom_killed":     result.OOMKilled,
+		"output_limit":   result.OutputLimitExceeded(),
+		"wall_time_ms":   result.WallTime.Milliseconds(),
+		"cpu_time_ms":    result.CPUTime.Milliseconds(),
+		"memory_kb":      result.PeakMemory / 1024,
+	}
+}
+
+// outputChunkWriter передает вывод программы фрагментами в emit. Незавершенный
+// UTF-8 символ на конце фрагмента откладывается до следующей записи,
+// чтобы не отправить клиенту половину символа
+type outputChunkWriter struct {
+	emit    func(data string)
+	mu      sync.Mutex
+	pending []byte
+}
+
+func newOutputChunkWriter(emit func(data string)) *outputChunkWriter {
+	return &outputChunkWriter{emit: emit}
+}
+
+// Write реализует io.Writer
+func (s *outputChunkWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := append(s.pending, p...)
+	cut := len(data)
+	// Начало последнего символа ищем не дальше utf8.UTFMax байт от конца
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+
+	s.pending = append([]byte(nil), data[cut:]...)
+	if cut > 0 {
+		s.emit(string(data[:cut]))
+	}
+	return len(p), nil
+}
+
+// flush отправляет отложенные байты после завершения программы
+func (s *outputChunkWriter) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) > 0 {
+		s.emit(string(s.pending))
+		s.pending = nil
+	}
+}
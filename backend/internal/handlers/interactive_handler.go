@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"backend/internal/executor"
+	"backend/internal/models"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Лимиты интерактивного запуска по умолчанию: общее время работы программы
+// и время без ввода и вывода, после которого сессия закрывается
+const (
+	defaultInteractiveTimeLimit = 300 * time.Second
+	defaultInteractiveIdleLimit = 60 * time.Second
+)
+
+// interactiveInputBuffer - сколько сообщений ввода может ждать, пока программа
+// их не прочитала. Клиент, который присылает больше, получает ошибку
+const interactiveInputBuffer = 64
+
+// interactiveWriteTimeout ограничивает отправку одного сообщения клиенту
+const interactiveWriteTimeout = 10 * time.Second
+
+// Причины завершения интерактивного запуска в сообщении exit
+const (
+	interactiveReasonFinished     = "finished"
+	interactiveReasonTimeLimit    = "time_limit"
+	interactiveReasonIdle         = "idle"
+	interactiveReasonKilled       = "killed"
+	interactiveReasonDisconnected = "disconnected"
+)
+
+// interactiveMessage - сообщение протокола интерактивного запуска
+type interactiveMessage struct {
+	Type     string `json:"type"`
+	Code     string `json:"code,omitempty"`
+	Language string `json:"language,omitempty"`
+	Data     string `json:"data,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Position int    `json:"position,omitempty"`
+
+	// Многофайловая программа в start, как в /api/execute
+	Files      []models.SourceFile `json:"files,omitempty"`
+	EntryPoint string              `json:"entry_point,omitempty"`
+	TaskID     interface{}         `json:"task_id,omitempty"`
+}
+
+// NewInteractiveRunHandler возвращает обработчик интерактивного запуска через WebSocket.
+// Протокол (JSON-сообщения с полем type):
+//
+//	клиент: start {code, language} - первое сообщение, запускает программу
+//	                                 (files, entry_point и task_id - как в /api/execute)
+//	клиент: stdin {data}           - ввод программы (перевод строки добавляет клиент)
+//	клиент: eof                    - закрыть stdin программы
+//	клиент: kill                   - остановить программу
+//	сервер: queued {position}      - запуск ждет в очереди исполнителя
+//	сервер: stdout / stderr {data} - очередной фрагмент вывода
+//	сервер: exit                   - итог запуска, как в /api/execute/stream, и reason
+//	сервер: error {message}        - ошибка запроса или исполнителя; busy - сервер перегружен
+//
+// Время работы программы ограничено INTERACTIVE_TIME_LIMIT_SECONDS, время без
+// ввода и вывода - INTERACTIVE_IDLE_SECONDS. Соединения принимаются только
+// с allowedOrigins или с того же хоста. Отмена shutdownCtx (остановка сервера)
+// убивает запущенные программы: Shutdown сервера захваченные соединения не ждет
+func NewInteractiveRunHandler(shutdownCtx context.Context, allowedOrigins []string) http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return checkWebSocketOrigin(r, allowedOrigins)
+		},
+	}
+	timeLimit := time.Duration(envPositiveInt("INTERACTIVE_TIME_LIMIT_SECONDS", int(defaultInteractiveTimeLimit/time.Second))) * time.Second
+	idleLimit := time.Duration(envPositiveInt("INTERACTIVE_IDLE_SECONDS", int(defaultInteractiveIdleLimit/time.Second))) * time.Second
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Upgrade сам отвечает клиенту ошибкой
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Printf("❌ WebSocket upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		// Таймауты сервера на захваченное соединение не распространяются, но
+		// выставленные им дедлайны остаются - снимаем их
+		conn.NetConn().SetDeadline(time.Time{})
+
+		session := &interactiveSession{conn: conn, timeLimit: timeLimit, idleLimit: idleLimit}
+		// Сессия переживает обработчик, поэтому ее контекст - контекст остановки
+		// сервера; из запроса берется только пользователь
+		session.serve(executionContext(r.WithContext(shutdownCtx)))
+	}
+}
+
+// checkWebSocketOrigin разрешает запросы без Origin (не из браузера), с того
+// же хоста и с разрешенных адресов
+func checkWebSocketOrigin(r *http.Request, allowedOrigins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range allowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// interactiveSession - одно WebSocket-соединение с запущенной программой
+type interactiveSession struct {
+	conn      *websocket.Conn
+	timeLimit time.Duration
+	idleLimit time.Duration
+
+	writeMu      sync.Mutex // Вывод программы отправляется из разных горутин
+	lastActivity atomic.Int64
+	reasonMu     sync.Mutex
+	reason       string // Почему программа была остановлена до завершения
+}
+
+// serve ждет сообщение start, запускает программу и передает ввод и вывод до ее завершения
+func (s *interactiveSession) serve(sessionCtx context.Context) {
+	var start interactiveMessage
+	s.conn.SetReadDeadline(time.Now().Add(s.idleLimit))
+	if err := s.conn.ReadJSON(&start); err != nil {
+		log.Printf("⚠️ Interactive session closed before start: %v", err)
+		return
+	}
+	s.conn.SetReadDeadline(time.Time{})
+
+	if start.Type != "start" {
+		s.fail("Первым сообщением должен быть start")
+		return
+	}
+	if start.Code == "" && len(start.Files) == 0 {
+		s.fail("Code is required")
+		return
+	}
+	if start.Language == "" {
+		s.fail("Language is required")
+		return
+	}
+
+	log.Printf("🔌 Interactive execution for language: %s, code length: %d", start.Language, len(start.Code))
+
+	ctx, cancel := context.WithCancel(sessionCtx)
+	defer cancel()
+	ctx = executor.WithQueueListener(ctx, func(position int) {
+		s.touch() // Ожидание в очереди - не простой клиента
+		s.send(interactiveMessage{Type: "queued", Position: position})
+	})
+	s.touch()
+
+	stdinReader, stdinWriter := io.Pipe()
+	// Программа завершилась - ввод больше никто не прочитает
+	defer stdinReader.Close()
+
+	input := make(chan string, interactiveInputBuffer)
+	go feedInput(input, stdinWriter)
+	go s.readClient(input, cancel)
+	go s.watchIdle(ctx, cancel)
+
+	stdout := newOutputChunkWriter(func(data string) {
+		s.touch()
+		s.send(interactiveMessage{Type: "stdout", Data: data})
+	})
+	stderr := newOutputChunkWriter(func(data string) {
+		s.touch()
+		s.send(interactiveMessage{Type: "stderr", Data: data})
+	})
+
+	req := executionRequest(models.ExecutionRequest{
+		TaskID:     start.TaskID,
+		Code:       start.Code,
+		Language:   start.Language,
+		Files:      start.Files,
+		EntryPoint: start.EntryPoint,
+	})
+	req.Timeout = s.timeLimit
+	req.Stdin = stdinReader
+	req.Stdout = stdout
+	req.Stderr = stderr
+	result, err := codeExecutor.Execute(ctx, req)
+	stdout.flush()
+	stderr.flush()
+
+	if err != nil {
+		reason := s.stopReason()
+		if reason == "" && executor.IsBusy(err) {
+			log.Printf("🚦 Interactive execution rejected: %v", err)
+			s.send(map[string]interface{}{"type": "error", "success": false, "busy": true, "message": busyMessage(err)})
+			s.close()
+			return
+		}
+		if reason == "" {
+			log.Printf("❌ Interactive execution error: %v", err)
+			s.fail("Execution failed: " + err.Error())
+			return
+		}
+
+		log.Printf("🛑 Interactive execution stopped: %s", reason)
+		if reason != interactiveReasonDisconnected {
+			s.sendExit(map[string]interface{}{
+				"success": false,
+				"message": "❌ Программа остановлена",
+				"reason":  reason,
+			})
+		}
+		return
+	}
+
+	summary := executionSummary(result)
+	summary["reason"] = interactiveReasonFinished
+	if result.TimedOut {
+		summary["reason"] = interactiveReasonTimeLimit
+	}
+	s.sendExit(summary)
+
+	log.Printf("📊 Interactive execution completed - Success: %t", result.Success())
+}
+
+// readClient читает сообщения клиента до закрытия соединения: ввод передается
+// в input, kill и разрыв соединения останавливают программу
+func (s *interactiveSession) readClient(input chan<- string, cancel context.CancelFunc) {
+	inputClosed := false
+	closeInput := func() {
+		if !inputClosed {
+			inputClosed = true
+			close(input)
+		}
+	}
+	defer closeInput()
+
+	for {
+		var msg interactiveMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			// Если программа уже завершилась, отмена ни на что не влияет
+			s.stop(interactiveReasonDisconnected, cancel)
+			return
+		}
+
+		switch msg.Type {
+		case "stdin":
+			if inputClosed {
+				s.send(interactiveMessage{Type: "error", Message: "Ввод уже закрыт"})
+				continue
+			}
+			s.touch()
+			select {
+			case input <- msg.Data:
+			default:
+				s.send(interactiveMessage{Type: "error", Message: "Программа не успевает читать ввод"})
+			}
+		case "eof":
+			closeInput()
+		case "kill":
+			s.stop(interactiveReasonKilled, cancel)
+		default:
+			s.send(interactiveMessage{Type: "error", Message: "Неизвестный тип сообщения: " + msg.Type})
+		}
+	}
+}
+
+// feedInput передает ввод программе, пока канал не закрыт или программа не завершилась
+func feedInput(input <-chan string, stdin *io.PipeWriter) {
+	defer stdin.Close()
+	for data := range input {
+		if _, err := io.WriteString(stdin, data); err != nil {
+			// Программа завершилась; дочитываем канал, чтобы не блокировать readClient
+			for range input {
+			}
+			return
+		}
+	}
+}
+
+// watchIdle останавливает программу, если дольше idleLimit не было ни ввода, ни вывода
+func (s *interactiveSession) watchIdle(ctx context.Context, cancel context.CancelFunc) {
+	interval := s.idleLimit / 10
+	if interval > time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if time.Since(time.Unix(0, s.lastActivity.Load())) > s.idleLimit {
+				s.stop(interactiveReasonIdle, cancel)
+				return
+			}
+		}
+	}
+}
+
+// touch отмечает активность ввода или вывода
+func (s *interactiveSession) touch() {
+	s.lastActivity.Store(time.Now().UnixNano())
+}
+
+// stop запоминает первую причину остановки и отменяет запуск
+func (s *interactiveSession) stop(reason string, cancel context.CancelFunc) {
+	s.reasonMu.Lock()
+	if s.reason == "" {
+		s.reason = reason
+	}
+	s.reasonMu.Unlock()
+	cancel()
+}
+
+// stopReason возвращает причину остановки программы ("" - не останавливалась)
+func (s *interactiveSession) stopReason() string {
+	s.reasonMu.Lock()
+	defer s.reasonMu.Unlock()
+	return s.reason
+}
+
+// send отправляет сообщение клиенту. Ошибка записи означает разрыв соединения,
+// его обнаружит readClient
+func (s *interactiveSession) send(payload interface{}) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(interactiveWriteTimeout))
+	s.conn.WriteJSON(payload)
+}
+
+// sendExit отправляет итог запуска и закрывает соединение
+func (s *interactiveSession) sendExit(summary map[string]interface{}) {
+	summary["type"] = "exit"
+	s.send(summary)
+	s.close()
+}
+
+// fail отправляет ошибку и закрывает соединение
+func (s *interactiveSession) fail(message string) {
+	s.send(map[string]interface{}{"type": "error", "success": false, "message": message})
+	s.close()
+}
+
+// close сообщает клиенту о штатном закрытии соединения
+func (s *interactiveSession) close() {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(interactiveWriteTimeout))
+}
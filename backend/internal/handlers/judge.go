@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"backend/internal/checker"
+	"backend/internal/executor"
+	"backend/internal/harness"
+	"backend/internal/models"
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// judgeSolution компилирует решение один раз и прогоняет его на тестах, сравнивая
+// вывод чекером задачи (nil - точное совпадение). files - остальные файлы
+// многофайлового решения, рядом с ними кладутся файлы поддержки задачи. Решение задачи с функцией
+// оборачивается харнессом, и сравниваются возвращаемые значения. Результаты содержат все данные тестов, включая скрытые - перед отправкой
+// студенту их нужно убрать через hideHiddenTestDetails.
+// Ошибка возвращается только при отмене ctx или если исполнитель перегружен (executor.IsBusy)
+func judgeSolution(ctx context.Context, code, language string, files []models.SourceFile, tests []models.Test, task models.Task) (*models.CheckResponse, error) {
+	var testResults []models.TestResult
+
+	// Решение и чекер занимают одно место в лимитере исполнителя
+	ctx, release, err := executor.Acquire(ctx, codeExecutor)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if task.Function != nil {
+		wrapped, err := harness.Wrap(language, code, task.Function)
+		var prepared []models.Test
+		if err == nil {
+			prepared, err = harness.PrepareTests(tests)
+		}
+		if err != nil {
+			// Некорректная сигнатура или тесты - ошибка задачи, а не решения
+			log.Printf("❌ Failed to prepare function harness: %v", err)
+			return taskErrorResponse(tests, "Function harness error: "+err.Error()), nil
+		}
+		code, tests = wrapped, prepared
+	}
+
+	var outputChecker checker.Checker
+	if task.Function != nil {
+		outputChecker, err = checker.NewFunction(ctx, task.Checker, codeExecutor)
+	} else {
+		outputChecker, err = checker.New(ctx, task.Checker, codeExecutor)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// Сломанный чекер - ошибка задачи, а не решения
+		log.Printf("❌ Failed to prepare checker: %v", err)
+		return taskErrorResponse(tests, "Checker error: "+err.Error()), nil
+	}
+	defer outputChecker.Close()
+
+	session, compileResult, err := executor.PrepareSession(ctx, codeExecutor, executor.ExecutionRequest{
+		Code:       code,
+		Language:   language,
+		Files:      executionFiles(files, task.SupportFiles),
+		EntryPoint: task.EntryPoint,
+	})
+	switch {
+	case err != nil:
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Printf("❌ Failed to prepare execution session: %v", err)
+		testResults = append(testResults, firstTestResult(tests, models.VerdictInternalError, err.Error()))
+	case compileResult != nil:
+		// Ошибка компиляции одинакова для всех тестов - тесты не запускаем
+		verdict := executionVerdict(compileResult)
+		log.Printf("🧪 Compilation failed: verdict=%s", verdict)
+		testResults = append(testResults, firstTestResult(tests, verdict, compileResult.ErrorMessage()))
+	default:
+		defer session.Close()
+		testResults, err = runTests(ctx, session, tests, outputChecker, task.Function != nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return judgeResponse(tests, testResults), nil
+}
+
+// executionFiles собирает файлы запуска: файлы студента и файлы поддержки
+// задачи, которые решение не может подменить
+func executionFiles(files, supportFiles []models.SourceFile) []executor.File {
+	var result []executor.File
+	for _, file := range files {
+		result = append(result, executor.File{Name: file.Name, Content: file.Content})
+	}
+	for _, file := range supportFiles {
+		result = append(result, executor.File{Name: file.Name, Content: file.Content, ReadOnly: true})
+	}
+	return result
+}
+
+// taskErrorResponse - итог проверки, когда задача настроена некорректно
+func taskErrorResponse(tests []models.Test, message string) *models.CheckResponse {
+	return judgeResponse(tests, []models.TestResult{firstTestResult(tests, models.VerdictInternalError, message)})
+}
+
+// firstTestResult - результат первого теста, когда до запуска тестов дело не
+// дошло (ошибка задачи, компиляции или исполнителя). Тестов может не быть
+func firstTestResult(tests []models.Test, verdict models.Verdict, message string) models.TestResult {
+	result := models.TestResult{
+		TestNumber: 1,
+		Verdict:    verdict,
+		Error:      message,
+	}
+	if len(tests) > 0 {
+		result.Expected = tests[0].ExpectedOutput
+		result.Input = tests[0].Input
+		result.IsHidden = tests[0].IsHidden
+	}
+	return result
+}
+
+// judgeResponse собирает итог проверки по результатам тестов
+func judgeResponse(tests []models.Test, testResults []models.TestResult) *models.CheckResponse {
+	verdict := aggregateVerdict(testResults)
+	response := &models.CheckResponse{
+		Success:     verdict == models.VerdictAccepted,
+		Message:     verdictMessage(verdict),
+		Verdict:     verdict,
+		TestResults: testResults,
+		TotalTests:  len(tests),
+		PassedTests: countPassedTests(testResults),
+	}
+	for _, result := range testResults {
+		response.TimeElapsed += result.TimeMs
+		response.CPUTimeMs += result.CPUTimeMs
+		if result.MemoryKB > response.MaxMemoryKB {
+			response.MaxMemoryKB = result.MemoryKB
+		}
+	}
+
+	log.Printf("📊 Check completed - Verdict: %s, Passed: %d/%d",
+		verdict, response.PassedTests, response.TotalTests)
+	return response
+}
+
+// runTests запускает подготовленную программу на всех тестах и проверяет вывод чекером.
+// Для задач с функцией чекер сравнивает результат, напечатанный харнессом.
+// Ошибка возвращается только при отмене ctx
+func runTests(ctx context.Context, session executor.Session, tests []models.Test, outputChecker checker.Checker, function bool) ([]models.TestResult, error) {
+	var testResults []models.TestResult
+
+	for i, test := range tests {
+		// Подготавливаем входные данные если есть
+		var inputs []string
+		if test.Input != "" {
+			// Разделяем многострочный ввод
+			inputs = strings.Split(test.Input, "\n")
+		}
+
+		// Выполняем программу с текущим тестом
+		result, err := session.Run(ctx, inputs, time.Duration(test.Timeout)*time.Millisecond)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("❌ Test %d execution error: %v", i+1, err)
+			testResults = append(testResults, models.TestResult{
+				TestNumber: i + 1,
+				Passed:     false,
+				Verdict:    models.VerdictInternalError,
+				Output:     "",
+				Error:      err.Error(),
+				Expected:   test.ExpectedOutput,
+				Actual:     "",
+				Input:      test.Input,
+				IsHidden:   test.IsHidden,
+			})
+			continue
+		}
+
+		// Получаем вывод
+		output := result.Stdout
+		errorMsg := result.ErrorMessage()
+
+		// Харнесс печатает результат функции после собственного вывода решения
+		answer, returned := output, true
+		if function {
+			answer, output, returned = harness.ParseOutput(result.Stdout)
+		}
+
+		// Нормализуем вывод для сравнения
+		normalizedOutput := normalizeOutput(answer)
+		normalizedExpected := normalizeOutput(test.ExpectedOutput)
+
+		// Вердикт по результату запуска, затем проверяем ответ чекером
+		var checkerMessage string
+		verdict := executionVerdict(result)
+		if verdict == "" && !returned {
+			verdict = models.VerdictRuntimeError
+			errorMsg = strings.TrimSpace("Function did not return a result\n" + errorMsg)
+		}
+		if verdict == "" {
+			checkResult, err := outputChecker.Check(ctx, test.Input, test.ExpectedOutput, answer)
+			switch {
+			case err != nil:
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				log.Printf("❌ Test %d checker error: %v", i+1, err)
+				verdict = models.VerdictInternalError
+				errorMsg = "Checker error: " + err.Error()
+			case checkResult.Passed:
+				verdict = models.VerdictAccepted
+			default:
+				verdict = models.VerdictWrongAnswer
+			}
+			checkerMessage = checkResult.Message
+		}
+		passed := verdict == models.VerdictAccepted
+
+		testResults = append(testResults, models.TestResult{
+			TestNumber: i + 1,
+			Passed:     passed,
+			Verdict:    verdict,
+			Output:     output,
+			Error:      errorMsg,
+			Expected:   test.ExpectedOutput,
+			Actual:     normalizedOutput,
+			Input:      test.Input,
+			IsHidden:   test.IsHidden,
+			Timeout:    verdict == models.VerdictTimeLimitExceeded,
+			TimeMs:     result.WallTime.Milliseconds(),
+			CPUTimeMs:  result.CPUTime.Milliseconds(),
+			MemoryKB:   result.PeakMemory / 1024,
+
+			CheckerMessage: checkerMessage,
+		})
+
+		log.Printf("🧪 Test %d: verdict=%s, time=%v, cpu=%v, memory=%dKB, output='%s', expected='%s'",
+			i+1, verdict, result.WallTime, result.CPUTime, result.PeakMemory/1024, normalizedOutput, normalizedExpected)
+	}
+
+	return testResults, nil
+}
+
+// hideResultForStudent убирает из результата проверки всё, что студент видеть
+// не должен. Через неё проходит каждый результат, который отдается не преподавателю
+func hideResultForStudent(result *models.CheckResponse) {
+	if result == nil {
+		return
+	}
+	hideHiddenTestDetails(result.TestResults)
+	hideStressAnswer(result)
+}
+
+// hideHiddenTestDetails убирает из результатов скрытых тестов входные данные
+// и вывод, оставляя только вердикт. Ошибка компиляции не зависит от теста,
+// поэтому её текст сохраняется
+func hideHiddenTestDetails(results []models.TestResult) {
+	for i := range results {
+		result := &results[i]
+		if !result.IsHidden {
+			continue
+		}
+		result.Input = ""
+		result.Output = ""
+		result.Expected = ""
+		result.Actual = ""
+		result.CheckerMessage = ""
+		if result.Verdict != models.VerdictCompilationError {
+			result.Error = ""
+		}
+	}
+}
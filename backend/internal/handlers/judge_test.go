@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"backend/internal/models"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestJudgeTaskErrors(t *testing.T) {
+	add := &models.Function{Name: "add", ReturnType: "int", Params: []models.FunctionParam{{Name: "a", Type: "int"}}}
+
+	tests := []struct {
+		name     string
+		function *models.Function
+		tests    []models.Test
+		input    string // Ввод первого теста в результате
+	}{
+		{"invalid test args", add, []models.Test{
+			{Input: "first", Args: []json.RawMessage{json.RawMessage("[1,")}, ExpectedReturn: json.RawMessage("1")},
+		}, "first"},
+		{"invalid signature", &models.Function{Name: "1add", ReturnType: "int"}, []models.Test{
+			{Input: "first", ExpectedReturn: json.RawMessage("1")},
+		}, "first"},
+		{"no tests", &models.Function{Name: "add", ReturnType: "void"}, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := models.Task{Title: "Add", Language: "python", Function: tt.function}
+			response, err := judgeSolution(context.Background(), "def add(a):\n    return a", "python", nil, tt.tests, task)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.Verdict != models.VerdictInternalError || len(response.TestResults) != 1 {
+				t.Fatalf("verdict = %s, results = %+v, want one IE result", response.Verdict, response.TestResults)
+			}
+			if result := response.TestResults[0]; result.Input != tt.input || result.Error == "" {
+				t.Errorf("result = %+v, want input %q and an error", result, tt.input)
+			}
+		})
+	}
+}
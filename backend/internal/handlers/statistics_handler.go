@@ -44,10 +44,10 @@ func StatisticsHandler(w http.ResponseWriter, r *http.Request) {
 	// Получаем всех студентов (не преподавателей)
 	query := `
 		SELECT u.id, u.username, u.email,
-			COUNT(ts.id) as total_solutions,
+			COUNT(ts.submission_id) as total_solutions,
 			COUNT(CASE WHEN ts.success = true THEN 1 END) as solved_tasks
 		FROM users u
-		LEFT JOIN task_solutions ts ON u.id = ts.user_id
+		LEFT JOIN best_solutions ts ON u.id = ts.user_id
 		WHERE u.role = 'student' OR u.role IS NULL
 		GROUP BY u.id, u.username, u.email
 		ORDER BY u.username
@@ -79,7 +79,7 @@ func StatisticsHandler(w http.ResponseWriter, r *http.Request) {
 			SELECT language,
 				COUNT(*) as total_tasks,
 				COUNT(CASE WHEN success = true THEN 1 END) as solved_tasks
-			FROM task_solutions
+			FROM best_solutions
 			WHERE user_id = $1
 			GROUP BY language
 		`
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"backend/internal/checker"
+	"backend/internal/database"
+	"backend/internal/executor"
+	"backend/internal/harness"
+	"backend/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultStressIterations = 200              // Максимум случайных тестов за один прогон
+	defaultStressTimeout    = 30 * time.Second // Время на поиск контрпримера
+	// После стольких найденных расхождений поиск меньшего контрпримера прекращается
+	maxStressCounterexamples = 20
+)
+
+// stressIterations возвращает лимит тестов стресс-тестирования из STRESS_MAX_ITERATIONS
+func stressIterations() int {
+	return envPositiveInt("STRESS_MAX_ITERATIONS", defaultStressIterations)
+}
+
+// stressTimeout возвращает время стресс-тестирования из STRESS_TIMEOUT_SECONDS
+func stressTimeout() time.Duration {
+	return time.Duration(envPositiveInt("STRESS_TIMEOUT_SECONDS", int(defaultStressTimeout/time.Second))) * time.Second
+}
+
+// stressApplicable сообщает, есть ли смысл искать контрпример для вердикта:
+// решение скомпилировалось, но ошиблось на каком-то тесте
+func stressApplicable(verdict models.Verdict) bool {
+	switch verdict {
+	case models.VerdictWrongAnswer, models.VerdictRuntimeError, models.VerdictTimeLimitExceeded,
+		models.VerdictMemoryLimitExceeded, models.VerdictOutputLimitExceeded:
+		return true
+	}
+	return false
+}
+
+// loadStressSetup загружает эталонное решение задачи и генератор её активной
+// версии тестов. nil - у задачи их нет
+func loadStressSetup(taskID string) (*models.ReferenceSolution, *models.TestGenerator, error) {
+	var referenceJSON, generatorJSON []byte
+	err := database.DB.QueryRow(`
+		SELECT t.reference_solution, ts.generator
+		FROM tasks t
+		LEFT JOIN task_test_sets ts ON ts.task_id = t.id AND ts.version = t.test_set_version
+		WHERE t.id::text = $1
+	`, taskID).Scan(&referenceJSON, &generatorJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reference *models.ReferenceSolution
+	var generator *models.TestGenerator
+	if len(referenceJSON) > 0 {
+		if err := json.Unmarshal(referenceJSON, &reference); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(generatorJSON) > 0 {
+		if err := json.Unmarshal(generatorJSON, &generator); err != nil {
+			return nil, nil, err
+		}
+	}
+	return reference, generator, nil
+}
+
+// stressTest сравнивает решение с эталонным на случайных тестах генератора задачи,
+// пока не кончатся лимиты тестов или времени, и возвращает наименьший (по размеру
+// ввода) тест, на котором решение ошибается. Ошибка возвращается только при отмене
+// ctx или если исполнитель перегружен (executor.IsBusy)
+func stressTest(ctx context.Context, code, language string, files []models.SourceFile, task models.Task) (*models.StressResult, error) {
+	reference, generator, err := loadStressSetup(task.ID)
+	if err != nil {
+		log.Printf("⚠️ Stress setup for task %s: %v", task.ID, err)
+	}
+	if reference == nil || generator == nil {
+		return &models.StressResult{
+			Status:  models.StressUnavailable,
+			Message: "Для стресс-тестирования у задачи должны быть эталонное решение и генератор тестов",
+		}, nil
+	}
+	task.ReferenceSolution = reference
+
+	ctx, release, err := executor.Acquire(ctx, codeExecutor)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Поиск ограничен по времени отдельно от запроса: по истечении лимита
+	// возвращается то, что успели найти
+	stressCtx, cancel := context.WithTimeout(ctx, stressTimeout())
+	defer cancel()
+
+	result, err := runStress(stressCtx, code, language, files, task, *generator)
+	var failure *generationFailure
+	switch {
+	case errors.As(err, &failure):
+		return &models.StressResult{Status: models.StressError, Message: failure.message}, nil
+	case err != nil && ctx.Err() != nil:
+		return nil, ctx.Err()
+	case err != nil && stressCtx.Err() == nil:
+		// Решение или чекер не запустились - сравнивать не с чем
+		return &models.StressResult{Status: models.StressError, Message: err.Error()}, nil
+	}
+
+	if result.Counterexample != nil {
+		result.Status = models.StressCounterexample
+		result.Message = fmt.Sprintf("Найден тест, на котором решение расходится с эталонным (проверено тестов: %d)", result.Iterations)
+	} else {
+		result.Status = models.StressNotFound
+		result.Message = fmt.Sprintf("Расхождений с эталонным решением не найдено (проверено тестов: %d)", result.Iterations)
+	}
+	log.Printf("🔬 Stress test for task %s: %s, iterations=%d, counterexamples=%d",
+		task.ID, result.Status, result.Iterations, result.Counterexamples)
+	return result, nil
+}
+
+// runStress запускает генератор, эталонное решение и решение студента на случайных
+// seed. При истечении ctx возвращает уже найденное без ошибки
+func runStress(ctx context.Context, code, language string, files []models.SourceFile, task models.Task, generator models.TestGenerator) (*models.StressResult, error) {
+	result := &models.StressResult{}
+
+	generatorSession, err := prepareGenerator(ctx, task, generator)
+	if err != nil {
+		return result, err
+	}
+	defer generatorSession.Close()
+
+	referenceSession, err := prepareReference(ctx, task)
+	if err != nil {
+		return result, err
+	}
+	defer referenceSession.Close()
+
+	var outputChecker checker.Checker
+	if task.Function != nil {
+		if code, err = harness.Wrap(language, code, task.Function); err != nil {
+			return result, err
+		}
+		outputChecker, err = checker.NewFunction(ctx, task.Checker, codeExecutor)
+	} else {
+		outputChecker, err = checker.New(ctx, task.Checker, codeExecutor)
+	}
+	if err != nil {
+		return result, err
+	}
+	defer outputChecker.Close()
+
+	session, compileResult, err := executor.PrepareSession(ctx, codeExecutor, executor.ExecutionRequest{
+		Code:       code,
+		Language:   language,
+		Files:      executionFiles(files, task.SupportFiles),
+		EntryPoint: task.EntryPoint,
+	})
+	if err != nil {
+		return result, err
+	}
+	if compileResult != nil {
+		return result, fmt.Errorf("solution failed to compile: %s", compileResult.ErrorMessage())
+	}
+	defer session.Close()
+
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	limit := stressIterations()
+	for result.Iterations < limit && result.Counterexamples < maxStressCounterexamples {
+		// Seed помещается в int32, чтобы генератор мог читать его в int любого языка
+		seed := int64(random.Int31())
+		test, err := generateTest(ctx, generatorSession, task, seed)
+		if err == nil {
+			err = referenceAnswer(ctx, referenceSession, task, &test, seed)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, nil
+			}
+			return result, err
+		}
+
+		tests := []models.Test{test}
+		if task.Function != nil {
+			if tests, err = harness.PrepareTests(tests); err != nil {
+				return result, err
+			}
+		}
+		tests[0].IsHidden = false
+
+		testResults, err := runTests(ctx, session, tests, outputChecker, task.Function != nil)
+		if err != nil {
+			return result, nil
+		}
+		result.Iterations++
+
+		testResult := testResults[0]
+		if testResult.Passed || testResult.Verdict == models.VerdictInternalError {
+			continue
+		}
+		result.Counterexamples++
+		if result.Counterexample == nil || len(testResult.Input) < len(result.Counterexample.Input) {
+			testResult.TestNumber = result.Iterations
+			result.Counterexample = &testResult
+			result.Seed = seed
+		}
+	}
+	return result, nil
+}
+
+// hideStressAnswer убирает из итога стресс-тестирования ответ эталонного решения:
+// студент видит контрпример и свой вывод, но не может получать ответы на любые тесты
+func hideStressAnswer(response *models.CheckResponse) {
+	if response.Stress == nil || response.Stress.Counterexample == nil {
+		return
+	}
+	response.Stress.Counterexample.Expected = ""
+	response.Stress.Counterexample.CheckerMessage = ""
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SubmitHandler ставит решение в очередь проверки и сразу возвращает его ID.
+// Результат получают через GET /api/submissions/{id}
+func SubmitHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, `{"success": false, "message": "Only POST method allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.CheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ Failed to parse submission: %v", err)
+		http.Error(w, `{"success": false, "message": "Invalid JSON"}`, http.StatusBadRequest)
+		return
+	}
+
+	// Валидация
+	if req.Code == "" && len(req.Files) == 0 {
+		http.Error(w, `{"success": false, "message": "Code is required"}`, http.StatusBadRequest)
+		return
+	}
+	if req.Language == "" {
+		http.Error(w, `{"success": false, "message": "Language is required"}`, http.StatusBadRequest)
+		return
+	}
+	if req.TaskID == nil {
+		http.Error(w, `{"success": false, "message": "Task ID is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	taskID := convertTaskIDToString(req.TaskID)
+
+	// Проверяем задачу сразу, чтобы не ставить в очередь заведомо ошибочное решение
+	task, ok := findTask(req.Language, taskID)
+	if !ok {
+		http.Error(w, `{"success": false, "message": "Task not found"}`, http.StatusNotFound)
+		return
+	}
+	if len(task.Tests) == 0 && len(req.Tests) == 0 {
+		http.Error(w, `{"success": false, "message": "No tests available for this task"}`, http.StatusBadRequest)
+		return
+	}
+
+	// Тесты из запроса сохраняем вместе с решением, тесты задачи берутся при проверке
+	var testsJSON interface{} // NULL, если тесты не переданы
+	if len(req.Tests) > 0 {
+		data, err := json.Marshal(req.Tests)
+		if err != nil {
+			http.Error(w, `{"success": false, "message": "Invalid tests"}`, http.StatusBadRequest)
+			return
+		}
+		testsJSON = data
+	}
+
+	filesJSON, err := sourceFilesJSON(req.Files)
+	if err != nil {
+		http.Error(w, `{"success": false, "message": "Invalid files"}`, http.StatusBadRequest)
+		return
+	}
+
+	var userID sql.NullInt64
+	if id, ok := optionalUserID(r); ok {
+		userID = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	submissionID, err := newSubmissionID()
+	if err != nil {
+		log.Printf("❌ Failed to generate submission id: %v", err)
+		http.Error(w, `{"success": false, "message": "Internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	query := `
+	INSERT INTO submissions (id, user_id, task_id, language, code, files, tests)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	if _, err := database.DB.Exec(query, submissionID, userID, taskID, req.Language, req.Code, filesJSON, testsJSON); err != nil {
+		log.Printf("❌ Failed to enqueue submission: %v", err)
+		http.Error(w, `{"success": false, "message": "Failed to enqueue submission"}`, http.StatusInternalServerError)
+		return
+	}
+	notifySubmissionWorkers()
+
+	log.Printf("📨 Submission %s queued: task=%s, language=%s", submissionID, taskID, req.Language)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(models.SubmitResponse{
+		Success:      true,
+		SubmissionID: submissionID,
+		Status:       models.SubmissionQueued,
+	})
+}
+
+// GetSubmissionHandler возвращает статус и результаты проверки решения.
+// Решение пользователя доступно только ему и преподавателям; подробности
+// скрытых тестов видят только преподаватели
+func GetSubmissionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, `{"success": false, "message": "Only GET method allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/submissions/"), "/")
+	if id == "" {
+		http.Error(w, `{"success": false, "message": "Submission ID is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	submission, err := getSubmission(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, `{"success": false, "message": "Submission not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load submission %s: %v", id, err)
+		http.Error(w, `{"success": false, "message": "Internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	isTeacher := false
+	claims, err := ParseTokenFromRequest(r)
+	if err == nil {
+		isTeacher = claims["role"] == "teacher"
+	}
+	if submission.UserID != nil && !isTeacher {
+		if userID, ok := optionalUserID(r); !ok || userID != *submission.UserID {
+			// Не раскрываем существование чужих решений
+			http.Error(w, `{"success": false, "message": "Submission not found"}`, http.StatusNotFound)
+			return
+		}
+	}
+
+	if !isTeacher {
+		hideResultForStudent(submission.Result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(submission); err != nil {
+		log.Printf("❌ Failed to encode submission: %v", err)
+	}
+}
+
+// getSubmission загружает решение из БД
+func getSubmission(id string) (*models.Submission, error) {
+	query := `
+	SELECT ` + submissionColumns + `
+	FROM submissions s
+	LEFT JOIN users u ON u.id = s.user_id
+	WHERE s.id = $1
+	`
+	return scanSubmission(database.DB.QueryRow(query, id))
+}
+
+// newSubmissionID генерирует случайный ID решения. ID не должен угадываться:
+// анонимные решения доступны любому, кто его знает
+func newSubmissionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// envPositiveInt читает положительное целое из переменной окружения
+func envPositiveInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultSubmissionPageSize - размер страницы списка решений по умолчанию
+	defaultSubmissionPageSize = 50
+	// maxSubmissionPageSize - максимальный размер страницы списка решений
+	maxSubmissionPageSize = 200
+)
+
+// recordSubmission сохраняет проверенную синхронно попытку в историю решений.
+// Тесты из запроса сохраняются вместе с ней: такие попытки не считаются решением задачи
+func recordSubmission(userID int64, taskID, language, code string, files []models.SourceFile, tests []models.Test, response *models.CheckResponse) {
+	submissionID, err := newSubmissionID()
+	if err != nil {
+		log.Printf("⚠️ Ошибка при генерации id решения: %v", err)
+		return
+	}
+
+	resultJSON, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("⚠️ Ошибка при сериализации результата: %v", err)
+		return
+	}
+
+	filesJSON, err := sourceFilesJSON(files)
+	if err != nil {
+		log.Printf("⚠️ Ошибка при сериализации файлов решения: %v", err)
+		return
+	}
+
+	var testsJSON interface{} // NULL - проверка на тестах задачи
+	if len(tests) > 0 {
+		data, err := json.Marshal(tests)
+		if err != nil {
+			log.Printf("⚠️ Ошибка при сериализации тестов решения: %v", err)
+			return
+		}
+		testsJSON = data
+	}
+
+	query := `
+	INSERT INTO submissions (id, user_id, task_id, language, code, files, tests, status, verdict, result,
+		passed_tests, total_tests, time_ms, cpu_time_ms, memory_kb, started_at, finished_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, 'done', $8, $9, $10, $11, $12, $13, $14, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`
+	_, err = database.DB.Exec(query, submissionID, userID, taskID, language, code, filesJSON, testsJSON, string(response.Verdict),
+		resultJSON, response.PassedTests, response.TotalTests, response.TimeElapsed, response.CPUTimeMs, response.MaxMemoryKB)
+	if err != nil {
+		log.Printf("⚠️ Ошибка при сохранении решения задачи: %v", err)
+	} else {
+		log.Printf("✅ Решение задачи сохранено: user_id=%d, task_id=%s, language=%s, verdict=%s",
+			userID, taskID, language, response.Verdict)
+	}
+}
+
+// SubmissionsHandler обслуживает /api/submissions: POST ставит решение в очередь,
+// GET возвращает историю попыток текущего пользователя по задаче
+func SubmissionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		AuthMiddleware(GetMySubmissionsHandler)(w, r)
+		return
+	}
+	SubmitHandler(w, r)
+}
+
+// GetMySubmissionsHandler возвращает историю попыток пользователя по задаче:
+// GET /api/submissions?task_id=...&language=...
+func GetMySubmissionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromRequest(r)
+	if err != nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	taskID := r.URL.Query().Get("task_id")
+	if taskID == "" {
+		http.Error(w, `{"success": false, "message": "task_id is required"}`, http.StatusBadRequest)
+		return
+	}
+	language := r.URL.Query().Get("language")
+
+	history, err := getSubmissionHistory(userID, taskID, language)
+	if err != nil {
+		log.Printf("❌ Ошибка при получении истории решений: %v", err)
+		http.Error(w, `{"error":"database_error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Студент видит только вердикты скрытых тестов
+	for i := range history.Submissions {
+		hideResultForStudent(history.Submissions[i].Result)
+	}
+	if history.Best != nil {
+		hideResultForStudent(history.Best.Result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		log.Printf("❌ Ошибка при кодировании ответа: %v", err)
+	}
+}
+
+// GetTeacherSubmissionsHandler возвращает попытки студентов для преподавателя:
+// GET /api/teacher/submissions?user_id=...&task_id=...&language=...&limit=...&offset=...
+// С user_id и task_id ответ содержит полную историю студента по задаче со статистикой
+func GetTeacherSubmissionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, `{"error":"method_not_allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	var userID int64
+	if value := query.Get("user_id"); value != "" {
+		var err error
+		if userID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			http.Error(w, `{"success": false, "message": "Invalid user_id"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	taskID := query.Get("task_id")
+	language := query.Get("language")
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if userID != 0 && taskID != "" {
+		history, err := getSubmissionHistory(userID, taskID, language)
+		if err != nil {
+			log.Printf("❌ Ошибка при получении истории решений: %v", err)
+			http.Error(w, `{"error":"database_error"}`, http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(history)
+		return
+	}
+
+	limit := defaultSubmissionPageSize
+	if value, err := strconv.Atoi(query.Get("limit")); err == nil && value > 0 {
+		limit = value
+	}
+	if limit > maxSubmissionPageSize {
+		limit = maxSubmissionPageSize
+	}
+	offset := 0
+	if value, err := strconv.Atoi(query.Get("offset")); err == nil && value > 0 {
+		offset = value
+	}
+
+	list, err := listSubmissions(userID, taskID, language, limit, offset)
+	if err != nil {
+		log.Printf("❌ Ошибка при получении списка решений: %v", err)
+		http.Error(w, `{"error":"database_error"}`, http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// submissionColumns - колонки решения для scanSubmission
+const submissionColumns = `s.id, s.user_id, COALESCE(u.username, ''), s.task_id, s.language, s.code, s.files,
+	s.status, s.verdict, s.passed_tests, s.total_tests, s.time_ms, s.cpu_time_ms, s.memory_kb, s.result, s.error,
+	s.created_at, s.started_at, s.finished_at`
+
+// rowScanner - общий интерфейс sql.Row и sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSubmission читает решение, выбранное с колонками submissionColumns
+func scanSubmission(row rowScanner) (*models.Submission, error) {
+	var submission models.Submission
+	var userID sql.NullInt64
+	var verdict, errorText sql.NullString
+	var resultJSON, filesJSON []byte
+	var startedAt, finishedAt sql.NullTime
+
+	err := row.Scan(
+		&submission.ID,
+		&userID,
+		&submission.Username,
+		&submission.TaskID,
+		&submission.Language,
+		&submission.Code,
+		&filesJSON,
+		&submission.Status,
+		&verdict,
+		&submission.PassedTests,
+		&submission.TotalTests,
+		&submission.TimeMs,
+		&submission.CPUTimeMs,
+		&submission.MemoryKB,
+		&resultJSON,
+		&errorText,
+		&submission.CreatedAt,
+		&startedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID.Valid {
+		submission.UserID = &userID.Int64
+	}
+	submission.Verdict = models.Verdict(verdict.String)
+	submission.Error = errorText.String
+	if startedAt.Valid {
+		submission.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		submission.FinishedAt = &finishedAt.Time
+	}
+	if len(filesJSON) > 0 {
+		if err := json.Unmarshal(filesJSON, &submission.Files); err != nil {
+			log.Printf("⚠️ Failed to parse submission %s files: %v", submission.ID, err)
+		}
+	}
+	if len(resultJSON) > 0 {
+		var result models.CheckResponse
+		if err := json.Unmarshal(resultJSON, &result); err != nil {
+			log.Printf("⚠️ Failed to parse submission %s result: %v", submission.ID, err)
+		} else {
+			submission.Result = &result
+		}
+	}
+
+	return &submission, nil
+}
+
+// getSubmissionHistory собирает все попытки пользователя по задаче, лучшее
+// решение и статистику. Пустой language - все языки
+func getSubmissionHistory(userID int64, taskID, language string) (*models.SubmissionHistory, error) {
+	query := `
+	SELECT ` + submissionColumns + `
+	FROM submissions s
+	LEFT JOIN users u ON u.id = s.user_id
+	WHERE s.user_id = $1 AND s.task_id = $2 AND ($3 = '' OR s.language = $3)
+	ORDER BY s.created_at DESC
+	`
+	rows, err := database.DB.Query(query, userID, taskID, language)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := &models.SubmissionHistory{
+		TaskID:      taskID,
+		Language:    language,
+		Submissions: []models.Submission{},
+	}
+	stats := &history.Stats
+	stats.TaskID = taskID
+
+	var totalTime int64
+	for rows.Next() {
+		submission, err := scanSubmission(rows)
+		if err != nil {
+			return nil, err
+		}
+		history.Submissions = append(history.Submissions, *submission)
+
+		if submission.Status != models.SubmissionDone {
+			continue
+		}
+		stats.TotalAttempts++
+		totalTime += submission.TimeMs
+		if submission.Verdict == models.VerdictAccepted {
+			stats.SuccessAttempts++
+			// Попытки отсортированы от новых к старым
+			createdAt := submission.CreatedAt
+			stats.FirstSolved = &createdAt
+		}
+		if stats.LastAttempt == nil {
+			createdAt := submission.CreatedAt
+			stats.LastAttempt = &createdAt
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if stats.TotalAttempts > 0 {
+		stats.SuccessRate = float64(stats.SuccessAttempts) / float64(stats.TotalAttempts) * 100
+		stats.AvgTime = float64(totalTime) / float64(stats.TotalAttempts) / 1000
+	}
+
+	best, err := getBestSolution(userID, taskID, language)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	history.Best = best
+
+	return history, nil
+}
+
+// getBestSolution возвращает лучшее решение пользователя из представления best_solutions.
+// Если language пустой, выбирается лучшее среди всех языков
+func getBestSolution(userID int64, taskID, language string) (*models.Submission, error) {
+	query := `
+	SELECT ` + submissionColumns + `
+	FROM best_solutions b
+	JOIN submissions s ON s.id = b.submission_id
+	LEFT JOIN users u ON u.id = s.user_id
+	WHERE b.user_id = $1 AND b.task_id = $2 AND ($3 = '' OR b.language = $3)
+	ORDER BY b.success DESC, b.passed_tests DESC, b.created_at ASC
+	LIMIT 1
+	`
+	return scanSubmission(database.DB.QueryRow(query, userID, taskID, language))
+}
+
+// listSubmissions возвращает страницу попыток с фильтрами (нулевые значения - без фильтра).
+// Результаты тестов в список не входят, их можно получить по id решения
+func listSubmissions(userID int64, taskID, language string, limit, offset int) (*models.SubmissionList, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, strings.Replace(condition, "?", "$"+strconv.Itoa(len(args)), 1))
+	}
+
+	// Анонимные решения не относятся ни к одному студенту
+	conditions = append(conditions, "s.user_id IS NOT NULL")
+	if userID != 0 {
+		addCondition("s.user_id = ?", userID)
+	}
+	if taskID != "" {
+		addCondition("s.task_id = ?", taskID)
+	}
+	if language != "" {
+		addCondition("s.language = ?", language)
+	}
+	where := strings.Join(conditions, " AND ")
+
+	list := &models.SubmissionList{
+		Submissions: []models.Submission{},
+		Limit:       limit,
+		Offset:      offset,
+	}
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM submissions s WHERE "+where, args...).Scan(&list.Total); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT ` + submissionColumns + `
+	FROM submissions s
+	LEFT JOIN users u ON u.id = s.user_id
+	WHERE ` + where + `
+	ORDER BY s.created_at DESC
+	LIMIT $` + strconv.Itoa(len(args)+1) + ` OFFSET $` + strconv.Itoa(len(args)+2)
+	rows, err := database.DB.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		submission, err := scanSubmission(rows)
+		if err != nil {
+			return nil, err
+		}
+		submission.Result = nil
+		list.Submissions = append(list.Submissions, *submission)
+	}
+	return list, rows.Err()
+}
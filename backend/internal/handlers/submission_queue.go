@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"backend/internal/database"
+	"backend/internal/executor"
+	"backend/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// submissionPollInterval - как часто свободный воркер проверяет очередь,
+	// если его не разбудили (решение могло прийти на другой экземпляр сервера)
+	submissionPollInterval = 2 * time.Second
+	// submissionMaintenanceInterval - период обслуживания очереди
+	submissionMaintenanceInterval = time.Minute
+	// submissionHeartbeatInterval - как часто воркер отмечает, что еще проверяет
+	// решение. Должен быть намного меньше SUBMISSION_STALE_MINUTES
+	submissionHeartbeatInterval = 30 * time.Second
+	// maxSubmissionAttempts - сколько раз решение может быть взято в работу без
+	// результата. Защищает от решений, на которых падает сам сервер; попытки,
+	// отложенные из-за занятого исполнителя, не считаются
+	maxSubmissionAttempts = 3
+)
+
+// submissionWakeup будит свободного воркера при постановке решения в очередь
+var submissionWakeup = make(chan struct{}, 1)
+
+// notifySubmissionWorkers сообщает воркерам о новом решении в очереди
+func notifySubmissionWorkers() {
+	select {
+	case submissionWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// queuedSubmission - решение, взятое воркером в работу
+type queuedSubmission struct {
+	ID       string
+	UserID   sql.NullInt64
+	TaskID   string
+	Language string
+	Code     string
+	Files    []byte
+	Tests    []byte
+}
+
+// StartSubmissionWorkers запускает пул воркеров очереди проверки.
+// Количество воркеров задается SUBMISSION_WORKERS (по умолчанию 2), срок
+// хранения анонимных решений - SUBMISSION_RETENTION_HOURS (по умолчанию 168),
+// время без отметки воркера (heartbeat_at), после которого проверка считается
+// зависшей и возвращается в очередь, - SUBMISSION_STALE_MINUTES (по умолчанию 15).
+// Возвращает функцию, ожидающую завершения воркеров после отмены ctx
+func StartSubmissionWorkers(ctx context.Context) (wait func()) {
+	workers := envPositiveInt("SUBMISSION_WORKERS", 2)
+	retention := time.Duration(envPositiveInt("SUBMISSION_RETENTION_HOURS", 168)) * time.Hour
+	stale := time.Duration(envPositiveInt("SUBMISSION_STALE_MINUTES", 15)) * time.Minute
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			runSubmissionWorker(ctx, worker)
+		}(i + 1)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runSubmissionMaintenance(ctx, retention, stale)
+	}()
+
+	log.Printf("✅ Submission queue started: workers=%d, retention=%v", workers, retention)
+	return wg.Wait
+}
+
+// runSubmissionWorker берет решения из очереди и проверяет их, пока не отменен ctx
+func runSubmissionWorker(ctx context.Context, worker int) {
+	for ctx.Err() == nil {
+		submission, err := claimSubmission()
+		if err != nil {
+			log.Printf("❌ Worker %d failed to claim submission: %v", worker, err)
+		}
+		if submission == nil {
+			select {
+			case <-ctx.Done():
+			case <-submissionWakeup:
+			case <-time.After(submissionPollInterval):
+			}
+			continue
+		}
+
+		log.Printf("⚙️ Worker %d judging submission %s (task %s, %s)", worker, submission.ID, submission.TaskID, submission.Language)
+		stopHeartbeat := startSubmissionHeartbeat(submission.ID)
+		processSubmission(ctx, submission)
+		stopHeartbeat()
+	}
+}
+
+// claimSubmission атомарно берет самое старое решение из очереди.
+// FOR UPDATE SKIP LOCKED позволяет нескольким воркерам и экземплярам сервера
+// разбирать очередь, не блокируя друг друга
+func claimSubmission() (*queuedSubmission, error) {
+	query := `
+	UPDATE submissions
+	SET status = 'running', started_at = CURRENT_TIMESTAMP, heartbeat_at = CURRENT_TIMESTAMP, attempts = attempts + 1
+	WHERE id = (
+		SELECT id FROM submissions
+		WHERE status = 'queued'
+		ORDER BY created_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, user_id, task_id, language, code, files, tests
+	`
+
+	var submission queuedSubmission
+	err := database.DB.QueryRow(query).Scan(
+		&submission.ID,
+		&submission.UserID,
+		&submission.TaskID,
+		&submission.Language,
+		&submission.Code,
+		&submission.Files,
+		&submission.Tests,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+// startSubmissionHeartbeat периодически отмечает в heartbeat_at, что решение
+// все еще проверяется, чтобы обслуживание очереди не вернуло долгую проверку
+// в очередь. Возвращает функцию остановки
+func startSubmissionHeartbeat(id string) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(submissionHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			query := `UPDATE submissions SET heartbeat_at = CURRENT_TIMESTAMP WHERE id = $1 AND status = 'running'`
+			if _, err := database.DB.Exec(query, id); err != nil {
+				log.Printf("⚠️ Failed to update submission %s heartbeat: %v", id, err)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// processSubmission проверяет решение и сохраняет результат
+func processSubmission(ctx context.Context, submission *queuedSubmission) {
+	// Тесты из запроса или из задачи на момент проверки, чекер - всегда из задачи
+	task, ok := findTask(submission.Language, submission.TaskID)
+	tests := task.Tests
+	if len(submission.Tests) > 0 {
+		if err := json.Unmarshal(submission.Tests, &tests); err != nil {
+			failSubmission(submission.ID, "Invalid tests: "+err.Error())
+			return
+		}
+	} else if !ok {
+		failSubmission(submission.ID, "Task not found")
+		return
+	}
+	if len(tests) == 0 {
+		failSubmission(submission.ID, "No tests available for this task")
+		return
+	}
+
+	var files []models.SourceFile
+	if len(submission.Files) > 0 {
+		if err := json.Unmarshal(submission.Files, &files); err != nil {
+			failSubmission(submission.ID, "Invalid files: "+err.Error())
+			return
+		}
+	}
+
+	response, err := judgeSolution(ctx, submission.Code, submission.Language, files, tests, task)
+	if err != nil && executor.IsBusy(err) {
+		// Исполнитель перегружен запусками из редактора - решение подождет в очереди
+		log.Printf("🚦 Submission %s postponed: %v", submission.ID, err)
+		requeueSubmission(submission.ID)
+		select {
+		case <-ctx.Done():
+		case <-time.After(submissionPollInterval):
+		}
+		return
+	}
+	if err != nil {
+		// Сервер останавливается - возвращаем решение в очередь
+		requeueSubmission(submission.ID)
+		return
+	}
+
+	if len(submission.Tests) == 0 {
+		response.TestSetVersion = task.TestSetVersion
+	}
+
+	resultJSON, err := json.Marshal(response)
+	if err != nil {
+		failSubmission(submission.ID, "Failed to encode result: "+err.Error())
+		return
+	}
+
+	query := `
+	UPDATE submissions
+	SET status = 'done', verdict = $2, result = $3, passed_tests = $4, total_tests = $5,
+		time_ms = $6, cpu_time_ms = $7, memory_kb = $8, finished_at = CURRENT_TIMESTAMP
+	WHERE id = $1
+	`
+	_, err = database.DB.Exec(query, submission.ID, string(response.Verdict), resultJSON,
+		response.PassedTests, response.TotalTests, response.TimeElapsed, response.CPUTimeMs, response.MaxMemoryKB)
+	if err != nil {
+		log.Printf("❌ Failed to save submission %s result: %v", submission.ID, err)
+		return
+	}
+	log.Printf("✅ Submission %s judged: %s", submission.ID, response.Verdict)
+}
+
+// failSubmission помечает решение как непроверенное
+func failSubmission(id, reason string) {
+	query := `
+	UPDATE submissions
+	SET status = 'failed', error = $2, finished_at = CURRENT_TIMESTAMP
+	WHERE id = $1
+	`
+	if _, err := database.DB.Exec(query, id, reason); err != nil {
+		log.Printf("❌ Failed to mark submission %s as failed: %v", id, err)
+		return
+	}
+	log.Printf("⚠️ Submission %s failed: %s", id, reason)
+}
+
+// requeueSubmission возвращает в очередь решение, которое не удалось проверить
+// не по его вине (исполнитель занят, сервер останавливается). Такая попытка не
+// засчитывается: attempts считает только проверки, прерванные падением сервера
+func requeueSubmission(id string) {
+	query := `UPDATE submissions SET status = 'queued', started_at = NULL, heartbeat_at = NULL, attempts = attempts - 1 WHERE id = $1`
+	if _, err := database.DB.Exec(query, id); err != nil {
+		log.Printf("❌ Failed to requeue submission %s: %v", id, err)
+		return
+	}
+	log.Printf("🔄 Submission %s returned to queue", id)
+}
+
+// runSubmissionMaintenance периодически возвращает в очередь зависшие проверки
+// (экземпляр сервера упал посреди проверки, и heartbeat_at давно не обновлялся)
+// и удаляет старые анонимные результаты
+func runSubmissionMaintenance(ctx context.Context, retention, stale time.Duration) {
+	ticker := time.NewTicker(submissionMaintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		maintainSubmissions(retention, stale)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// maintainSubmissions выполняет один проход обслуживания очереди
+func maintainSubmissions(retention, stale time.Duration) {
+	staleMinutes := int(stale / time.Minute)
+
+	// Проверка зависла, если воркер давно не обновлял heartbeat_at. У решений,
+	// взятых в работу до появления heartbeat_at, есть только started_at.
+	// Решения, на которых сервер падал несколько раз, больше не берем
+	result, err := database.DB.Exec(`
+	UPDATE submissions
+	SET status = 'failed', error = 'Judging was interrupted too many times', finished_at = CURRENT_TIMESTAMP
+	WHERE status = 'running' AND COALESCE(heartbeat_at, started_at) < CURRENT_TIMESTAMP - make_interval(mins => $1) AND attempts >= $2
+	`, staleMinutes, maxSubmissionAttempts)
+	if err != nil {
+		log.Printf("⚠️ Failed to fail stale submissions: %v", err)
+	} else if n, _ := result.RowsAffected(); n > 0 {
+		log.Printf("⚠️ %d stale submissions marked as failed", n)
+	}
+
+	result, err = database.DB.Exec(`
+	UPDATE submissions
+	SET status = 'queued', started_at = NULL, heartbeat_at = NULL
+	WHERE status = 'running' AND COALESCE(heartbeat_at, started_at) < CURRENT_TIMESTAMP - make_interval(mins => $1)
+	`, staleMinutes)
+	if err != nil {
+		log.Printf("⚠️ Failed to requeue stale submissions: %v", err)
+	} else if n, _ := result.RowsAffected(); n > 0 {
+		log.Printf("🔄 %d stale submissions returned to queue", n)
+		notifySubmissionWorkers()
+	}
+
+	// Попытки пользователей - это история решений, удаляем только анонимные
+	result, err = database.DB.Exec(`
+	DELETE FROM submissions
+	WHERE user_id IS NULL AND status IN ('done', 'failed')
+		AND finished_at < CURRENT_TIMESTAMP - make_interval(hours => $1)
+	`, int(retention/time.Hour))
+	if err != nil {
+		log.Printf("⚠️ Failed to delete old submissions: %v", err)
+	} else if n, _ := result.RowsAffected(); n > 0 {
+		log.Printf("🧹 %d old submissions deleted", n)
+	}
+}
@@ -1,12 +1,20 @@
 package handlers
 
 import (
+	"backend/internal/checker"
+	"backend/internal/executor"
+	"backend/internal/harness"
+	"backend/internal/languages"
 	"backend/internal/models"
+	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -53,13 +61,14 @@ func (h *TaskHandler) getTaskByLanguageAndID(w http.ResponseWriter, language, ta
 	query := `
 		SELECT id::text, title, description, language,
             COALESCE(template, starter_code) as template,
-            starter_code, tests, created_at, updated_at
+            starter_code, tests, function_signature, created_at, updated_at,
+            COALESCE(entry_point, ''), support_files, starter_files
     	FROM tasks
     	WHERE language = $1 AND id::text = $2 AND is_published = true
 	`
 
 	var task models.Task
-	var testsJSON []byte
+	var testsJSON, functionJSON, supportJSON, starterJSON []byte
 	var createdAt, updatedAt time.Time
 	var starterCode, template sql.NullString
 
@@ -71,8 +80,12 @@ func (h *TaskHandler) getTaskByLanguageAndID(w http.ResponseWriter, language, ta
 		&template,
 		&starterCode,
 		&testsJSON,
+		&functionJSON,
 		&createdAt,
 		&updatedAt,
+		&task.EntryPoint,
+		&supportJSON,
+		&starterJSON,
 	)
 
 	if err != nil {
@@ -94,6 +107,17 @@ func (h *TaskHandler) getTaskByLanguageAndID(w http.ResponseWriter, language, ta
 		http.Error(w, "Error parsing tests", http.StatusInternalServerError)
 		return
 	}
+	// Скрытые тесты студентам не показываем
+	task.Tests = models.PublicTests(task.Tests)
+
+	// Сигнатура функции нужна студенту для решения
+	if len(functionJSON) > 0 {
+		if err := json.Unmarshal(functionJSON, &task.Function); err != nil {
+			log.Printf("⚠️ Ошибка парсинга сигнатуры функции: %v", err)
+		}
+	}
+	parseTaskFiles(&task, supportJSON, starterJSON)
+	task.SupportFiles = models.PublicFiles(task.SupportFiles)
 
 	// Добавляем метаданные
 	task.CreatedAt = createdAt
@@ -186,7 +210,8 @@ func (h *TaskHandler) getTasksByLanguage(w http.ResponseWriter, language string)
         SELECT id::text, title, description, language, 
                COALESCE(template, starter_code) as template,
                starter_code, tests, created_at, updated_at,
-               is_published, created_by
+               is_published, created_by, function_signature,
+               COALESCE(entry_point, ''), support_files, starter_files
         FROM tasks 
         WHERE language = $1 AND is_published = true
         ORDER BY created_at DESC
@@ -201,7 +226,7 @@ func (h *TaskHandler) getTasksByLanguage(w http.ResponseWriter, language string)
 
 		for rows.Next() {
 			var task models.Task
-			var testsJSON []byte
+			var testsJSON, functionJSON, supportJSON, starterJSON []byte
 			var createdAt, updatedAt time.Time
 			var starterCode, template string
 			var isPublished bool
@@ -219,6 +244,10 @@ func (h *TaskHandler) getTasksByLanguage(w http.ResponseWriter, language string)
 				&updatedAt,
 				&isPublished,
 				&createdBy,
+				&functionJSON,
+				&task.EntryPoint,
+				&supportJSON,
+				&starterJSON,
 			)
 
 			if err != nil {
@@ -236,6 +265,15 @@ func (h *TaskHandler) getTasksByLanguage(w http.ResponseWriter, language string)
 					task.Tests = []models.Test{}
 				}
 			}
+			task.Tests = models.PublicTests(task.Tests)
+
+			if len(functionJSON) > 0 {
+				if err := json.Unmarshal(functionJSON, &task.Function); err != nil {
+					log.Printf("⚠️ Ошибка парсинга сигнатуры функции: %v", err)
+				}
+			}
+			parseTaskFiles(&task, supportJSON, starterJSON)
+			task.SupportFiles = models.PublicFiles(task.SupportFiles)
 
 			task.CreatedAt = createdAt
 			task.UpdatedAt = updatedAt
@@ -341,7 +379,8 @@ func (h *TaskHandler) getAllTasks(w http.ResponseWriter) {
 	// Дополняем задачами из БД
 	query := `
 		SELECT id::text, title, description, language, template, 
-		       starter_code, tests, created_at, updated_at
+		       starter_code, tests, created_at, updated_at, function_signature,
+		       COALESCE(entry_point, ''), support_files, starter_files
 		FROM tasks 
 		WHERE is_published = true
 		ORDER BY language, created_at DESC
@@ -358,7 +397,7 @@ func (h *TaskHandler) getAllTasks(w http.ResponseWriter) {
 
 	for rows.Next() {
 		var task models.Task
-		var testsJSON []byte
+		var testsJSON, functionJSON, supportJSON, starterJSON []byte
 		var createdAt, updatedAt time.Time
 		var starterCode, template sql.NullString
 
@@ -372,6 +411,10 @@ func (h *TaskHandler) getAllTasks(w http.ResponseWriter) {
 			&testsJSON,
 			&createdAt,
 			&updatedAt,
+			&functionJSON,
+			&task.EntryPoint,
+			&supportJSON,
+			&starterJSON,
 		)
 
 		if err != nil {
@@ -385,9 +428,17 @@ func (h *TaskHandler) getAllTasks(w http.ResponseWriter) {
 		if starterCode.Valid {
 			task.StarterCode = starterCode.String
 		}
+		if len(functionJSON) > 0 {
+			if err := json.Unmarshal(functionJSON, &task.Function); err != nil {
+				log.Printf("⚠️ Ошибка парсинга сигнатуры функции: %v", err)
+			}
+		}
+		parseTaskFiles(&task, supportJSON, starterJSON)
+		task.SupportFiles = models.PublicFiles(task.SupportFiles)
 
 		// Парсим тесты
 		if err := json.Unmarshal(testsJSON, &task.Tests); err == nil {
+			task.Tests = models.PublicTests(task.Tests)
 			task.CreatedAt = createdAt
 			task.UpdatedAt = updatedAt
 			allTasks = append(allTasks, task)
@@ -419,58 +470,36 @@ func (h *TaskHandler) CreateTaskHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Валидация
-	if taskReq.Title == "" || taskReq.Description == "" || taskReq.Language == "" {
-		http.Error(w, "Missing required fields", http.StatusBadRequest)
+	if err := validateTaskRequest(&taskReq); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if len(taskReq.Tests) == 0 {
-		http.Error(w, "At least one test is required", http.StatusBadRequest)
-		return
-	}
-
-	// Конвертируем тесты в JSON
-	testsJSON, err := json.Marshal(taskReq.Tests)
+	// Задача с противоречивыми тестами или тестами, которые не проходит
+	// эталонное решение, сохраняется неопубликованной
+	validation, err := validateTaskForPublish(w, r, taskFromRequest(taskReq))
 	if err != nil {
-		http.Error(w, "Error processing tests", http.StatusInternalServerError)
+		writeTaskValidationError(w, err)
 		return
 	}
 
 	// Вставляем в БД
-	query := `
-		INSERT INTO tasks (
-			title, description, language, difficulty, template, starter_code,
-			tests, created_by, created_at, updated_at, is_published
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		RETURNING id
-	`
-
-	now := time.Now()
-	var taskID int
-	err = h.DB.QueryRow(
-		query,
-		taskReq.Title,
-		taskReq.Description,
-		taskReq.Language,
-		taskReq.Difficulty,
-		taskReq.Template,
-		taskReq.StarterCode,
-		testsJSON,
-		userID,
-		now,
-		now,
-		true, // is_published
-	).Scan(&taskID)
-
+	taskID, err := h.insertTask(taskReq, userID, validation.Valid, validation)
 	if err != nil {
 		http.Error(w, "Error creating task: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Возвращаем созданную задачу
+	message := "Task created successfully"
+	if !validation.Valid {
+		message = "Task created but not published: tests failed validation"
+	}
 	response := map[string]interface{}{
-		"id":      strconv.Itoa(taskID),
-		"message": "Task created successfully",
+		"id":           strconv.Itoa(taskID),
+		"message":      message,
+		"is_published": validation.Valid,
+		"validation":   validation,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -498,7 +527,9 @@ func (h *TaskHandler) GetTeacherTasksHandler(w http.ResponseWriter, r *http.Requ
         SELECT id::text, title, description, language, 
                COALESCE(template, starter_code) as template,
                starter_code, tests, created_at, updated_at, 
-               is_published
+               is_published, checker, function_signature,
+               COALESCE(entry_point, ''), support_files, starter_files,
+               reference_solution, validation
         FROM tasks 
         WHERE created_by = $1
         ORDER BY created_at DESC
@@ -515,7 +546,8 @@ func (h *TaskHandler) GetTeacherTasksHandler(w http.ResponseWriter, r *http.Requ
 	var teacherTasks []models.Task
 	for rows.Next() {
 		var task models.Task
-		var testsJSON []byte
+		var testsJSON, checkerJSON, functionJSON, supportJSON, starterJSON []byte
+		var referenceJSON, validationJSON []byte
 		var createdAt, updatedAt time.Time
 		var starterCode, template string
 		var isPublished bool
@@ -531,6 +563,13 @@ func (h *TaskHandler) GetTeacherTasksHandler(w http.ResponseWriter, r *http.Requ
 			&createdAt,
 			&updatedAt,
 			&isPublished,
+			&checkerJSON,
+			&functionJSON,
+			&task.EntryPoint,
+			&supportJSON,
+			&starterJSON,
+			&referenceJSON,
+			&validationJSON,
 		)
 
 		if err != nil {
@@ -549,6 +588,29 @@ func (h *TaskHandler) GetTeacherTasksHandler(w http.ResponseWriter, r *http.Requ
 			}
 		}
 
+		if len(checkerJSON) > 0 {
+			if err := json.Unmarshal(checkerJSON, &task.Checker); err != nil {
+				log.Printf("⚠️ Ошибка парсинга чекера задачи: %v", err)
+			}
+		}
+		if len(functionJSON) > 0 {
+			if err := json.Unmarshal(functionJSON, &task.Function); err != nil {
+				log.Printf("⚠️ Ошибка парсинга сигнатуры функции: %v", err)
+			}
+		}
+		// Преподаватель видит и скрытые файлы поддержки
+		parseTaskFiles(&task, supportJSON, starterJSON)
+		if len(referenceJSON) > 0 {
+			if err := json.Unmarshal(referenceJSON, &task.ReferenceSolution); err != nil {
+				log.Printf("⚠️ Ошибка парсинга эталонного решения: %v", err)
+			}
+		}
+		if len(validationJSON) > 0 {
+			if err := json.Unmarshal(validationJSON, &task.Validation); err != nil {
+				log.Printf("⚠️ Ошибка парсинга проверки задачи: %v", err)
+			}
+		}
+
 		task.CreatedAt = createdAt
 		task.UpdatedAt = updatedAt
 		teacherTasks = append(teacherTasks, task)
@@ -562,6 +624,280 @@ func (h *TaskHandler) GetTeacherTasksHandler(w http.ResponseWriter, r *http.Requ
 	}
 }
 
+// taskCheckerJSON сериализует настройки чекера для колонки checker (NULL - чекер по умолчанию)
+func taskCheckerJSON(config *models.Checker) (interface{}, error) {
+	if config == nil {
+		return nil, nil
+	}
+	return json.Marshal(config)
+}
+
+// taskFunctionJSON сериализует сигнатуру функции для колонки function_signature
+// (NULL - задача на программу целиком)
+func taskFunctionJSON(function *models.Function) (interface{}, error) {
+	if function == nil {
+		return nil, nil
+	}
+	return json.Marshal(function)
+}
+
+// validateTaskFunction проверяет сигнатуру функции и соответствие ей тестов
+func validateTaskFunction(function *models.Function, language string, tests []models.Test) error {
+	if function == nil {
+		return nil
+	}
+	if err := harness.Validate(function, language); err != nil {
+		return err
+	}
+	for i, test := range tests {
+		if err := harness.ValidateTest(function, test); err != nil {
+			return fmt.Errorf("test %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// sourceFilesJSON сериализует файлы для колонок support_files, starter_files
+// задач и files решений (NULL - файлов нет)
+func sourceFilesJSON(files []models.SourceFile) (interface{}, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(files)
+}
+
+// taskReferenceJSON сериализует эталонное решение для колонки reference_solution
+// (NULL - решения нет)
+func taskReferenceJSON(reference *models.ReferenceSolution) (interface{}, error) {
+	if reference == nil {
+		return nil, nil
+	}
+	return json.Marshal(reference)
+}
+
+// parseTaskFiles заполняет файлы задачи из колонок support_files и starter_files
+func parseTaskFiles(task *models.Task, supportJSON, starterJSON []byte) {
+	if len(supportJSON) > 0 {
+		if err := json.Unmarshal(supportJSON, &task.SupportFiles); err != nil {
+			log.Printf("⚠️ Ошибка парсинга файлов поддержки: %v", err)
+		}
+	}
+	if len(starterJSON) > 0 {
+		if err := json.Unmarshal(starterJSON, &task.StarterFiles); err != nil {
+			log.Printf("⚠️ Ошибка парсинга начальных файлов: %v", err)
+		}
+	}
+}
+
+// validateTaskFiles проверяет точку входа и имена файлов многофайловой задачи.
+// Начальные файлы студента не могут совпадать с файлами поддержки
+func validateTaskFiles(entryPoint string, supportFiles, starterFiles []models.SourceFile) error {
+	if entryPoint != "" {
+		if err := executor.ValidateFileName(entryPoint); err != nil {
+			return fmt.Errorf("entry point: %w", err)
+		}
+		if strings.Contains(entryPoint, "/") {
+			return fmt.Errorf("entry point %s must be in the root directory", entryPoint)
+		}
+	}
+
+	support := make(map[string]bool)
+	for _, file := range supportFiles {
+		if err := executor.ValidateFileName(file.Name); err != nil {
+			return fmt.Errorf("support file: %w", err)
+		}
+		if support[file.Name] {
+			return fmt.Errorf("duplicate support file %s", file.Name)
+		}
+		support[file.Name] = true
+	}
+
+	starter := make(map[string]bool)
+	for _, file := range starterFiles {
+		if err := executor.ValidateFileName(file.Name); err != nil {
+			return fmt.Errorf("starter file: %w", err)
+		}
+		if starter[file.Name] {
+			return fmt.Errorf("duplicate starter file %s", file.Name)
+		}
+		if support[file.Name] {
+			return fmt.Errorf("starter file %s conflicts with a support file", file.Name)
+		}
+		starter[file.Name] = true
+	}
+	return nil
+}
+
+// validateTaskRequest проверяет задачу из запроса преподавателя и приводит
+// язык к id реестра. Пустое эталонное решение заменяется на nil.
+// Ошибка - сообщение для ответа 400
+func validateTaskRequest(taskReq *models.TaskRequest) error {
+	if taskReq.Title == "" || taskReq.Description == "" || taskReq.Language == "" {
+		return fmt.Errorf("Missing required fields")
+	}
+
+	lang, ok := languages.Lookup(taskReq.Language)
+	if !ok {
+		return fmt.Errorf("Unsupported language: %s", taskReq.Language)
+	}
+	taskReq.Language = lang.ID
+
+	if len(taskReq.Tests) == 0 {
+		return fmt.Errorf("At least one test is required")
+	}
+
+	if err := checker.Validate(taskReq.Checker); err != nil {
+		return fmt.Errorf("Invalid checker: %w", err)
+	}
+
+	if err := validateTaskFunction(taskReq.Function, taskReq.Language, taskReq.Tests); err != nil {
+		return fmt.Errorf("Invalid function: %w", err)
+	}
+
+	if err := validateTaskFiles(taskReq.EntryPoint, taskReq.SupportFiles, taskReq.StarterFiles); err != nil {
+		return fmt.Errorf("Invalid files: %w", err)
+	}
+
+	// Пустое эталонное решение - решения нет
+	if ref := taskReq.ReferenceSolution; ref != nil && ref.Code == "" && len(ref.Files) == 0 {
+		taskReq.ReferenceSolution = nil
+	}
+	return nil
+}
+
+// taskRecord - JSON-колонки задачи для INSERT и UPDATE
+type taskRecord struct {
+	tests, checker, function, support, starter, reference, validation interface{}
+}
+
+// newTaskRecord сериализует поля задачи в JSON-колонки
+func newTaskRecord(taskReq models.TaskRequest, validation *models.TaskValidation) (taskRecord, error) {
+	var record taskRecord
+	var err error
+
+	if record.tests, err = json.Marshal(taskReq.Tests); err != nil {
+		return record, fmt.Errorf("processing tests: %w", err)
+	}
+	if record.checker, err = taskCheckerJSON(taskReq.Checker); err != nil {
+		return record, fmt.Errorf("processing checker: %w", err)
+	}
+	if record.function, err = taskFunctionJSON(taskReq.Function); err != nil {
+		return record, fmt.Errorf("processing function: %w", err)
+	}
+	if record.support, err = sourceFilesJSON(taskReq.SupportFiles); err != nil {
+		return record, fmt.Errorf("processing support files: %w", err)
+	}
+	if record.starter, err = sourceFilesJSON(taskReq.StarterFiles); err != nil {
+		return record, fmt.Errorf("processing starter files: %w", err)
+	}
+	if record.reference, err = taskReferenceJSON(taskReq.ReferenceSolution); err != nil {
+		return record, fmt.Errorf("processing reference solution: %w", err)
+	}
+	if record.validation, err = json.Marshal(validation); err != nil {
+		return record, fmt.Errorf("processing validation: %w", err)
+	}
+	return record, nil
+}
+
+// insertTask сохраняет новую задачу преподавателя и возвращает её id
+func (h *TaskHandler) insertTask(taskReq models.TaskRequest, userID int, published bool, validation *models.TaskValidation) (int, error) {
+	record, err := newTaskRecord(taskReq, validation)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO tasks (
+			title, description, language, difficulty, template, starter_code,
+			tests, created_by, created_at, updated_at, is_published, checker,
+			function_signature, entry_point, support_files, starter_files,
+			reference_solution, validation
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		RETURNING id
+	`
+
+	now := time.Now()
+	var taskID int
+	err = h.DB.QueryRow(
+		query,
+		taskReq.Title,
+		taskReq.Description,
+		taskReq.Language,
+		taskReq.Difficulty,
+		taskReq.Template,
+		taskReq.StarterCode,
+		record.tests,
+		userID,
+		now,
+		now,
+		published,
+		record.checker,
+		record.function,
+		taskReq.EntryPoint,
+		record.support,
+		record.starter,
+		record.reference,
+		record.validation,
+	).Scan(&taskID)
+	return taskID, err
+}
+
+// updateTask обновляет задачу преподавателя. sql.ErrNoRows - задачи нет или
+// она принадлежит другому преподавателю
+func (h *TaskHandler) updateTask(taskID string, taskReq models.TaskRequest, userID int, published bool, validation *models.TaskValidation) (int, error) {
+	record, err := newTaskRecord(taskReq, validation)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		UPDATE tasks 
+		SET 
+			title = $1,
+			description = $2,
+			language = $3,
+			difficulty = $4,
+			template = $5,
+			starter_code = $6,
+			tests = $7,
+			updated_at = $8,
+			is_published = $9,
+			checker = $12,
+			function_signature = $13,
+			entry_point = $14,
+			support_files = $15,
+			starter_files = $16,
+			reference_solution = $17,
+			validation = $18
+		WHERE id::text = $10 AND created_by = $11
+		RETURNING id
+	`
+
+	var updatedID int
+	err = h.DB.QueryRow(
+		query,
+		taskReq.Title,
+		taskReq.Description,
+		taskReq.Language,
+		taskReq.Difficulty,
+		taskReq.Template,
+		taskReq.StarterCode,
+		record.tests,
+		time.Now(),
+		published,
+		taskID,
+		userID,
+		record.checker,
+		record.function,
+		taskReq.EntryPoint,
+		record.support,
+		record.starter,
+		record.reference,
+		record.validation,
+	).Scan(&updatedID)
+	return updatedID, err
+}
+
 // getUserFromRequest извлекает данные пользователя из запроса
 func (h *TaskHandler) getUserFromRequest(r *http.Request) (int, string, error) {
 	// Здесь должна быть проверка JWT токена
@@ -597,22 +933,18 @@ func (h *TaskHandler) UpdateTaskHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Валидация
-	if taskReq.Title == "" || taskReq.Description == "" || taskReq.Language == "" {
-		http.Error(w, "Missing required fields", http.StatusBadRequest)
-		return
-	}
-
-	if len(taskReq.Tests) == 0 {
-		http.Error(w, "At least one test is required", http.StatusBadRequest)
+	if err := validateTaskRequest(&taskReq); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Проверяем, принадлежит ли задача этому учителю
-	var createdBy int
+	var createdBy, testSetVersion int
+	var referenceJSON []byte
 	err = h.DB.QueryRow(
-		"SELECT created_by FROM tasks WHERE id::text = $1",
+		"SELECT created_by, reference_solution, COALESCE(test_set_version, 0) FROM tasks WHERE id::text = $1",
 		taskID,
-	).Scan(&createdBy)
+	).Scan(&createdBy, &referenceJSON, &testSetVersion)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -628,62 +960,92 @@ func (h *TaskHandler) UpdateTaskHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Конвертируем тесты в JSON
-	testsJSON, err := json.Marshal(taskReq.Tests)
+	// Задача с противоречивыми тестами или тестами, которые не проходит
+	// эталонное решение, сохраняется неопубликованной
+	validation, err := validateTaskForPublish(w, r, taskFromRequest(taskReq))
 	if err != nil {
-		http.Error(w, "Error processing tests", http.StatusInternalServerError)
+		writeTaskValidationError(w, err)
 		return
 	}
 
-	// Обновляем задачу в БД
-	query := `
-		UPDATE tasks 
-		SET 
-			title = $1,
-			description = $2,
-			language = $3,
-			difficulty = $4,
-			template = $5,
-			starter_code = $6,
-			tests = $7,
-			updated_at = $8,
-			is_published = $9
-		WHERE id::text = $10 AND created_by = $11
-		RETURNING id
-	`
+	// Ответы активной версии сгенерированных тестов посчитаны прежним эталонным
+	// решением, поэтому с новым решением она генерируется заново до сохранения
+	// задачи. Если это не удалось, задача не обновляется
+	var regenerated *models.TestSet
+	if testSetVersion > 0 && referenceChanged(referenceJSON, taskReq.ReferenceSolution) {
+		active, err := h.loadTestSet(taskID, testSetVersion)
+		if err != nil {
+			http.Error(w, "Error loading test set: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
 
-	now := time.Now()
-	var updatedID int
-	err = h.DB.QueryRow(
-		query,
-		taskReq.Title,
-		taskReq.Description,
-		taskReq.Language,
-		taskReq.Difficulty,
-		taskReq.Template,
-		taskReq.StarterCode,
-		testsJSON,
-		now,
-		taskReq.IsPublished,
-		taskID,
-		userID,
-	).Scan(&updatedID)
+		// Генерация может быть дольше WriteTimeout сервера - продлеваем дедлайн ответа
+		timeout := testGenerationTimeout()
+		http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 10*time.Second))
+		ctx, cancel := context.WithTimeout(executionContext(r), timeout)
+		defer cancel()
 
+		task := taskFromRequest(taskReq)
+		task.ID = taskID
+		active.Tests, err = regenerateActiveTestSet(ctx, active, task)
+		if err != nil {
+			writeGenerationError(w, r, ctx, task, err, "Reference solution changed, but the active test set could not be regenerated: ", timeout)
+			return
+		}
+		regenerated = &active
+	}
+
+	// Обновляем задачу в БД
+	updatedID, err := h.updateTask(taskID, taskReq, userID, taskReq.IsPublished && validation.Valid, validation)
 	if err != nil {
 		http.Error(w, "Error updating task: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if regenerated != nil {
+		// Если ответы не изменились, активной остается прежняя версия
+		testSetVersion, err = h.importTestSet(r.Context(), taskID, userID, taskFromRequest(taskReq), *regenerated.Generator, regenerated.Tests)
+		if err != nil {
+			log.Printf("❌ Ошибка сохранения версии тестов: %v", err)
+			http.Error(w, "Task updated, but error saving regenerated test set: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Printf("✅ Задача %s: эталонное решение изменено, версия тестов %d", taskID, testSetVersion)
+	}
+
 	// Возвращаем успешный ответ
+	message := "Task updated successfully"
+	if taskReq.IsPublished && !validation.Valid {
+		message = "Task updated but not published: tests failed validation"
+	}
 	response := map[string]interface{}{
-		"id":      strconv.Itoa(updatedID),
-		"message": "Task updated successfully",
+		"id":           strconv.Itoa(updatedID),
+		"message":      message,
+		"is_published": taskReq.IsPublished && validation.Valid,
+		"validation":   validation,
+	}
+	if regenerated != nil {
+		response["test_set_version"] = testSetVersion
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// referenceChanged сообщает, отличается ли эталонное решение из запроса от
+// сохраненного в задаче. Решения сравниваются в JSON: в базе он переформатирован
+func referenceChanged(storedJSON []byte, reference *models.ReferenceSolution) bool {
+	var stored *models.ReferenceSolution
+	if len(storedJSON) > 0 {
+		if err := json.Unmarshal(storedJSON, &stored); err != nil {
+			return true
+		}
+	}
+	a, errA := json.Marshal(stored)
+	b, errB := json.Marshal(reference)
+	return errA != nil || errB != nil || !bytes.Equal(a, b)
+}
+
 // DeleteTaskHandler удаляет задачу
 func (h *TaskHandler) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "DELETE" {
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"backend/internal/models"
+	"backend/internal/taskpackage"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxPackageUploadSize ограничивает размер загружаемого zip-архива
+const maxPackageUploadSize = 64 << 20
+
+// ExportTasksHandler выгружает задачи преподавателя zip-архивом:
+// GET /api/teacher/tasks/export?ids=1,2 (без ids - все задачи)
+func (h *TaskHandler) ExportTasksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, role, err := h.getUserFromRequest(r)
+	if err != nil || role != "teacher" {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	var ids []string
+	if value := r.URL.Query().Get("ids"); value != "" {
+		ids = strings.Split(value, ",")
+	}
+
+	tasks, err := h.ExportTasks(userID, ids)
+	if err != nil {
+		log.Printf("❌ Ошибка экспорта задач: %v", err)
+		http.Error(w, "Error exporting tasks", http.StatusInternalServerError)
+		return
+	}
+	if len(tasks) == 0 {
+		http.Error(w, "No tasks to export", http.StatusNotFound)
+		return
+	}
+
+	// Архив собирается целиком, чтобы ошибка не оборвала уже начатый ответ
+	var buf bytes.Buffer
+	if err := taskpackage.Write(&buf, tasks); err != nil {
+		log.Printf("❌ Ошибка упаковки задач: %v", err)
+		http.Error(w, "Error exporting tasks: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("📦 Экспортировано %d задач учителя ID: %d", len(tasks), userID)
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="tasks.zip"`)
+	w.Write(buf.Bytes())
+}
+
+// ImportTasksHandler загружает задачи из zip-архива: POST /api/teacher/tasks/import
+// с архивом в теле запроса или в поле file формы multipart. Кроме пакетов платформы
+// принимаются пакеты Polygon и Kattis; их языки задаются параметром languages=python,cpp
+func (h *TaskHandler) ImportTasksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, role, err := h.getUserFromRequest(r)
+	if err != nil || role != "teacher" {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPackageUploadSize)
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Package file is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, "Error reading package: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var options taskpackage.Options
+	if value := r.URL.Query().Get("languages"); value != "" {
+		options.Languages = strings.Split(value, ",")
+	}
+	tasks, err := taskpackage.Read(data, options)
+	if err != nil {
+		http.Error(w, "Invalid package: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Каждая задача проверяется эталонным решением - продлеваем дедлайн ответа
+	deadline := time.Duration(len(tasks)) * (taskValidationTimeout() + testGenerationTimeout())
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(deadline + 10*time.Second))
+
+	results, err := h.ImportTasks(executionContext(r), tasks, userID)
+	if err != nil {
+		writeTaskValidationError(w, err)
+		return
+	}
+
+	imported := 0
+	for _, result := range results {
+		if result.Error == "" {
+			imported++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": imported == len(results),
+		"message": fmt.Sprintf("Imported %d of %d tasks", imported, len(results)),
+		"tasks":   results,
+	})
+}
+
+// ExportTasks загружает задачи преподавателя для пакета вместе с активной
+// версией сгенерированных тестов. ids ограничивает выгрузку задачами с этими id
+func (h *TaskHandler) ExportTasks(userID int, ids []string) ([]taskpackage.Task, error) {
+	query := `
+		SELECT t.id::text, t.title, t.description, t.language, COALESCE(t.difficulty, ''),
+		       COALESCE(t.template, ''), COALESCE(t.starter_code, ''), t.tests,
+		       COALESCE(t.is_published, false), t.checker, t.function_signature,
+		       COALESCE(t.entry_point, ''), t.support_files, t.starter_files,
+		       t.reference_solution, ts.generator, ts.tests
+		FROM tasks t
+		LEFT JOIN task_test_sets ts ON ts.task_id = t.id AND ts.version = t.test_set_version
+		WHERE t.created_by = $1
+		ORDER BY t.id
+	`
+
+	rows, err := h.DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wanted := make(map[string]bool)
+	for _, id := range ids {
+		wanted[strings.TrimSpace(id)] = true
+	}
+
+	var tasks []taskpackage.Task
+	for rows.Next() {
+		var task taskpackage.Task
+		var testsJSON, checkerJSON, functionJSON, supportJSON, starterJSON []byte
+		var referenceJSON, generatorJSON, generatedJSON []byte
+
+		err := rows.Scan(
+			&task.ID,
+			&task.Title,
+			&task.Description,
+			&task.Language,
+			&task.Difficulty,
+			&task.Template,
+			&task.StarterCode,
+			&testsJSON,
+			&task.IsPublished,
+			&checkerJSON,
+			&functionJSON,
+			&task.EntryPoint,
+			&supportJSON,
+			&starterJSON,
+			&referenceJSON,
+			&generatorJSON,
+			&generatedJSON,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(wanted) > 0 && !wanted[task.ID] {
+			continue
+		}
+
+		if err := json.Unmarshal(testsJSON, &task.Tests); err != nil {
+			return nil, fmt.Errorf("task %s tests: %w", task.ID, err)
+		}
+		if len(checkerJSON) > 0 {
+			if err := json.Unmarshal(checkerJSON, &task.Checker); err != nil {
+				return nil, fmt.Errorf("task %s checker: %w", task.ID, err)
+			}
+		}
+		if len(functionJSON) > 0 {
+			if err := json.Unmarshal(functionJSON, &task.Function); err != nil {
+				return nil, fmt.Errorf("task %s function: %w", task.ID, err)
+			}
+		}
+		parseTaskFiles(&task.Task, supportJSON, starterJSON)
+		if len(referenceJSON) > 0 {
+			if err := json.Unmarshal(referenceJSON, &task.ReferenceSolution); err != nil {
+				return nil, fmt.Errorf("task %s reference solution: %w", task.ID, err)
+			}
+		}
+		if len(generatorJSON) > 0 {
+			if err := json.Unmarshal(generatorJSON, &task.Generator); err != nil {
+				return nil, fmt.Errorf("task %s generator: %w", task.ID, err)
+			}
+			if err := json.Unmarshal(generatedJSON, &task.GeneratedTests); err != nil {
+				return nil, fmt.Errorf("task %s generated tests: %w", task.ID, err)
+			}
+		}
+
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// ImportTasks сохраняет задачи пакета преподавателю userID. Задача с тем же
+// названием и языком обновляется, остальные создаются. Как и при сохранении
+// из панели, тесты проверяются эталонным решением, и задача публикуется, только
+// если проверка пройдена. Ошибки отдельных задач записываются в их результаты,
+// ошибка возвращается, только если импорт прервали (отмена ctx, перегрузка исполнителя)
+func (h *TaskHandler) ImportTasks(ctx context.Context, tasks []taskpackage.Task, userID int) ([]models.TaskImportResult, error) {
+	var results []models.TaskImportResult
+	for _, task := range tasks {
+		result, err := h.importTask(ctx, task, userID)
+		if err != nil {
+			return results, err
+		}
+		if result.Error != "" {
+			log.Printf("⚠️ Импорт задачи '%s' (%s): %s", result.Title, result.Language, result.Error)
+		} else if len(result.Unmapped) > 0 {
+			log.Printf("⚠️ Импортирована задача '%s' (%s): %s, id=%s, не перенесено: %d",
+				result.Title, result.Language, result.Action, result.ID, len(result.Unmapped))
+		} else {
+			log.Printf("✅ Импортирована задача '%s' (%s): %s, id=%s", result.Title, result.Language, result.Action, result.ID)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// importTask сохраняет одну задачу пакета
+func (h *TaskHandler) importTask(ctx context.Context, task taskpackage.Task, userID int) (models.TaskImportResult, error) {
+	result := models.TaskImportResult{Title: task.Title, Language: task.Language, Unmapped: task.Unmapped}
+
+	taskReq := models.TaskRequest{
+		Title:             task.Title,
+		Description:       task.Description,
+		Language:          task.Language,
+		Difficulty:        task.Difficulty,
+		Template:          task.Template,
+		StarterCode:       task.StarterCode,
+		Tests:             task.Tests,
+		IsPublished:       task.IsPublished,
+		Checker:           task.Checker,
+		Function:          task.Function,
+		EntryPoint:        task.EntryPoint,
+		SupportFiles:      task.SupportFiles,
+		StarterFiles:      task.StarterFiles,
+		ReferenceSolution: task.ReferenceSolution,
+	}
+	if err := validateTaskRequest(&taskReq); err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Language = taskReq.Language
+
+	validation, err := validateTaskWithin(ctx, taskFromRequest(taskReq), taskValidationTimeout())
+	if err != nil {
+		return result, err
+	}
+	result.Validation = validation
+	result.IsPublished = taskReq.IsPublished && validation.Valid
+
+	var existingID string
+	err = h.DB.QueryRow(
+		"SELECT id::text FROM tasks WHERE title = $1 AND language = $2 AND created_by = $3 ORDER BY id LIMIT 1",
+		taskReq.Title, taskReq.Language, userID,
+	).Scan(&existingID)
+
+	var id int
+	switch {
+	case err == sql.ErrNoRows:
+		id, err = h.insertTask(taskReq, userID, result.IsPublished, validation)
+		result.Action = "created"
+	case err == nil:
+		id, err = h.updateTask(existingID, taskReq, userID, result.IsPublished, validation)
+		result.Action = "updated"
+	}
+	if err != nil {
+		result.Error = "Error saving task: " + err.Error()
+		return result, nil
+	}
+	result.ID = fmt.Sprint(id)
+
+	if task.Generator != nil {
+		version, err := h.importTestSet(ctx, result.ID, userID, taskFromRequest(taskReq), *task.Generator, task.GeneratedTests)
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			result.Error = "Test set: " + err.Error()
+		}
+		result.TestSetVersion = version
+	}
+	return result, nil
+}
+
+// importTestSet сохраняет сгенерированные тесты задачи новой активной версией.
+// Без тестов в пакете они генерируются. Если тесты совпадают с активной версией,
+// новая версия не создается
+func (h *TaskHandler) importTestSet(ctx context.Context, taskID string, userID int, task models.Task, generator models.TestGenerator, tests []models.Test) (int, error) {
+	if len(tests) == 0 {
+		if err := validateTestGenerator(generator); err != nil {
+			return 0, err
+		}
+		generateCtx, cancel := context.WithTimeout(ctx, testGenerationTimeout())
+		defer cancel()
+
+		var err error
+		if tests, err = generateTests(generateCtx, task, generator); err != nil {
+			return 0, err
+		}
+	}
+
+	var activeVersion int
+	var activeJSON []byte
+	err := h.DB.QueryRow(`
+		SELECT ts.version, ts.tests
+		FROM tasks t
+		JOIN task_test_sets ts ON ts.task_id = t.id AND ts.version = t.test_set_version
+		WHERE t.id::text = $1
+	`, taskID).Scan(&activeVersion, &activeJSON)
+	if err == nil {
+		var active []models.Test
+		if json.Unmarshal(activeJSON, &active) == nil && sameTests(active, tests) {
+			return activeVersion, nil
+		}
+	} else if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	testSet, err := h.saveTestSet(taskID, userID, generator, tests)
+	if err != nil {
+		return 0, err
+	}
+	return testSet.Version, nil
+}
+
+// sameTests сравнивает наборы тестов по их JSON
+func sameTests(a, b []models.Test) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(aJSON, bJSON)
+}
+
+// SeedTasks импортирует задачи из пакета (zip-архива или директории), если
+// в базе ещё нет задач. Задачи принадлежат первому преподавателю
+func (h *TaskHandler) SeedTasks(ctx context.Context, path string) {
+	var count int
+	if err := h.DB.QueryRow("SELECT COUNT(*) FROM tasks").Scan(&count); err != nil {
+		log.Printf("⚠️ Ошибка при проверке количества задач: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("✅ В БД уже есть %d задач, пропускаем загрузку из %s", count, path)
+		return
+	}
+
+	var teacherID int
+	if err := h.DB.QueryRow("SELECT id FROM users WHERE role = 'teacher' ORDER BY id LIMIT 1").Scan(&teacherID); err != nil {
+		log.Printf("⚠️ Не найден преподаватель для задач из %s: %v", path, err)
+		return
+	}
+
+	tasks, err := taskpackage.Open(path, taskpackage.Options{})
+	if err != nil {
+		log.Printf("❌ Ошибка чтения пакета задач %s: %v", path, err)
+		return
+	}
+
+	results, err := h.ImportTasks(ctx, tasks, teacherID)
+	if err != nil {
+		log.Printf("❌ Загрузка задач из %s прервана: %v", path, err)
+		return
+	}
+	log.Printf("📊 Загружено %d задач из %s", len(results), path)
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"backend/internal/checker"
+	"backend/internal/executor"
+	"backend/internal/harness"
+	"backend/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// defaultTaskValidationTimeout ограничивает прогон эталонного решения при сохранении задачи
+const defaultTaskValidationTimeout = 120 * time.Second
+
+// taskValidationTimeout возвращает лимит проверки задачи из TASK_VALIDATION_TIMEOUT_SECONDS
+func taskValidationTimeout() time.Duration {
+	return time.Duration(envPositiveInt("TASK_VALIDATION_TIMEOUT_SECONDS", int(defaultTaskValidationTimeout/time.Second))) * time.Second
+}
+
+// validateTaskForPublish проверяет тесты сохраняемой задачи. Прогон эталонного
+// решения может быть дольше WriteTimeout сервера, поэтому дедлайн ответа продлевается
+func validateTaskForPublish(w http.ResponseWriter, r *http.Request, task models.Task) (*models.TaskValidation, error) {
+	timeout := taskValidationTimeout()
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 10*time.Second))
+	return validateTaskWithin(executionContext(r), task, timeout)
+}
+
+// validateTaskWithin проверяет тесты задачи с ограничением по времени. Если
+// проверка не уложилась в timeout, задача считается непрошедшей проверку
+func validateTaskWithin(parent context.Context, task models.Task, timeout time.Duration) (*models.TaskValidation, error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	validation, err := validateTaskTests(ctx, task)
+	if err != nil && ctx.Err() == context.DeadlineExceeded && parent.Err() == nil {
+		// Эталонное решение не уложилось в лимит проверки - задача не публикуется
+		return &models.TaskValidation{
+			Problems: []models.TaskProblem{{
+				Type:    models.TaskProblemValidationError,
+				Message: fmt.Sprintf("Проверка тестов не уложилась в %v", timeout),
+			}},
+			CheckedAt: time.Now(),
+		}, nil
+	}
+	return validation, err
+}
+
+// taskFromRequest собирает задачу для проверки тестов из запроса преподавателя
+func taskFromRequest(req models.TaskRequest) models.Task {
+	return models.Task{
+		Title:             req.Title,
+		Language:          req.Language,
+		Tests:             req.Tests,
+		Checker:           req.Checker,
+		Function:          req.Function,
+		EntryPoint:        req.EntryPoint,
+		SupportFiles:      req.SupportFiles,
+		ReferenceSolution: req.ReferenceSolution,
+	}
+}
+
+// writeTaskValidationError отвечает, что проверку задачи прервали
+func writeTaskValidationError(w http.ResponseWriter, err error) {
+	if executor.IsBusy(err) {
+		writeBusyError(w, err)
+		return
+	}
+	log.Printf("🛑 Task validation cancelled: %v", err)
+	http.Error(w, "Task validation cancelled", http.StatusServiceUnavailable)
+}
+
+// validateTaskTests проверяет тесты задачи перед публикацией: ищет противоречивые
+// тесты и прогоняет на всех тестах эталонное решение, если оно задано. Ошибка
+// возвращается, только если проверку прервали (отмена ctx, перегрузка исполнителя)
+func validateTaskTests(ctx context.Context, task models.Task) (*models.TaskValidation, error) {
+	validation := &models.TaskValidation{CheckedAt: time.Now()}
+	validation.Problems = contradictoryTests(ctx, task)
+
+	if reference := task.ReferenceSolution; reference != nil {
+		language := reference.Language
+		if language == "" {
+			language = task.Language
+		}
+
+		log.Printf("🧪 Validating task %q with reference solution (%s)", task.Title, language)
+		response, err := judgeSolution(ctx, reference.Code, language, reference.Files, task.Tests, task)
+		if err != nil {
+			return nil, err
+		}
+		validation.ReferenceChecked = true
+		validation.ReferenceResult = response
+
+		for _, result := range response.TestResults {
+			if result.Passed {
+				continue
+			}
+			validation.Problems = append(validation.Problems, models.TaskProblem{
+				Type:    models.TaskProblemReferenceFailed,
+				Tests:   []int{result.TestNumber},
+				Verdict: result.Verdict,
+				Message: referenceFailureMessage(result),
+			})
+		}
+	}
+
+	validation.Valid = len(validation.Problems) == 0
+	log.Printf("📊 Task %q validation: valid=%t, problems=%d", task.Title, validation.Valid, len(validation.Problems))
+	return validation, nil
+}
+
+// contradictoryTests находит тесты с одинаковым вводом и несовместимыми ответами.
+// Ответы сравниваются чекером задачи, поэтому "1.0" и "1.00" для float-чекера
+// не противоречат друг другу. С пользовательским чекером у теста может быть
+// несколько верных ответов, и такие тесты не сравниваются
+func contradictoryTests(ctx context.Context, task models.Task) []models.TaskProblem {
+	if task.Checker != nil && task.Checker.Type == models.CheckerCustom {
+		return nil
+	}
+
+	tests := task.Tests
+	var compare checker.Checker
+	var err error
+	if task.Function != nil {
+		// Тесты функции сравниваются по аргументам и результату в JSON
+		tests, err = harness.PrepareTests(tests)
+		if err == nil {
+			compare, err = checker.NewFunction(ctx, task.Checker, nil)
+		}
+	} else {
+		compare, err = checker.New(ctx, task.Checker, nil)
+	}
+	if err != nil {
+		// Некорректные тесты и чекер отклоняются раньше, при разборе задачи
+		return nil
+	}
+	defer compare.Close()
+
+	var problems []models.TaskProblem
+	first := make(map[string]int) // Ввод -> индекс первого теста с ним
+	for i, test := range tests {
+		input := normalizeOutput(test.Input)
+		j, seen := first[input]
+		if !seen {
+			first[input] = i
+			continue
+		}
+
+		result, err := compare.Check(ctx, test.Input, tests[j].ExpectedOutput, test.ExpectedOutput)
+		if err != nil || result.Passed {
+			continue
+		}
+		problems = append(problems, models.TaskProblem{
+			Type:    models.TaskProblemContradictoryTests,
+			Tests:   []int{j + 1, i + 1},
+			Message: fmt.Sprintf("Тесты %d и %d: одинаковые входные данные, но разные ответы", j+1, i+1),
+		})
+	}
+	return problems
+}
+
+// referenceFailureMessage описывает тест, не пройденный эталонным решением
+func referenceFailureMessage(result models.TestResult) string {
+	var message string
+	switch result.Verdict {
+	case models.VerdictCompilationError:
+		return "Эталонное решение не компилируется: " + result.Error
+	case models.VerdictWrongAnswer:
+		message = "ответ не совпадает с ожидаемым"
+	case models.VerdictTimeLimitExceeded:
+		message = "превышен лимит времени"
+	case models.VerdictMemoryLimitExceeded:
+		message = "превышен лимит памяти"
+	case models.VerdictOutputLimitExceeded:
+		message = "превышен лимит вывода"
+	case models.VerdictRuntimeError:
+		message = "ошибка выполнения"
+	default:
+		message = "сбой проверки"
+	}
+	if result.Error != "" {
+		message += ": " + result.Error
+	}
+	return fmt.Sprintf("Тест %d: %s", result.TestNumber, message)
+}
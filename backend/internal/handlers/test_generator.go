@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"backend/internal/executor"
+	"backend/internal/harness"
+	"backend/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMaxGeneratedTests ограничивает число тестов одной версии генератора
+const defaultMaxGeneratedTests = 500
+
+// defaultTestGenerationTimeout ограничивает генерацию тестов вместе с прогоном эталонного решения
+const defaultTestGenerationTimeout = 300 * time.Second
+
+// maxGeneratedTests возвращает лимит тестов генератора из TEST_GENERATOR_MAX_TESTS
+func maxGeneratedTests() int {
+	return envPositiveInt("TEST_GENERATOR_MAX_TESTS", defaultMaxGeneratedTests)
+}
+
+// testGenerationTimeout возвращает лимит генерации из TEST_GENERATION_TIMEOUT_SECONDS
+func testGenerationTimeout() time.Duration {
+	return time.Duration(envPositiveInt("TEST_GENERATION_TIMEOUT_SECONDS", int(defaultTestGenerationTimeout/time.Second))) * time.Second
+}
+
+// generationFailure - генератор или эталонное решение не справились. Сообщение
+// показывается преподавателю
+type generationFailure struct {
+	message string
+}
+
+func (f *generationFailure) Error() string {
+	return f.message
+}
+
+// generationFailed создает ошибку генерации с сообщением для преподавателя
+func generationFailed(format string, args ...interface{}) error {
+	return &generationFailure{message: fmt.Sprintf(format, args...)}
+}
+
+// generatorSeeds возвращает seed для каждого теста: заданные явно или 1..Count
+func generatorSeeds(generator models.TestGenerator) []int64 {
+	if len(generator.Seeds) > 0 {
+		return generator.Seeds
+	}
+	seeds := make([]int64, generator.Count)
+	for i := range seeds {
+		seeds[i] = int64(i + 1)
+	}
+	return seeds
+}
+
+// validateTestGenerator проверяет настройки генератора до запуска
+func validateTestGenerator(generator models.TestGenerator) error {
+	if generator.Code == "" {
+		return fmt.Errorf("generator code is required")
+	}
+	count := len(generator.Seeds)
+	if count == 0 {
+		count = generator.Count
+	}
+	if count <= 0 {
+		return fmt.Errorf("count or seeds is required")
+	}
+	if limit := maxGeneratedTests(); count > limit {
+		return fmt.Errorf("too many tests: %d (max %d)", count, limit)
+	}
+	return nil
+}
+
+// generateTests запускает генератор на каждом seed и вычисляет ожидаемые ответы
+// эталонным решением задачи. Сгенерированные тесты скрыты от студентов.
+// Ошибка *generationFailure описывает проблему генератора или эталонного решения,
+// остальные ошибки - отмена ctx или перегрузка исполнителя (executor.IsBusy)
+func generateTests(ctx context.Context, task models.Task, generator models.TestGenerator) ([]models.Test, error) {
+	if task.ReferenceSolution == nil {
+		return nil, generationFailed("Для генерации тестов нужно эталонное решение задачи")
+	}
+
+	// Генератор и эталонное решение занимают одно место в лимитере исполнителя
+	ctx, release, err := executor.Acquire(ctx, codeExecutor)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	seeds := generatorSeeds(generator)
+	log.Printf("🎲 Generating %d tests for task %q", len(seeds), task.Title)
+
+	generatorSession, err := prepareGenerator(ctx, task, generator)
+	if err != nil {
+		return nil, err
+	}
+	defer generatorSession.Close()
+
+	referenceSession, err := prepareReference(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	defer referenceSession.Close()
+
+	tests := make([]models.Test, len(seeds))
+	for i, seed := range seeds {
+		test, err := generateTest(ctx, generatorSession, task, seed)
+		if err != nil {
+			return nil, err
+		}
+		if err := referenceAnswer(ctx, referenceSession, task, &test, seed); err != nil {
+			return nil, err
+		}
+		tests[i] = test
+	}
+
+	log.Printf("✅ Generated %d tests for task %q", len(tests), task.Title)
+	return tests, nil
+}
+
+// prepareGenerator компилирует генератор тестов
+func prepareGenerator(ctx context.Context, task models.Task, generator models.TestGenerator) (executor.Session, error) {
+	language := generator.Language
+	if language == "" {
+		language = task.Language
+	}
+
+	session, compileResult, err := executor.PrepareSession(ctx, codeExecutor, executor.ExecutionRequest{
+		Code:     generator.Code,
+		Language: language,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, generationFailed("Не удалось запустить генератор: %v", err)
+	}
+	if compileResult != nil {
+		return nil, generationFailed("Генератор не компилируется: %s", compileResult.ErrorMessage())
+	}
+	return session, nil
+}
+
+// generateTest получает от генератора входные данные теста для seed.
+// Ожидаемый ответ заполняет referenceAnswer
+func generateTest(ctx context.Context, session executor.Session, task models.Task, seed int64) (models.Test, error) {
+	test := models.Test{
+		Description: fmt.Sprintf("Сгенерирован, seed %d", seed),
+		IsHidden:    true,
+	}
+
+	result, err := session.Run(ctx, []string{strconv.FormatInt(seed, 10)}, 0)
+	if err != nil {
+		if ctx.Err() != nil {
+			return test, ctx.Err()
+		}
+		return test, generationFailed("Генератор (seed %d): %v", seed, err)
+	}
+	if verdict := executionVerdict(result); verdict != "" {
+		return test, generationFailed("Генератор (seed %d): %s %s", seed, verdict, result.ErrorMessage())
+	}
+
+	input := strings.TrimRight(result.Stdout, "\r\n")
+	if task.Function == nil {
+		test.Input = input
+		return test, nil
+	}
+
+	// Для задачи с функцией генератор печатает аргументы вызова
+	if err := json.Unmarshal([]byte(input), &test.Args); err != nil {
+		return test, generationFailed("Генератор (seed %d) должен напечатать JSON-массив аргументов: %v", seed, err)
+	}
+	if len(test.Args) != len(task.Function.Params) {
+		return test, generationFailed("Генератор (seed %d): ожидалось аргументов %d, получено %d",
+			seed, len(task.Function.Params), len(test.Args))
+	}
+	return test, nil
+}
+
+// prepareReference компилирует эталонное решение задачи (для задач с функцией -
+// вместе с харнессом)
+func prepareReference(ctx context.Context, task models.Task) (executor.Session, error) {
+	reference := task.ReferenceSolution
+	language := reference.Language
+	if language == "" {
+		language = task.Language
+	}
+
+	code := reference.Code
+	if task.Function != nil {
+		wrapped, err := harness.Wrap(language, code, task.Function)
+		if err != nil {
+			return nil, generationFailed("Ошибка обертки функции: %v", err)
+		}
+		code = wrapped
+	}
+
+	session, compileResult, err := executor.PrepareSession(ctx, codeExecutor, executor.ExecutionRequest{
+		Code:       code,
+		Language:   language,
+		Files:      executionFiles(reference.Files, task.SupportFiles),
+		EntryPoint: task.EntryPoint,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, generationFailed("Не удалось запустить эталонное решение: %v", err)
+	}
+	if compileResult != nil {
+		return nil, generationFailed("Эталонное решение не компилируется: %s", compileResult.ErrorMessage())
+	}
+	return session, nil
+}
+
+// referenceAnswer запускает эталонное решение на тесте и записывает его ответ
+// как ожидаемый
+func referenceAnswer(ctx context.Context, session executor.Session, task models.Task, test *models.Test, seed int64) error {
+	input := test.Input
+	if task.Function != nil {
+		prepared, err := harness.PrepareTests([]models.Test{*test})
+		if err != nil {
+			return generationFailed("Генератор (seed %d): %v", seed, err)
+		}
+		input = prepared[0].Input
+	}
+
+	var lines []string
+	if input != "" {
+		lines = strings.Split(input, "\n")
+	}
+	result, err := session.Run(ctx, lines, 0)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return generationFailed("Эталонное решение (seed %d): %v", seed, err)
+	}
+	if verdict := executionVerdict(result); verdict != "" {
+		return generationFailed("Эталонное решение (seed %d): %s %s", seed, verdict, result.ErrorMessage())
+	}
+
+	if task.Function == nil {
+		test.ExpectedOutput = strings.TrimRight(result.Stdout, "\r\n")
+		return nil
+	}
+	value, _, returned := harness.ParseOutput(result.Stdout)
+	if !returned || !json.Valid([]byte(value)) {
+		return generationFailed("Эталонное решение (seed %d) не вернуло результат функции", seed)
+	}
+	test.ExpectedReturn = json.RawMessage(value)
+	return nil
+}
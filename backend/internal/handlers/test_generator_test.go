@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"backend/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGeneratorSeeds(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator models.TestGenerator
+		want      []int64
+	}{
+		{"count", models.TestGenerator{Count: 3}, []int64{1, 2, 3}},
+		{"explicit seeds", models.TestGenerator{Seeds: []int64{42, 7, 42}}, []int64{42, 7, 42}},
+		{"seeds override count", models.TestGenerator{Count: 5, Seeds: []int64{-1}}, []int64{-1}},
+		{"nothing", models.TestGenerator{}, []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := generatorSeeds(tt.generator); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("generatorSeeds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTestGenerator(t *testing.T) {
+	t.Setenv("TEST_GENERATOR_MAX_TESTS", "10")
+
+	tests := []struct {
+		name      string
+		generator models.TestGenerator
+		wantErr   bool
+	}{
+		{"count", models.TestGenerator{Code: "print(1)", Count: 10}, false},
+		{"seeds", models.TestGenerator{Code: "print(1)", Seeds: []int64{1, 2}}, false},
+		{"no code", models.TestGenerator{Count: 1}, true},
+		{"no count", models.TestGenerator{Code: "print(1)"}, true},
+		{"negative count", models.TestGenerator{Code: "print(1)", Count: -1}, true},
+		{"too many tests", models.TestGenerator{Code: "print(1)", Count: 11}, true},
+		{"too many seeds", models.TestGenerator{Code: "print(1)", Seeds: make([]int64, 11)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateTestGenerator(tt.generator); (err != nil) != tt.wantErr {
+				t.Errorf("validateTestGenerator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// seedGenerator печатает два числа, зависящих только от seed
+const seedGenerator = `import random
+random.seed(int(input()))
+print(random.randint(1, 1000), random.randint(1, 1000))
+`
+
+func TestGenerateTests(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs generator and reference programs")
+	}
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+
+	sumTask := models.Task{
+		Title:             "Sum",
+		Language:          "python",
+		ReferenceSolution: &models.ReferenceSolution{Code: "a, b = map(int, input().split())\nprint(a + b)"},
+	}
+	functionTask := models.Task{
+		Title:    "Sum function",
+		Language: "python",
+		Function: &models.Function{Name: "add", ReturnType: "int", Params: []models.FunctionParam{
+			{Name: "a", Type: "int"}, {Name: "b", Type: "int"},
+		}},
+		ReferenceSolution: &models.ReferenceSolution{Code: "def add(a, b):\n    return a + b"},
+	}
+
+	tests := []struct {
+		name      string
+		task      models.Task
+		generator models.TestGenerator
+		failure   string // Часть сообщения *generationFailure, "" - генерация успешна
+	}{
+		{"stdin task", sumTask, models.TestGenerator{Code: seedGenerator, Seeds: []int64{1, 2, 1}}, ""},
+		{"function task", functionTask, models.TestGenerator{
+			Code:  "import json, random\nrandom.seed(int(input()))\nprint(json.dumps([random.randint(1, 9), random.randint(1, 9)]))",
+			Count: 3,
+		}, ""},
+		{"no reference solution", models.Task{Title: "No reference", Language: "python"},
+			models.TestGenerator{Code: seedGenerator, Count: 1}, "эталонное решение"},
+		{"generator crashes", sumTask, models.TestGenerator{Code: "raise SystemExit(3)", Count: 1}, "Генератор (seed 1)"},
+		{"generator does not compile", sumTask, models.TestGenerator{Code: "int main() {", Language: "cpp", Count: 1}, "Генератор не компилируется"},
+		{"function args are not JSON", functionTask, models.TestGenerator{Code: "print('1 2')", Count: 1}, "JSON-массив аргументов"},
+		{"wrong number of args", functionTask, models.TestGenerator{Code: "print('[1]')", Count: 1}, "ожидалось аргументов 2, получено 1"},
+		{"reference fails", models.Task{Title: "Broken", Language: "python", ReferenceSolution: &models.ReferenceSolution{Code: "print(1 / 0)"}},
+			models.TestGenerator{Code: seedGenerator, Count: 1}, "Эталонное решение (seed 1)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.generator.Language == "cpp" {
+				if _, err := exec.LookPath("g++"); err != nil {
+					t.Skip("g++ is not installed")
+				}
+			}
+
+			generated, err := generateTests(context.Background(), tt.task, tt.generator)
+			if tt.failure != "" {
+				var failure *generationFailure
+				if !errors.As(err, &failure) || !strings.Contains(failure.message, tt.failure) {
+					t.Fatalf("error = %v, want generation failure containing %q", err, tt.failure)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			seeds := generatorSeeds(tt.generator)
+			if len(generated) != len(seeds) {
+				t.Fatalf("generated %d tests, want %d", len(generated), len(seeds))
+			}
+			for i, test := range generated {
+				if !test.IsHidden {
+					t.Errorf("test %d is not hidden", i+1)
+				}
+				checkGeneratedAnswer(t, tt.task, test)
+			}
+
+			// Тот же seed дает тот же тест, и генерация воспроизводима
+			again, err := generateTests(context.Background(), tt.task, tt.generator)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(again, generated) {
+				t.Errorf("generation is not reproducible:\n%+v\n%+v", generated, again)
+			}
+			for i, seed := range seeds {
+				for j := 0; j < i; j++ {
+					if seeds[j] == seed && !reflect.DeepEqual(generated[i], generated[j]) {
+						t.Errorf("seed %d generated different tests %d and %d", seed, j+1, i+1)
+					}
+				}
+			}
+		})
+	}
+}
+
+// checkGeneratedAnswer проверяет, что ответ теста посчитан эталонным решением суммы
+func checkGeneratedAnswer(t *testing.T, task models.Task, test models.Test) {
+	t.Helper()
+	var a, b int
+	if task.Function == nil {
+		if _, err := fmt.Sscan(test.Input, &a, &b); err != nil {
+			t.Fatalf("input %q: %v", test.Input, err)
+		}
+		if want := strconv.Itoa(a + b); test.ExpectedOutput != want {
+			t.Errorf("input %q: expected output %q, want %q", test.Input, test.ExpectedOutput, want)
+		}
+		return
+	}
+
+	if len(test.Args) != 2 || json.Unmarshal(test.Args[0], &a) != nil || json.Unmarshal(test.Args[1], &b) != nil {
+		t.Fatalf("invalid args %s", test.Args)
+	}
+	if want := strconv.Itoa(a + b); string(test.ExpectedReturn) != want {
+		t.Errorf("args %s: expected return %s, want %s", test.Args, test.ExpectedReturn, want)
+	}
+}
+
+func TestReferenceChanged(t *testing.T) {
+	reference := &models.ReferenceSolution{Code: "print(1)", Language: "python"}
+	tests := []struct {
+		name      string
+		stored    string
+		reference *models.ReferenceSolution
+		want      bool
+	}{
+		{"same", `{"language": "python", "code": "print(1)"}`, reference, false},
+		{"both empty", "", nil, false},
+		{"null stored", "null", nil, false},
+		{"code changed", `{"code": "print(2)", "language": "python"}`, reference, true},
+		{"added", "", reference, true},
+		{"removed", `{"code": "print(1)", "language": "python"}`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := referenceChanged([]byte(tt.stored), tt.reference); got != tt.want {
+				t.Errorf("referenceChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegenerateActiveTestSet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs generator and reference programs")
+	}
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+
+	active := models.TestSet{Version: 1, Generator: &models.TestGenerator{Code: seedGenerator, Seeds: []int64{3, 5}}}
+	sumTask := models.Task{
+		Title:             "Sum",
+		Language:          "python",
+		ReferenceSolution: &models.ReferenceSolution{Code: "a, b = map(int, input().split())\nprint(a + b)"},
+	}
+	doubleTask := sumTask
+	doubleTask.ReferenceSolution = &models.ReferenceSolution{Code: "a, b = map(int, input().split())\nprint(2 * (a + b))"}
+
+	before, err := regenerateActiveTestSet(context.Background(), active, sumTask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := regenerateActiveTestSet(context.Background(), active, doubleTask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before) {
+		t.Fatalf("regenerated %d tests, want %d", len(after), len(before))
+	}
+	for i := range before {
+		// Входные данные те же, ответы посчитаны новым эталонным решением
+		want, _ := strconv.Atoi(before[i].ExpectedOutput)
+		if after[i].Input != before[i].Input || after[i].ExpectedOutput != strconv.Itoa(2*want) {
+			t.Errorf("test %d = %+v, want input %q and answer %d", i+1, after[i], before[i].Input, 2*want)
+		}
+	}
+
+	var failure *generationFailure
+	if _, err := regenerateActiveTestSet(context.Background(), models.TestSet{Version: 2}, sumTask); !errors.As(err, &failure) {
+		t.Errorf("error without generator = %v, want generation failure", err)
+	}
+}
@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"backend/internal/executor"
+	"backend/internal/languages"
+	"backend/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TestSetsHandler управляет версиями сгенерированных тестов задачи. route - часть
+// пути после /api/teacher/tasks/{id}/:
+//
+//	GET  test-sets                      - список версий
+//	POST test-sets                      - сгенерировать новую версию и сделать её активной
+//	GET  test-sets/{version}            - версия вместе с тестами
+//	POST test-sets/{version}/activate   - вернуться к другой версии
+func (h *TaskHandler) TestSetsHandler(w http.ResponseWriter, r *http.Request, route string) {
+	parts := strings.Split(strings.Trim(route, "/"), "/")
+	if parts[0] != "test-sets" || len(parts) > 3 {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	var version int
+	if len(parts) > 1 {
+		var err error
+		version, err = strconv.Atoi(parts[1])
+		if err != nil || version <= 0 {
+			http.Error(w, "Invalid test set version", http.StatusBadRequest)
+			return
+		}
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == "GET":
+		h.listTestSets(w, r)
+	case len(parts) == 1 && r.Method == "POST":
+		h.generateTestSet(w, r)
+	case len(parts) == 2 && r.Method == "GET":
+		h.getTestSet(w, r, version)
+	case len(parts) == 3 && parts[2] == "activate" && r.Method == "POST":
+		h.activateTestSet(w, r, version)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ownTaskForTestSets загружает задачу преподавателя для работы с тестами.
+// При ошибке ответ уже отправлен
+func (h *TaskHandler) ownTaskForTestSets(w http.ResponseWriter, r *http.Request) (models.Task, int, bool) {
+	var task models.Task
+
+	userID, role, err := h.getUserFromRequest(r)
+	if err != nil || role != "teacher" {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return task, 0, false
+	}
+
+	taskID := r.URL.Query().Get("id")
+	if taskID == "" {
+		http.Error(w, "Task ID is required", http.StatusBadRequest)
+		return task, 0, false
+	}
+
+	query := `
+		SELECT id::text, title, language, checker, function_signature,
+		       COALESCE(entry_point, ''), support_files, reference_solution,
+		       COALESCE(test_set_version, 0), created_by
+		FROM tasks
+		WHERE id::text = $1
+	`
+
+	var checkerJSON, functionJSON, supportJSON, referenceJSON []byte
+	var createdBy sql.NullInt64
+	err = h.DB.QueryRow(query, taskID).Scan(
+		&task.ID,
+		&task.Title,
+		&task.Language,
+		&checkerJSON,
+		&functionJSON,
+		&task.EntryPoint,
+		&supportJSON,
+		&referenceJSON,
+		&task.TestSetVersion,
+		&createdBy,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Task not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+		}
+		return task, 0, false
+	}
+
+	if int(createdBy.Int64) != userID {
+		http.Error(w, "You can only manage tests of your own tasks", http.StatusForbidden)
+		return task, 0, false
+	}
+
+	if len(checkerJSON) > 0 {
+		if err := json.Unmarshal(checkerJSON, &task.Checker); err != nil {
+			log.Printf("⚠️ Ошибка парсинга чекера задачи: %v", err)
+		}
+	}
+	if len(functionJSON) > 0 {
+		if err := json.Unmarshal(functionJSON, &task.Function); err != nil {
+			log.Printf("⚠️ Ошибка парсинга сигнатуры функции: %v", err)
+		}
+	}
+	parseTaskFiles(&task, supportJSON, nil)
+	if len(referenceJSON) > 0 {
+		if err := json.Unmarshal(referenceJSON, &task.ReferenceSolution); err != nil {
+			log.Printf("⚠️ Ошибка парсинга эталонного решения: %v", err)
+		}
+	}
+
+	return task, userID, true
+}
+
+// listTestSets возвращает версии сгенерированных тестов задачи без самих тестов
+func (h *TaskHandler) listTestSets(w http.ResponseWriter, r *http.Request) {
+	task, _, ok := h.ownTaskForTestSets(w, r)
+	if !ok {
+		return
+	}
+
+	query := `
+		SELECT version, generator, jsonb_array_length(tests), created_at
+		FROM task_test_sets
+		WHERE task_id::text = $1
+		ORDER BY version DESC
+	`
+	rows, err := h.DB.Query(query, task.ID)
+	if err != nil {
+		log.Printf("❌ Ошибка запроса версий тестов: %v", err)
+		http.Error(w, "Error fetching test sets", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	testSets := []models.TestSet{}
+	for rows.Next() {
+		var testSet models.TestSet
+		var generatorJSON []byte
+		if err := rows.Scan(&testSet.Version, &generatorJSON, &testSet.TestCount, &testSet.CreatedAt); err != nil {
+			log.Printf("⚠️ Ошибка сканирования версии тестов: %v", err)
+			continue
+		}
+		if len(generatorJSON) > 0 {
+			if err := json.Unmarshal(generatorJSON, &testSet.Generator); err != nil {
+				log.Printf("⚠️ Ошибка парсинга генератора: %v", err)
+			}
+		}
+		testSet.Active = testSet.Version == task.TestSetVersion
+		testSets = append(testSets, testSet)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(testSets)
+}
+
+// getTestSet возвращает версию сгенерированных тестов вместе с тестами
+func (h *TaskHandler) getTestSet(w http.ResponseWriter, r *http.Request, version int) {
+	task, _, ok := h.ownTaskForTestSets(w, r)
+	if !ok {
+		return
+	}
+
+	testSet, err := h.loadTestSet(task.ID, version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Test set not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+		}
+		return
+	}
+	testSet.Active = testSet.Version == task.TestSetVersion
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(testSet)
+}
+
+// loadTestSet читает версию сгенерированных тестов задачи
+func (h *TaskHandler) loadTestSet(taskID string, version int) (models.TestSet, error) {
+	var testSet models.TestSet
+	var generatorJSON, testsJSON []byte
+
+	err := h.DB.QueryRow(`
+		SELECT version, generator, tests, created_at
+		FROM task_test_sets
+		WHERE task_id::text = $1 AND version = $2
+	`, taskID, version).Scan(&testSet.Version, &generatorJSON, &testsJSON, &testSet.CreatedAt)
+	if err != nil {
+		return testSet, err
+	}
+
+	if len(generatorJSON) > 0 {
+		if err := json.Unmarshal(generatorJSON, &testSet.Generator); err != nil {
+			log.Printf("⚠️ Ошибка парсинга генератора: %v", err)
+		}
+	}
+	if err := json.Unmarshal(testsJSON, &testSet.Tests); err != nil {
+		return testSet, err
+	}
+	testSet.TestCount = len(testSet.Tests)
+	return testSet, nil
+}
+
+// generateTestSet генерирует новую версию тестов и делает её активной. Без кода
+// генератора в запросе повторяется генератор активной версии - например, после
+// исправления эталонного решения
+func (h *TaskHandler) generateTestSet(w http.ResponseWriter, r *http.Request) {
+	task, userID, ok := h.ownTaskForTestSets(w, r)
+	if !ok {
+		return
+	}
+
+	var generator models.TestGenerator
+	if err := json.NewDecoder(r.Body).Decode(&generator); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if generator.Code == "" && task.TestSetVersion > 0 {
+		active, err := h.loadTestSet(task.ID, task.TestSetVersion)
+		if err == nil && active.Generator != nil {
+			generator = *active.Generator
+		}
+	}
+
+	if err := validateTestGenerator(generator); err != nil {
+		http.Error(w, "Invalid generator: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if generator.Language != "" {
+		lang, ok := languages.Lookup(generator.Language)
+		if !ok {
+			http.Error(w, "Unsupported language: "+generator.Language, http.StatusBadRequest)
+			return
+		}
+		generator.Language = lang.ID
+	}
+
+	// Генерация может быть дольше WriteTimeout сервера - продлеваем дедлайн ответа
+	timeout := testGenerationTimeout()
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 10*time.Second))
+	ctx, cancel := context.WithTimeout(executionContext(r), timeout)
+	defer cancel()
+
+	tests, err := generateTests(ctx, task, generator)
+	if err != nil {
+		writeGenerationError(w, r, ctx, task, err, "", timeout)
+		return
+	}
+
+	testSet, err := h.saveTestSet(task.ID, userID, generator, tests)
+	if err != nil {
+		log.Printf("❌ Ошибка сохранения версии тестов: %v", err)
+		http.Error(w, "Error saving test set: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("✅ Задача %s: версия тестов %d (%d тестов)", task.ID, testSet.Version, len(tests))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"message":  "Test set generated",
+		"test_set": testSet,
+	})
+}
+
+// writeGenerationError отвечает, что тесты задачи сгенерировать не удалось.
+// prefix дописывается перед сообщением об ошибке генератора или эталонного решения
+func writeGenerationError(w http.ResponseWriter, r *http.Request, ctx context.Context, task models.Task, err error, prefix string, timeout time.Duration) {
+	var failure *generationFailure
+	switch {
+	case errors.As(err, &failure):
+		log.Printf("⚠️ Test generation for task %s failed: %s", task.ID, failure.message)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": prefix + failure.message,
+		})
+	case executor.IsBusy(err):
+		writeBusyError(w, err)
+	case ctx.Err() == context.DeadlineExceeded && r.Context().Err() == nil:
+		http.Error(w, prefix+"Test generation timed out after "+timeout.String(), http.StatusUnprocessableEntity)
+	default:
+		log.Printf("🛑 Test generation cancelled: %v", err)
+	}
+}
+
+// regenerateActiveTestSet заново генерирует активную версию тестов задачи тем же
+// генератором с теми же seed, вычисляя ответы эталонным решением task. Нужна,
+// когда преподаватель меняет эталонное решение: ответы активной версии
+// посчитаны прежним. Ошибки - как у generateTests
+func regenerateActiveTestSet(ctx context.Context, active models.TestSet, task models.Task) ([]models.Test, error) {
+	if active.Generator == nil {
+		return nil, generationFailed("У версии тестов %d нет генератора", active.Version)
+	}
+	return generateTests(ctx, task, *active.Generator)
+}
+
+// saveTestSet сохраняет тесты следующей версией и делает её активной
+func (h *TaskHandler) saveTestSet(taskID string, userID int, generator models.TestGenerator, tests []models.Test) (models.TestSet, error) {
+	testSet := models.TestSet{
+		Generator: &generator,
+		TestCount: len(tests),
+		Tests:     tests,
+		Active:    true,
+	}
+
+	generatorJSON, err := json.Marshal(generator)
+	if err != nil {
+		return testSet, err
+	}
+	testsJSON, err := json.Marshal(tests)
+	if err != nil {
+		return testSet, err
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		return testSet, err
+	}
+	defer tx.Rollback()
+
+	// Блокируем задачу, чтобы параллельные генерации не получили один номер версии
+	if _, err := tx.Exec("SELECT id FROM tasks WHERE id::text = $1 FOR UPDATE", taskID); err != nil {
+		return testSet, err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO task_test_sets (task_id, version, generator, tests, created_by)
+		SELECT t.id, COALESCE(MAX(ts.version), 0) + 1, $2, $3, $4
+		FROM tasks t
+		LEFT JOIN task_test_sets ts ON ts.task_id = t.id
+		WHERE t.id::text = $1
+		GROUP BY t.id
+		RETURNING version, created_at
+	`, taskID, generatorJSON, testsJSON, userID).Scan(&testSet.Version, &testSet.CreatedAt)
+	if err != nil {
+		return testSet, err
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE tasks SET test_set_version = $1, updated_at = $2 WHERE id::text = $3",
+		testSet.Version, time.Now(), taskID,
+	); err != nil {
+		return testSet, err
+	}
+
+	return testSet, tx.Commit()
+}
+
+// activateTestSet делает активной другую версию сгенерированных тестов
+func (h *TaskHandler) activateTestSet(w http.ResponseWriter, r *http.Request, version int) {
+	task, _, ok := h.ownTaskForTestSets(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.DB.Exec(`
+		UPDATE tasks SET test_set_version = $1, updated_at = $2
+		WHERE id::text = $3 AND EXISTS (
+			SELECT 1 FROM task_test_sets WHERE task_id = tasks.id AND version = $1
+		)
+	`, version, time.Now(), task.ID)
+	if err != nil {
+		http.Error(w, "Error activating test set: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		http.Error(w, "Test set not found", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("✅ Задача %s: активна версия тестов %d", task.ID, version)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":          true,
+		"message":          "Test set activated",
+		"test_set_version": version,
+	})
+}
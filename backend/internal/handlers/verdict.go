@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"backend/internal/executor"
+	"backend/internal/models"
+)
+
+// executionVerdict определяет вердикт по результату запуска, не сравнивая вывод.
+// Пустой вердикт означает, что программа отработала штатно и нужно проверить ответ
+func executionVerdict(result *executor.ExecutionResult) models.Verdict {
+	switch {
+	case result.CompileFailed:
+		return models.VerdictCompilationError
+	case result.TimedOut:
+		return models.VerdictTimeLimitExceeded
+	case result.OOMKilled:
+		return models.VerdictMemoryLimitExceeded
+	case result.OutputLimitExceeded():
+		return models.VerdictOutputLimitExceeded
+	case result.ExitCode != 0:
+		return models.VerdictRuntimeError
+	}
+	return ""
+}
+
+// aggregateVerdict возвращает итоговый вердикт решения - вердикт первого
+// непройденного теста, или AC если пройдены все
+func aggregateVerdict(results []models.TestResult) models.Verdict {
+	for _, result := range results {
+		if result.Verdict != models.VerdictAccepted {
+			return result.Verdict
+		}
+	}
+	return models.VerdictAccepted
+}
+
+// verdictMessage возвращает сообщение для пользователя по итоговому вердикту
+func verdictMessage(verdict models.Verdict) string {
+	switch verdict {
+	case models.VerdictAccepted:
+		return "✅ Все тесты пройдены!"
+	case models.VerdictCompilationError:
+		return "❌ Ошибка компиляции"
+	case models.VerdictTimeLimitExceeded:
+		return "❌ Превышен лимит времени"
+	case models.VerdictMemoryLimitExceeded:
+		return "❌ Превышен лимит памяти"
+	case models.VerdictOutputLimitExceeded:
+		return "❌ Превышен лимит вывода"
+	case models.VerdictRuntimeError:
+		return "❌ Ошибка выполнения"
+	case models.VerdictInternalError:
+		return "⚠️ Ошибка проверяющей системы, попробуйте позже"
+	}
+	return "❌ Некоторые тесты не пройдены"
+}
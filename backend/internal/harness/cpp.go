@@ -0,0 +1,233 @@
+package harness
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var cppMainRe = regexp.MustCompile(`\bint\s+main\s*\(`)
+
+// cppBaseTypes - типы C++ для базовых типов сигнатуры
+var cppBaseTypes = map[string]string{
+	typeInt:    "int",
+	typeLong:   "long long",
+	typeFloat:  "double",
+	typeString: "std::string",
+	typeBool:   "bool",
+}
+
+// wrapCpp дописывает после кода студента разбор аргументов и main.
+// Собственная main студента переименовывается и не вызывается
+func wrapCpp(code string, sig signature) string {
+	code = cppMainRe.ReplaceAllString(code, "int harness_student_main(")
+
+	var b strings.Builder
+	b.WriteString(code)
+	b.WriteString(cppRuntime)
+	b.WriteString(`
+int main() {
+    std::string harness_line;
+    std::getline(std::cin, harness_line);
+    harness::Parser harness_parser(harness_line);
+    harness_parser.expect('[');
+`)
+	var args []string
+	for i, param := range sig.params {
+		arg := fmt.Sprintf("harness_arg%d", i)
+		if i > 0 {
+			b.WriteString("    harness_parser.expect(',');\n")
+		}
+		fmt.Fprintf(&b, "    %s %s;\n    harness_parser.read(%s);\n", cppType(param), arg, arg)
+		args = append(args, arg)
+	}
+	fmt.Fprintf(&b, `    harness_parser.expect(']');
+    auto harness_result = %s(%s);
+    std::cout << "\n" << %q;
+    harness::write(std::cout, harness_result);
+    std::cout << std::endl;
+    return 0;
+}
+`, sig.name, strings.Join(args, ", "), ResultMarker)
+	return b.String()
+}
+
+// cppType возвращает тип C++, например std::vector<std::vector<int>> для int[][]
+func cppType(t valueType) string {
+	result := cppBaseTypes[t.base]
+	for i := 0; i < t.dims; i++ {
+		result = "std::vector<" + result + ">"
+	}
+	return result
+}
+
+// cppRuntime - разбор аргументов и сериализация результата в JSON
+const cppRuntime = `
+
+#include <cctype>
+#include <cmath>
+#include <cstdlib>
+#include <iomanip>
+#include <iostream>
+#include <string>
+#include <type_traits>
+#include <vector>
+
+namespace harness {
+
+struct Parser {
+    const std::string& text;
+    size_t pos = 0;
+
+    explicit Parser(const std::string& text) : text(text) {}
+
+    [[noreturn]] void fail() {
+        std::cerr << "harness: invalid arguments JSON at position " << pos << std::endl;
+        std::exit(1);
+    }
+
+    void skip() {
+        while (pos < text.size() && std::isspace(static_cast<unsigned char>(text[pos]))) pos++;
+    }
+
+    bool consume(char c) {
+        skip();
+        if (pos < text.size() && text[pos] == c) {
+            pos++;
+            return true;
+        }
+        return false;
+    }
+
+    void expect(char c) {
+        if (!consume(c)) fail();
+    }
+
+    std::string number() {
+        skip();
+        size_t start = pos;
+        while (pos < text.size() && std::string("+-0123456789.eE").find(text[pos]) != std::string::npos) pos++;
+        if (start == pos) fail();
+        return text.substr(start, pos - start);
+    }
+
+    void read(int& value) { value = static_cast<int>(std::strtoll(number().c_str(), nullptr, 10)); }
+    void read(long long& value) { value = std::strtoll(number().c_str(), nullptr, 10); }
+    void read(double& value) { value = std::strtod(number().c_str(), nullptr); }
+
+    void read(bool& value) {
+        skip();
+        if (text.compare(pos, 4, "true") == 0) {
+            value = true;
+            pos += 4;
+        } else if (text.compare(pos, 5, "false") == 0) {
+            value = false;
+            pos += 5;
+        } else {
+            fail();
+        }
+    }
+
+    void read(std::string& value) {
+        expect('"');
+        value.clear();
+        while (pos < text.size() && text[pos] != '"') {
+            char c = text[pos++];
+            if (c != '\\') {
+                value += c;
+                continue;
+            }
+            if (pos >= text.size()) fail();
+            char escaped = text[pos++];
+            switch (escaped) {
+                case 'n': value += '\n'; break;
+                case 't': value += '\t'; break;
+                case 'r': value += '\r'; break;
+                case 'b': value += '\b'; break;
+                case 'f': value += '\f'; break;
+                case 'u': {
+                    if (pos + 4 > text.size()) fail();
+                    unsigned long code = std::strtoul(text.substr(pos, 4).c_str(), nullptr, 16);
+                    pos += 4;
+                    if (code < 0x80) {
+                        value += static_cast<char>(code);
+                    } else if (code < 0x800) {
+                        value += static_cast<char>(0xC0 | (code >> 6));
+                        value += static_cast<char>(0x80 | (code & 0x3F));
+                    } else {
+                        value += static_cast<char>(0xE0 | (code >> 12));
+                        value += static_cast<char>(0x80 | ((code >> 6) & 0x3F));
+                        value += static_cast<char>(0x80 | (code & 0x3F));
+                    }
+                    break;
+                }
+                default: value += escaped;
+            }
+        }
+        expect('"');
+    }
+
+    template <class T>
+    void read(std::vector<T>& value) {
+        expect('[');
+        value.clear();
+        if (consume(']')) return;
+        do {
+            T item;
+            read(item);
+            value.push_back(item);
+        } while (consume(','));
+        expect(']');
+    }
+};
+
+inline void write(std::ostream& out, bool value) { out << (value ? "true" : "false"); }
+
+template <class T>
+typename std::enable_if<std::is_integral<T>::value>::type write(std::ostream& out, T value) {
+    out << static_cast<long long>(value);
+}
+
+template <class T>
+typename std::enable_if<std::is_floating_point<T>::value>::type write(std::ostream& out, T value) {
+    if (std::isfinite(value)) {
+        out << std::setprecision(17) << value;
+    } else {
+        out << "null";
+    }
+}
+
+inline void write(std::ostream& out, const std::string& value) {
+    out << '"';
+    for (char c : value) {
+        switch (c) {
+            case '"': out << "\\\""; break;
+            case '\\': out << "\\\\"; break;
+            case '\n': out << "\\n"; break;
+            case '\r': out << "\\r"; break;
+            case '\t': out << "\\t"; break;
+            default:
+                if (static_cast<unsigned char>(c) < 0x20) {
+                    out << "\\u00" << "0123456789abcdef"[(c >> 4) & 0xF] << "0123456789abcdef"[c & 0xF];
+                } else {
+                    out << c;
+                }
+        }
+    }
+    out << '"';
+}
+
+inline void write(std::ostream& out, const char* value) { write(out, std::string(value)); }
+
+template <class T>
+void write(std::ostream& out, const std::vector<T>& value) {
+    out << '[';
+    for (size_t i = 0; i < value.size(); i++) {
+        if (i > 0) out << ',';
+        write(out, static_cast<T>(value[i]));
+    }
+    out << ']';
+}
+
+}  // namespace harness
+`
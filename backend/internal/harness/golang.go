@@ -0,0 +1,77 @@
+package harness
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	goPackageRe = regexp.MustCompile(`(?m)^package\s+main\b`)
+	goMainRe    = regexp.MustCompile(`\bfunc\s+main\s*\(\s*\)`)
+)
+
+// goImports - пакеты обертки под собственными именами, чтобы не конфликтовать
+// с импортами студента. Добавляются в строку package, номера строк в ошибках
+// компиляции не сдвигаются
+const goImports = `; import (harnessjson "encoding/json"; harnessfmt "fmt"; harnessos "os")`
+
+// goBaseTypes - типы Go для базовых типов сигнатуры
+var goBaseTypes = map[string]string{
+	typeInt:    "int",
+	typeLong:   "int64",
+	typeFloat:  "float64",
+	typeString: "string",
+	typeBool:   "bool",
+}
+
+// wrapGo добавляет функцию main, вызывающую функцию студента. Собственная
+// main студента переименовывается и не вызывается
+func wrapGo(code string, sig signature) string {
+	code = goMainRe.ReplaceAllString(code, "func harnessStudentMain()")
+	if loc := goPackageRe.FindStringIndex(code); loc != nil {
+		code = code[:loc[1]] + goImports + code[loc[1]:]
+	} else {
+		code = "package main" + goImports + "\n" + code
+	}
+
+	var b strings.Builder
+	b.WriteString(code)
+	b.WriteString(`
+
+func main() {
+	var harnessArgs []harnessjson.RawMessage
+	if err := harnessjson.NewDecoder(harnessos.Stdin).Decode(&harnessArgs); err != nil {
+		harnessFail("invalid arguments: %v", err)
+	}
+	if len(harnessArgs) != ` + fmt.Sprint(len(sig.params)) + ` {
+		harnessFail("expected ` + fmt.Sprint(len(sig.params)) + ` arguments, got %d", len(harnessArgs))
+	}
+`)
+	var args []string
+	for i, param := range sig.params {
+		arg := fmt.Sprintf("harnessArg%d", i)
+		fmt.Fprintf(&b, "\tvar %s %s\n", arg, goType(param))
+		fmt.Fprintf(&b, "\tif err := harnessjson.Unmarshal(harnessArgs[%d], &%s); err != nil {\n", i, arg)
+		fmt.Fprintf(&b, "\t\tharnessFail(\"argument %d: %%v\", err)\n\t}\n", i+1)
+		args = append(args, arg)
+	}
+	fmt.Fprintf(&b, `	harnessResult, err := harnessjson.Marshal(%s(%s))
+	if err != nil {
+		harnessFail("cannot encode result: %%v", err)
+	}
+	harnessfmt.Printf("\n%%s%%s\n", %q, harnessResult)
+}
+
+func harnessFail(format string, args ...interface{}) {
+	harnessfmt.Fprintf(harnessos.Stderr, "harness: "+format+"\n", args...)
+	harnessos.Exit(1)
+}
+`, sig.name, strings.Join(args, ", "), ResultMarker)
+	return b.String()
+}
+
+// goType возвращает тип Go, например [][]int для int[][]
+func goType(t valueType) string {
+	return strings.Repeat("[]", t.dims) + goBaseTypes[t.base]
+}
@@ -0,0 +1,193 @@
+// Package harness оборачивает решение задачи "напишите функцию" в программу,
+// которая читает аргументы из stdin одной строкой JSON, вызывает функцию
+// студента и печатает возвращаемое значение в JSON после ResultMarker.
+// Вывод самого решения (отладочные print и т.п.) на результат не влияет
+package harness
+
+import (
+	"backend/internal/languages"
+	"backend/internal/models"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ResultMarker - префикс строки, в которой обертка выводит результат функции
+const ResultMarker = "__HARNESS_RESULT__:"
+
+// identifierRe - допустимое имя функции и параметра во всех поддерживаемых языках
+var identifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Базовые типы сигнатуры
+const (
+	typeInt    = "int"
+	typeLong   = "long"
+	typeFloat  = "float"
+	typeString = "string"
+	typeBool   = "bool"
+)
+
+// valueType - разобранный тип сигнатуры: базовый тип и вложенность массивов
+type valueType struct {
+	base string
+	dims int
+}
+
+// signature - проверенная сигнатура функции
+type signature struct {
+	name   string
+	params []valueType
+}
+
+// generator генерирует программу-обертку вокруг кода студента
+type generator func(code string, sig signature) string
+
+// generators - обертки по языкам (id из реестра языков)
+var generators = map[string]generator{
+	"python":     wrapPython,
+	"javascript": wrapJavaScript,
+	"go":         wrapGo,
+	"java":       wrapJava,
+	"cpp":        wrapCpp,
+}
+
+// generatorFor находит обертку по названию или псевдониму языка
+func generatorFor(language string) (generator, bool) {
+	lang, ok := languages.Lookup(language)
+	if !ok {
+		return nil, false
+	}
+	generate, ok := generators[lang.ID]
+	return generate, ok
+}
+
+// Supports сообщает, есть ли обертка для языка
+func Supports(language string) bool {
+	_, ok := generatorFor(language)
+	return ok
+}
+
+// Validate проверяет сигнатуру функции задачи на языке language
+func Validate(function *models.Function, language string) error {
+	if !Supports(language) {
+		return fmt.Errorf("function tasks are not supported for language %s", language)
+	}
+	_, err := parseSignature(function)
+	return err
+}
+
+// ValidateTest проверяет, что тест задает аргументы под сигнатуру и ожидаемый результат
+func ValidateTest(function *models.Function, test models.Test) error {
+	if len(test.Args) != len(function.Params) {
+		return fmt.Errorf("expected %d args, got %d", len(function.Params), len(test.Args))
+	}
+	for i, arg := range test.Args {
+		if !json.Valid(arg) {
+			return fmt.Errorf("arg %d is not valid JSON", i+1)
+		}
+	}
+	if len(test.ExpectedReturn) == 0 || !json.Valid(test.ExpectedReturn) {
+		return fmt.Errorf("expected_return is missing or not valid JSON")
+	}
+	return nil
+}
+
+// Wrap возвращает код программы, вызывающей функцию студента
+func Wrap(language, code string, function *models.Function) (string, error) {
+	generate, ok := generatorFor(language)
+	if !ok {
+		return "", fmt.Errorf("function tasks are not supported for language %s", language)
+	}
+	sig, err := parseSignature(function)
+	if err != nil {
+		return "", err
+	}
+	return generate(code, sig), nil
+}
+
+// PrepareTests переводит тесты функции в тесты программы-обертки: Input - строка
+// JSON с аргументами, ExpectedOutput - ожидаемый результат в компактном JSON
+func PrepareTests(tests []models.Test) ([]models.Test, error) {
+	prepared := make([]models.Test, len(tests))
+	for i, test := range tests {
+		args := test.Args
+		if args == nil {
+			args = []json.RawMessage{}
+		}
+		// json.Marshal уплотняет RawMessage, переводы строк внутри строк экранируются
+		input, err := json.Marshal(args)
+		if err != nil {
+			return nil, fmt.Errorf("test %d: invalid args: %w", i+1, err)
+		}
+		expected, err := json.Marshal(test.ExpectedReturn)
+		if err != nil {
+			return nil, fmt.Errorf("test %d: invalid expected_return: %w", i+1, err)
+		}
+
+		test.Input = string(input)
+		test.ExpectedOutput = string(expected)
+		prepared[i] = test
+	}
+	return prepared, nil
+}
+
+// ParseOutput отделяет результат функции от собственного вывода решения.
+// ok=false, если программа завершилась, не вернув результат
+func ParseOutput(stdout string) (value, programOutput string, ok bool) {
+	index := strings.LastIndex(stdout, ResultMarker)
+	if index < 0 || (index > 0 && stdout[index-1] != '\n') {
+		return "", stdout, false
+	}
+
+	value = stdout[index+len(ResultMarker):]
+	if end := strings.IndexByte(value, '\n'); end >= 0 {
+		value = value[:end]
+	}
+	// Перед результатом обертка печатает перевод строки
+	programOutput = strings.TrimSuffix(stdout[:index], "\n")
+	return strings.TrimSpace(value), programOutput, true
+}
+
+// parseSignature проверяет имена и типы сигнатуры
+func parseSignature(function *models.Function) (signature, error) {
+	var sig signature
+	if function == nil {
+		return sig, fmt.Errorf("function signature is required")
+	}
+	if !identifierRe.MatchString(function.Name) {
+		return sig, fmt.Errorf("invalid function name: %q", function.Name)
+	}
+	sig.name = function.Name
+
+	for _, param := range function.Params {
+		if !identifierRe.MatchString(param.Name) {
+			return sig, fmt.Errorf("invalid parameter name: %q", param.Name)
+		}
+		paramType, err := parseType(param.Type)
+		if err != nil {
+			return sig, fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+		sig.params = append(sig.params, paramType)
+	}
+
+	// Результат обертки сериализуют без объявления типа, но тип нужен для документации задачи
+	if _, err := parseType(function.ReturnType); err != nil {
+		return sig, fmt.Errorf("return type: %w", err)
+	}
+	return sig, nil
+}
+
+// parseType разбирает тип вида int, string[] или float[][]
+func parseType(name string) (valueType, error) {
+	t := valueType{base: strings.TrimSpace(name)}
+	for strings.HasSuffix(t.base, "[]") {
+		t.base = strings.TrimSpace(strings.TrimSuffix(t.base, "[]"))
+		t.dims++
+	}
+	switch t.base {
+	case typeInt, typeLong, typeFloat, typeString, typeBool:
+		return t, nil
+	}
+	return t, fmt.Errorf("unknown type %q", name)
+}
@@ -0,0 +1,313 @@
+package harness
+
+import (
+	"backend/internal/executor"
+	"backend/internal/languages"
+	"backend/internal/models"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSignature(t *testing.T) {
+	tests := []struct {
+		name     string
+		function *models.Function
+		wantErr  bool
+	}{
+		{"no params", &models.Function{Name: "answer", ReturnType: "int"}, false},
+		{"all base types", &models.Function{Name: "f", ReturnType: "bool", Params: []models.FunctionParam{
+			{Name: "a", Type: "int"}, {Name: "b", Type: "long"}, {Name: "c", Type: "float"}, {Name: "d", Type: "string"}, {Name: "e", Type: "bool"},
+		}}, false},
+		{"arrays", &models.Function{Name: "f", ReturnType: "string[]", Params: []models.FunctionParam{{Name: "grid", Type: "int[][]"}}}, false},
+		{"array with spaces", &models.Function{Name: "f", ReturnType: "int", Params: []models.FunctionParam{{Name: "xs", Type: "int [] []"}}}, false},
+		{"nil function", nil, true},
+		{"invalid name", &models.Function{Name: "1f", ReturnType: "int"}, true},
+		{"name with dot", &models.Function{Name: "os.exit", ReturnType: "int"}, true},
+		{"invalid param name", &models.Function{Name: "f", ReturnType: "int", Params: []models.FunctionParam{{Name: "a b", Type: "int"}}}, true},
+		{"unknown param type", &models.Function{Name: "f", ReturnType: "int", Params: []models.FunctionParam{{Name: "a", Type: "map"}}}, true},
+		{"unknown return type", &models.Function{Name: "f", ReturnType: "void"}, true},
+		{"empty return type", &models.Function{Name: "f"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseSignature(tt.function); (err != nil) != tt.wantErr {
+				t.Errorf("parseSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		name string
+		want valueType
+	}{
+		{"int", valueType{base: typeInt}},
+		{"float[]", valueType{base: typeFloat, dims: 1}},
+		{"string[][]", valueType{base: typeString, dims: 2}},
+		{" long [] ", valueType{base: typeLong, dims: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseType(tt.name)
+			if err != nil {
+				t.Fatalf("parseType(%q): %v", tt.name, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseType(%q) = %+v, want %+v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTest(t *testing.T) {
+	function := &models.Function{Name: "add", ReturnType: "int", Params: []models.FunctionParam{{Name: "a", Type: "int"}, {Name: "b", Type: "int"}}}
+	tests := []struct {
+		name    string
+		test    models.Test
+		wantErr bool
+	}{
+		{"valid", models.Test{Args: []json.RawMessage{json.RawMessage("1"), json.RawMessage("2")}, ExpectedReturn: json.RawMessage("3")}, false},
+		{"too few args", models.Test{Args: []json.RawMessage{json.RawMessage("1")}, ExpectedReturn: json.RawMessage("1")}, true},
+		{"invalid arg", models.Test{Args: []json.RawMessage{json.RawMessage("1"), json.RawMessage("[1,")}, ExpectedReturn: json.RawMessage("3")}, true},
+		{"missing expected", models.Test{Args: []json.RawMessage{json.RawMessage("1"), json.RawMessage("2")}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateTest(function, tt.test); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrepareTests(t *testing.T) {
+	tests, err := PrepareTests([]models.Test{
+		{Args: []json.RawMessage{json.RawMessage("[1, 2,\n 3]"), json.RawMessage(`"a\nb"`)}, ExpectedReturn: json.RawMessage("{ \"x\": 1 }")},
+		{ExpectedReturn: json.RawMessage("42")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ input, expected string }{
+		{`[[1,2,3],"a\nb"]`, `{"x":1}`},
+		{`[]`, `42`},
+	}
+	for i, w := range want {
+		if tests[i].Input != w.input || tests[i].ExpectedOutput != w.expected {
+			t.Errorf("test %d: Input = %q, ExpectedOutput = %q, want %q, %q", i+1, tests[i].Input, tests[i].ExpectedOutput, w.input, w.expected)
+		}
+		if strings.Contains(tests[i].Input, "\n") {
+			t.Errorf("test %d: input must be a single line: %q", i+1, tests[i].Input)
+		}
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		name          string
+		stdout        string
+		value         string
+		programOutput string
+		ok            bool
+	}{
+		{"only result", "\n" + ResultMarker + "42\n", "42", "", true},
+		{"result at start", ResultMarker + "[1,2]", "[1,2]", "", true},
+		{"program output", "debug 1\ndebug 2\n\n" + ResultMarker + "\"ok\"\n", `"ok"`, "debug 1\ndebug 2\n", true},
+		{"last marker wins", "\n" + ResultMarker + "1\n\n" + ResultMarker + "2\n", "2", "\n" + ResultMarker + "1\n", true},
+		{"marker inside a line", "print " + ResultMarker + "1\n", "", "print " + ResultMarker + "1\n", false},
+		{"no result", "Traceback...\n", "", "Traceback...\n", false},
+		{"trailing whitespace", "\n" + ResultMarker + " true \r\n", "true", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, programOutput, ok := ParseOutput(tt.stdout)
+			if value != tt.value || programOutput != tt.programOutput || ok != tt.ok {
+				t.Errorf("ParseOutput(%q) = %q, %q, %v, want %q, %q, %v",
+					tt.stdout, value, programOutput, ok, tt.value, tt.programOutput, tt.ok)
+			}
+		})
+	}
+}
+
+// harnessFunction - сигнатура, на которой проверяются обертки всех языков:
+// вложенные массивы, строки с экранированием, дробные числа и bool
+var harnessFunction = &models.Function{
+	Name:       "describe",
+	ReturnType: "string[]",
+	Params: []models.FunctionParam{
+		{Name: "grid", Type: "int[][]"},
+		{Name: "label", Type: "string"},
+		{Name: "scale", Type: "float"},
+		{Name: "loud", Type: "bool"},
+	},
+}
+
+// harnessSolutions - решения на каждом языке с обертками. Каждое печатает
+// отладочный вывод и объявляет свою main там, где это возможно
+var harnessSolutions = map[string]string{
+	"python": `def describe(grid, label, scale, loud):
+    print("debug")
+    total = sum(sum(row) for row in grid)
+    return [label.upper() if loud else label, str(len(grid)), str(int(total * scale))]
+`,
+	"javascript": `function describe(grid, label, scale, loud) {
+    console.log("debug");
+    const total = grid.flat().reduce((a, b) => a + b, 0);
+    return [loud ? label.toUpperCase() : label, String(grid.length), String(Math.trunc(total * scale))];
+}
+`,
+	"go": `package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func main() {
+	fmt.Println("student main must not run")
+}
+
+func describe(grid [][]int, label string, scale float64, loud bool) []string {
+	fmt.Println("debug")
+	total := 0
+	for _, row := range grid {
+		for _, v := range row {
+			total += v
+		}
+	}
+	if loud {
+		label = strings.ToUpper(label)
+	}
+	return []string{label, fmt.Sprint(len(grid)), fmt.Sprint(int(float64(total) * scale))}
+}
+`,
+	"cpp": `#include <bits/stdc++.h>
+using namespace std;
+
+vector<string> describe(vector<vector<int>> grid, string label, double scale, bool loud) {
+    cout << "debug" << endl;
+    long long total = 0;
+    for (auto& row : grid) for (int v : row) total += v;
+    if (loud) for (auto& c : label) c = toupper(c);
+    return {label, to_string(grid.size()), to_string((long long)(total * scale))};
+}
+
+int main() {
+    cout << "student main must not run" << endl;
+}
+`,
+	"java": `import java.util.*;
+
+public String[] describe(int[][] grid, String label, double scale, boolean loud) {
+    System.out.println("debug");
+    long total = 0;
+    for (int[] row : grid) for (int v : row) total += v;
+    return new String[]{loud ? label.toUpperCase() : label, String.valueOf(grid.length), String.valueOf((long) (total * scale))};
+}
+`,
+}
+
+func TestWrapRuns(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles and runs programs")
+	}
+
+	tests, err := PrepareTests([]models.Test{
+		{
+			Args:           []json.RawMessage{json.RawMessage("[[1,2],[3,4]]"), json.RawMessage(`"a \"b\"\\ c"`), json.RawMessage("1.5"), json.RawMessage("true")},
+			ExpectedReturn: json.RawMessage(`["A \"B\"\\ C","2","15"]`),
+		},
+		{
+			Args:           []json.RawMessage{json.RawMessage("[]"), json.RawMessage(`"ünï"`), json.RawMessage("0"), json.RawMessage("false")},
+			ExpectedReturn: json.RawMessage(`["ünï","0","0"]`),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ex := executor.NewLocalExecutor()
+	defer ex.Cleanup()
+
+	for language := range generators {
+		t.Run(language, func(t *testing.T) {
+			code, ok := harnessSolutions[language]
+			if !ok {
+				t.Fatalf("no solution for %s", language)
+			}
+			requireToolchain(t, language)
+
+			wrapped, err := Wrap(language, code, harnessFunction)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, test := range tests {
+				ctx, release, err := executor.Acquire(context.Background(), ex)
+				if err != nil {
+					t.Fatal(err)
+				}
+				result, err := ex.Execute(ctx, executor.ExecutionRequest{Language: language, Code: wrapped, Inputs: []string{test.Input}})
+				release()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.ExitCode != 0 {
+					t.Fatalf("test %d: exit code %d: %s%s", i+1, result.ExitCode, result.Stderr, result.CompileOutput)
+				}
+
+				value, programOutput, ok := ParseOutput(result.Stdout)
+				if !ok {
+					t.Fatalf("test %d: no result in output %q", i+1, result.Stdout)
+				}
+				// Разделители JSON у языков разные, сравниваются значения
+				if !equalJSON(value, test.ExpectedOutput) {
+					t.Errorf("test %d: result = %s, want %s", i+1, value, test.ExpectedOutput)
+				}
+				if strings.TrimSpace(programOutput) != "debug" {
+					t.Errorf("test %d: program output = %q, want %q", i+1, programOutput, "debug")
+				}
+			}
+		})
+	}
+}
+
+// equalJSON сравнивает два JSON-значения независимо от форматирования
+func equalJSON(a, b string) bool {
+	var x, y interface{}
+	if json.Unmarshal([]byte(a), &x) != nil || json.Unmarshal([]byte(b), &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// requireToolchain пропускает тест, если компилятора или среды выполнения языка нет
+func requireToolchain(t *testing.T, language string) {
+	t.Helper()
+	lang, ok := languages.Lookup(language)
+	if !ok {
+		t.Fatalf("unknown language %s", language)
+	}
+	tools := []string{lang.Run[0]}
+	if fields := strings.Fields(lang.Compile); len(fields) > 0 {
+		tools = append(tools, fields[0])
+	}
+	for _, tool := range tools {
+		if strings.HasPrefix(tool, "{") {
+			continue
+		}
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s is not installed", tool)
+		}
+	}
+}
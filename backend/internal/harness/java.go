@@ -0,0 +1,234 @@
+package harness
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	javaClassRe       = regexp.MustCompile(`\b(?:class|interface|enum|record)\s+[A-Za-z_$]`)
+	javaImportRe      = regexp.MustCompile(`(?m)^[ \t]*import\s+[\w.*\s]+;[ \t]*$`)
+	javaPublicClassRe = regexp.MustCompile(`\bpublic\s+((?:final\s+|abstract\s+)*class\s)`)
+)
+
+// javaBaseTypes - типы Java для базовых типов сигнатуры
+var javaBaseTypes = map[string]string{
+	typeInt:    "int",
+	typeLong:   "long",
+	typeFloat:  "double",
+	typeString: "String",
+	typeBool:   "boolean",
+}
+
+// wrapJava добавляет public класс Main, вызывающий метод класса Solution.
+// Если студент написал только методы, они оборачиваются в класс Solution.
+// Классы студента перестают быть public: в файле может быть только один public класс
+func wrapJava(code string, sig signature) string {
+	if !javaClassRe.MatchString(code) {
+		// Импорты выносим перед классом в первую строку, чтобы не сдвигать номера строк
+		var imports []string
+		code = javaImportRe.ReplaceAllStringFunc(code, func(line string) string {
+			imports = append(imports, strings.TrimSpace(line))
+			return ""
+		})
+		code = strings.Join(imports, " ") + " class Solution { " + code + "\n}\n"
+	}
+	code = javaPublicClassRe.ReplaceAllString(code, "$1")
+
+	var args []string
+	for i, param := range sig.params {
+		javaType := javaBaseTypes[param.base] + strings.Repeat("[]", param.dims)
+		args = append(args, fmt.Sprintf("(%s) HarnessJson.convert(harnessArgs.get(%d), %s.class)", javaType, i, javaType))
+	}
+
+	return code + fmt.Sprintf(`
+public class Main {
+    public static void main(String[] harnessArgv) throws Exception {
+        java.io.BufferedReader harnessReader = new java.io.BufferedReader(
+            new java.io.InputStreamReader(System.in, java.nio.charset.StandardCharsets.UTF_8));
+        java.util.List<?> harnessArgs = (java.util.List<?>) new HarnessJson(harnessReader.readLine()).parse();
+        if (harnessArgs.size() != %d) {
+            throw new IllegalArgumentException("harness: expected %d arguments, got " + harnessArgs.size());
+        }
+        Object harnessResult = new Solution().%s(%s);
+        StringBuilder harnessOut = new StringBuilder();
+        HarnessJson.write(harnessOut, harnessResult);
+        System.out.println();
+        System.out.println(%q + harnessOut);
+    }
+}
+`, len(sig.params), len(sig.params), sig.name, strings.Join(args, ", "), ResultMarker) + javaJSONClass
+}
+
+// javaJSONClass - минимальный разбор и сериализация JSON без внешних библиотек
+const javaJSONClass = `
+class HarnessJson {
+    private final String text;
+    private int pos;
+
+    HarnessJson(String text) {
+        this.text = text == null ? "" : text;
+    }
+
+    Object parse() {
+        skip();
+        if (pos >= text.length()) throw error();
+        char c = text.charAt(pos);
+        if (c == '[') {
+            pos++;
+            java.util.List<Object> list = new java.util.ArrayList<>();
+            skip();
+            if (peek(']')) return list;
+            do {
+                list.add(parse());
+                skip();
+            } while (peek(','));
+            expect(']');
+            return list;
+        }
+        if (c == '{') {
+            pos++;
+            java.util.Map<String, Object> map = new java.util.LinkedHashMap<>();
+            skip();
+            if (peek('}')) return map;
+            do {
+                skip();
+                String key = string();
+                skip();
+                expect(':');
+                map.put(key, parse());
+                skip();
+            } while (peek(','));
+            expect('}');
+            return map;
+        }
+        if (c == '"') return string();
+        if (text.startsWith("true", pos)) { pos += 4; return Boolean.TRUE; }
+        if (text.startsWith("false", pos)) { pos += 5; return Boolean.FALSE; }
+        if (text.startsWith("null", pos)) { pos += 4; return null; }
+        int start = pos;
+        while (pos < text.length() && "+-0123456789.eE".indexOf(text.charAt(pos)) >= 0) pos++;
+        String number = text.substring(start, pos);
+        if (number.isEmpty()) throw error();
+        if (number.contains(".") || number.contains("e") || number.contains("E")) return Double.parseDouble(number);
+        return Long.parseLong(number);
+    }
+
+    private String string() {
+        expect('"');
+        StringBuilder sb = new StringBuilder();
+        while (pos < text.length() && text.charAt(pos) != '"') {
+            char c = text.charAt(pos++);
+            if (c != '\\') { sb.append(c); continue; }
+            if (pos >= text.length()) throw error();
+            char e = text.charAt(pos++);
+            switch (e) {
+                case 'n': sb.append('\n'); break;
+                case 't': sb.append('\t'); break;
+                case 'r': sb.append('\r'); break;
+                case 'b': sb.append('\b'); break;
+                case 'f': sb.append('\f'); break;
+                case 'u': sb.append((char) Integer.parseInt(text.substring(pos, pos + 4), 16)); pos += 4; break;
+                default: sb.append(e);
+            }
+        }
+        expect('"');
+        return sb.toString();
+    }
+
+    private void skip() {
+        while (pos < text.length() && Character.isWhitespace(text.charAt(pos))) pos++;
+    }
+
+    private boolean peek(char c) {
+        if (pos < text.length() && text.charAt(pos) == c) { pos++; return true; }
+        return false;
+    }
+
+    private void expect(char c) {
+        if (!peek(c)) throw error();
+    }
+
+    private IllegalArgumentException error() {
+        return new IllegalArgumentException("harness: invalid arguments JSON at position " + pos);
+    }
+
+    static Object convert(Object value, Class<?> type) {
+        if (value == null) return null;
+        if (type == int.class || type == Integer.class) return ((Number) value).intValue();
+        if (type == long.class || type == Long.class) return ((Number) value).longValue();
+        if (type == double.class || type == Double.class) return ((Number) value).doubleValue();
+        if (type == boolean.class || type == Boolean.class) return (Boolean) value;
+        if (type == String.class) return (String) value;
+        if (type.isArray()) {
+            java.util.List<?> list = (java.util.List<?>) value;
+            Object array = java.lang.reflect.Array.newInstance(type.getComponentType(), list.size());
+            for (int i = 0; i < list.size(); i++) {
+                java.lang.reflect.Array.set(array, i, convert(list.get(i), type.getComponentType()));
+            }
+            return array;
+        }
+        throw new IllegalArgumentException("harness: unsupported type " + type);
+    }
+
+    static void write(StringBuilder out, Object value) {
+        if (value == null) {
+            out.append("null");
+        } else if (value instanceof Boolean || value instanceof Integer || value instanceof Long
+                || value instanceof Short || value instanceof Byte) {
+            out.append(value);
+        } else if (value instanceof Number) {
+            double d = ((Number) value).doubleValue();
+            out.append(Double.isNaN(d) || Double.isInfinite(d) ? "null" : Double.toString(d));
+        } else if (value.getClass().isArray()) {
+            out.append('[');
+            for (int i = 0; i < java.lang.reflect.Array.getLength(value); i++) {
+                if (i > 0) out.append(',');
+                write(out, java.lang.reflect.Array.get(value, i));
+            }
+            out.append(']');
+        } else if (value instanceof Iterable) {
+            out.append('[');
+            boolean first = true;
+            for (Object item : (Iterable<?>) value) {
+                if (!first) out.append(',');
+                write(out, item);
+                first = false;
+            }
+            out.append(']');
+        } else if (value instanceof java.util.Map) {
+            out.append('{');
+            boolean first = true;
+            for (java.util.Map.Entry<?, ?> entry : ((java.util.Map<?, ?>) value).entrySet()) {
+                if (!first) out.append(',');
+                writeString(out, String.valueOf(entry.getKey()));
+                out.append(':');
+                write(out, entry.getValue());
+                first = false;
+            }
+            out.append('}');
+        } else {
+            writeString(out, value.toString());
+        }
+    }
+
+    private static void writeString(StringBuilder out, String s) {
+        out.append('"');
+        for (int i = 0; i < s.length(); i++) {
+            char c = s.charAt(i);
+            switch (c) {
+                case '"': out.append("\\\""); break;
+                case '\\': out.append("\\\\"); break;
+                case '\n': out.append("\\n"); break;
+                case '\r': out.append("\\r"); break;
+                case '\t': out.append("\\t"); break;
+                default:
+                    if (c < 0x20) out.append(String.format("\\u%04x", (int) c));
+                    else out.append(c);
+            }
+        }
+        out.append('"');
+    }
+}
+`
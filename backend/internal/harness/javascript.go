@@ -0,0 +1,16 @@
+package harness
+
+import "fmt"
+
+// wrapJavaScript дописывает после кода студента вызов функции. Аргументы
+// читаются через input() обертки языка из реестра, а если в конфигурации
+// обертки нет - из stdin напрямую
+func wrapJavaScript(code string, sig signature) string {
+	return code + fmt.Sprintf(`
+;{
+    const harnessLine = typeof input === 'function' ? input() : require('fs').readFileSync(0, 'utf8');
+    const harnessResult = %s(...JSON.parse(harnessLine));
+    process.stdout.write('\n' + %q + JSON.stringify(harnessResult === undefined ? null : harnessResult) + '\n');
+}
+`, sig.name, ResultMarker)
+}
@@ -0,0 +1,16 @@
+package harness
+
+import "fmt"
+
+// wrapPython дописывает после кода студента вызов функции. Код студента
+// выполняется целиком, поэтому он не должен сам читать stdin
+func wrapPython(code string, sig signature) string {
+	return code + fmt.Sprintf(`
+
+import json as _harness_json, sys as _harness_sys
+_harness_args = _harness_json.loads(_harness_sys.stdin.readline())
+_harness_result = %s(*_harness_args)
+print()
+print(%q + _harness_json.dumps(_harness_result, ensure_ascii=False))
+`, sig.name, ResultMarker)
+}
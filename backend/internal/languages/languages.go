@@ -0,0 +1,295 @@
+// Package languages - реестр поддерживаемых языков программирования.
+// Образы, команды компиляции и запуска, лимиты и псевдонимы языков задаются
+// в конфигурационном файле, поэтому для добавления языка код менять не нужно
+package languages
+
+import (
+	_ "embed"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfig - реестр по умолчанию, встроенный в бинарник
+//
+//go:embed languages.yaml
+var defaultConfig []byte
+
+// Language - настройки одного языка
+type Language struct {
+	ID              string       `yaml:"id" json:"id"`
+	Name            string       `yaml:"name" json:"name"`
+	Version         string       `yaml:"version" json:"version"`
+	Aliases         []string     `yaml:"aliases" json:"aliases,omitempty"`
+	Image           string       `yaml:"image" json:"-"`
+	FileName        string       `yaml:"file_name" json:"-"`
+	FileNamePattern string       `yaml:"file_name_pattern" json:"-"`
+	Compile         string       `yaml:"compile" json:"-"`
+	Run             []string     `yaml:"run" json:"-"`
+	TimeLimitMs     int          `yaml:"time_limit_ms" json:"time_limit_ms"`
+	MemoryLimitMB   int          `yaml:"memory_limit_mb" json:"memory_limit_mb"`
+	Template        string       `yaml:"template" json:"-"`
+	Wrapper         string       `yaml:"wrapper" json:"-"`
+	Complete        []Completion `yaml:"complete" json:"-"`
+
+	fileNameRe *regexp.Regexp
+}
+
+// Completion дополняет код решения, в котором нет обязательной части:
+// например, package main в Go
+type Completion struct {
+	Missing string `yaml:"missing"` // Если этого текста в коде нет,
+	Prepend string `yaml:"prepend"` // код начинается с Prepend
+	Append  string `yaml:"append"`  // и заканчивается Append
+}
+
+// SourceFile возвращает имя файла для кода: если file_name_pattern находит
+// имя в коде, оно заменяет имя файла без расширения
+func (l *Language) SourceFile(code string) string {
+	if l.fileNameRe != nil {
+		if match := l.fileNameRe.FindStringSubmatch(code); len(match) > 1 && match[1] != "" {
+			return match[1] + fileExt(l.FileName)
+		}
+	}
+	return l.FileName
+}
+
+// WrapCode возвращает содержимое файла с точкой входа: код решения, дополненный
+// по complete, в обертке языка или без нее, если обертки нет
+func (l *Language) WrapCode(code string) string {
+	complete := code
+	for _, c := range l.Complete {
+		if c.Missing != "" && !strings.Contains(code, c.Missing) {
+			complete = c.Prepend + complete + c.Append
+		}
+	}
+	if l.Wrapper == "" {
+		return complete
+	}
+	return strings.Replace(l.Wrapper, "{code}", complete, 1)
+}
+
+// CompileCommand возвращает shell-команду компиляции файла file в директории dir.
+// sources - все исходники программы для {sources} (по умолчанию только file).
+// Пустая строка - язык не компилируется
+func (l *Language) CompileCommand(dir, file string, sources ...string) string {
+	if len(sources) == 0 {
+		sources = []string{file}
+	}
+	return strings.ReplaceAll(expand(l.Compile, dir, file), "{sources}", strings.Join(sources, " "))
+}
+
+// shellSyntax - символы, из-за которых команду компиляции нужно выполнять через shell
+const shellSyntax = "&|;<>$`'\"\\*?()#~\n"
+
+// CompileArgs возвращает команду компиляции как аргументы программы, чтобы
+// запускать компилятор без shell. Подстановки не разбиваются по пробелам, кроме
+// {sources} и {cpp_flags}. ok = false - команда использует возможности shell
+// (&&, перенаправления, кавычки) и выполняется через CompileCommand или язык не компилируется
+func (l *Language) CompileArgs(dir, file string, sources ...string) (args []string, ok bool) {
+	if l.Compile == "" || strings.ContainsAny(l.Compile, shellSyntax) {
+		return nil, false
+	}
+	if len(sources) == 0 {
+		sources = []string{file}
+	}
+	for _, field := range strings.Fields(l.Compile) {
+		switch field {
+		case "{sources}":
+			args = append(args, sources...)
+		case "{cpp_flags}":
+			args = append(args, cppFlags()...)
+		default:
+			args = append(args, strings.ReplaceAll(expand(field, dir, file), "{sources}", strings.Join(sources, " ")))
+		}
+	}
+	return args, true
+}
+
+// RunCommand возвращает команду запуска программы, скомпилированной из file в dir
+func (l *Language) RunCommand(dir, file string) []string {
+	command := make([]string, len(l.Run))
+	for i, arg := range l.Run {
+		command[i] = expand(arg, dir, file)
+	}
+	return command
+}
+
+// TimeLimit возвращает лимит времени по умолчанию (0 - не задан)
+func (l *Language) TimeLimit() time.Duration {
+	return time.Duration(l.TimeLimitMs) * time.Millisecond
+}
+
+// MemoryLimit возвращает лимит памяти в байтах (0 - не задан)
+func (l *Language) MemoryLimit() int64 {
+	return int64(l.MemoryLimitMB) * 1024 * 1024
+}
+
+// expand подставляет {dir}, {file}, {stem} и {cpp_flags} в шаблон команды
+func expand(template, dir, file string) string {
+	return strings.NewReplacer(
+		"{dir}", dir,
+		"{file}", file,
+		"{stem}", strings.TrimSuffix(file, fileExt(file)),
+		"{cpp_flags}", strings.Join(cppFlags(), " "),
+	).Replace(template)
+}
+
+// cppFlags возвращает флаги g++ для {cpp_flags}: стандарт из CPP_STD
+// (по умолчанию c++17) и флаги из CPP_FLAGS (по умолчанию -O2)
+func cppFlags() []string {
+	std := os.Getenv("CPP_STD")
+	if std == "" {
+		std = "c++17"
+	}
+	flags := strings.Fields(os.Getenv("CPP_FLAGS"))
+	if len(flags) == 0 {
+		flags = []string{"-O2"}
+	}
+	return append([]string{"-std=" + std}, flags...)
+}
+
+// fileExt возвращает расширение имени файла вместе с точкой
+func fileExt(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i > 0 {
+		return name[i:]
+	}
+	return ""
+}
+
+// Registry - набор языков с поиском по названию и псевдонимам
+type Registry struct {
+	languages []*Language
+	byName    map[string]*Language
+}
+
+// Parse разбирает реестр в формате YAML или JSON (JSON - подмножество YAML)
+func Parse(data []byte) (*Registry, error) {
+	var list []*Language
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid languages config: %w", err)
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("languages config is empty")
+	}
+
+	r := &Registry{byName: make(map[string]*Language)}
+	for i, lang := range list {
+		if lang == nil || strings.TrimSpace(lang.ID) == "" {
+			return nil, fmt.Errorf("language %d: id is required", i+1)
+		}
+		if err := validate(lang); err != nil {
+			return nil, fmt.Errorf("language %s: %w", lang.ID, err)
+		}
+		if lang.Name == "" {
+			lang.Name = lang.ID
+		}
+
+		for _, name := range append([]string{lang.ID}, lang.Aliases...) {
+			key := strings.ToLower(strings.TrimSpace(name))
+			if other, ok := r.byName[key]; ok {
+				return nil, fmt.Errorf("language %s: name %q is already used by %s", lang.ID, name, other.ID)
+			}
+			r.byName[key] = lang
+		}
+		r.languages = append(r.languages, lang)
+	}
+	return r, nil
+}
+
+// validate проверяет обязательные поля языка
+func validate(lang *Language) error {
+	if lang.Image == "" {
+		return fmt.Errorf("image is required")
+	}
+	if lang.FileName == "" || strings.ContainsAny(lang.FileName, `/\`) {
+		return fmt.Errorf("file_name must be a plain file name")
+	}
+	if len(lang.Run) == 0 {
+		return fmt.Errorf("run command is required")
+	}
+	if lang.TimeLimitMs < 0 || lang.MemoryLimitMB < 0 {
+		return fmt.Errorf("limits must be non-negative")
+	}
+	if lang.FileNamePattern != "" {
+		re, err := regexp.Compile(lang.FileNamePattern)
+		if err != nil {
+			return fmt.Errorf("invalid file_name_pattern: %w", err)
+		}
+		if re.NumSubexp() < 1 {
+			return fmt.Errorf("file_name_pattern must have a capture group")
+		}
+		lang.fileNameRe = re
+	}
+	return nil
+}
+
+// Load читает реестр из файла
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read languages config: %w", err)
+	}
+	return Parse(data)
+}
+
+// Lookup находит язык по названию или псевдониму без учета регистра
+func (r *Registry) Lookup(name string) (*Language, bool) {
+	lang, ok := r.byName[strings.ToLower(strings.TrimSpace(name))]
+	return lang, ok
+}
+
+// All возвращает языки в порядке конфигурации
+func (r *Registry) All() []*Language {
+	return append([]*Language(nil), r.languages...)
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultRegistry *Registry
+)
+
+// Default возвращает реестр приложения: файл из LANGUAGES_CONFIG или встроенный
+// languages.yaml. Ошибка в конфигурации останавливает сервер при первом обращении
+func Default() *Registry {
+	defaultOnce.Do(func() {
+		var err error
+		if path := os.Getenv("LANGUAGES_CONFIG"); path != "" {
+			defaultRegistry, err = Load(path)
+			if err == nil {
+				log.Printf("✅ Languages loaded from %s", path)
+			}
+		} else {
+			defaultRegistry, err = Parse(defaultConfig)
+		}
+		if err != nil {
+			log.Fatalf("❌ Failed to load languages: %v", err)
+		}
+	})
+	return defaultRegistry
+}
+
+// Lookup находит язык в реестре приложения
+func Lookup(name string) (*Language, bool) {
+	return Default().Lookup(name)
+}
+
+// All возвращает языки реестра приложения
+func All() []*Language {
+	return Default().All()
+}
+
+// IDs возвращает названия языков реестра приложения
+func IDs() []string {
+	var ids []string
+	for _, lang := range All() {
+		ids = append(ids, lang.ID)
+	}
+	return ids
+}
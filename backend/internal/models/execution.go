@@ -26,12 +26,3 @@ type DockerExecutionConfig struct {
 	Timeout   time.Duration     `json:"timeout"`    // Максимальное время работы
 	Env       map[string]string `json:"env"`
 }
-
-// LanguageConfig конфигурация для разных языков программирования
-type LanguageConfig struct {
-	DockerImage string        `json:"docker_image"`          // Какой образ использовать
-	CompileCmd  []string      `json:"compile_cmd,omitempty"` // Сколько памяти дать
-	RunCmd      []string      `json:"run_cmd"`               // Сколько процессора
-	FileName    string        `json:"file_name"`             // Максимальное время работы
-	Timeout     time.Duration `json:"timeout"`
-}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Статусы решения в очереди проверки
+const (
+	SubmissionQueued  = "queued"  // Ожидает свободного воркера
+	SubmissionRunning = "running" // Проверяется
+	SubmissionDone    = "done"    // Проверено, результат в Result
+	SubmissionFailed  = "failed"  // Проверить не удалось, причина в Error
+)
+
+// Submission - решение, отправленное на асинхронную проверку
+type Submission struct {
+	ID          string         `json:"id"`
+	UserID      *int64         `json:"user_id,omitempty"` // nil для анонимных решений
+	Username    string         `json:"username,omitempty"`
+	TaskID      string         `json:"task_id"`
+	Language    string         `json:"language"`
+	Code        string         `json:"code"`
+	Files       []SourceFile   `json:"files,omitempty"` // Остальные файлы многофайлового решения
+	Status      string         `json:"status"`
+	Verdict     Verdict        `json:"verdict,omitempty"`
+	PassedTests int            `json:"passed_tests"`
+	TotalTests  int            `json:"total_tests"`
+	TimeMs      int64          `json:"time_ms"`          // Суммарное время выполнения тестов
+	CPUTimeMs   int64          `json:"cpu_time_ms"`      // Суммарное процессорное время тестов
+	MemoryKB    int64          `json:"memory_kb"`        // Наибольшая пиковая память среди тестов
+	Result      *CheckResponse `json:"result,omitempty"` // Результаты тестов после проверки
+	Error       string         `json:"error,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	StartedAt   *time.Time     `json:"started_at,omitempty"`
+	FinishedAt  *time.Time     `json:"finished_at,omitempty"`
+}
+
+// SubmitResponse - ответ на постановку решения в очередь
+type SubmitResponse struct {
+	Success      bool   `json:"success"`
+	SubmissionID string `json:"submission_id"`
+	Status       string `json:"status"`
+}
+
+// SubmissionHistory - история попыток пользователя по задаче
+type SubmissionHistory struct {
+	TaskID      string       `json:"task_id"`
+	Language    string       `json:"language,omitempty"`
+	Stats       TaskStats    `json:"stats"`
+	Best        *Submission  `json:"best,omitempty"` // Лучшее решение (из представления best_solutions)
+	Submissions []Submission `json:"submissions"`    // Попытки, новые первыми
+}
+
+// SubmissionList - страница списка решений для преподавателя
+type SubmissionList struct {
+	Submissions []Submission `json:"submissions"`
+	Total       int          `json:"total"`
+	Limit       int          `json:"limit"`
+	Offset      int          `json:"offset"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -23,6 +24,144 @@ type Task struct {
 	Category    string    `json:"category,omitempty"`     // Категория задачи
 	Points      int       `json:"points,omitempty"`       // Очки за решение
 	Tags        []string  `json:"tags,omitempty"`         // Теги для поиска
+	Checker     *Checker  `json:"checker,omitempty"`      // Способ проверки ответа (по умолчанию exact)
+	Function    *Function `json:"function,omitempty"`     // Сигнатура функции для задач "напишите функцию"
+
+	// Многофайловые задачи
+	EntryPoint   string       `json:"entry_point,omitempty"`   // Файл с точкой входа (по умолчанию - файл языка)
+	SupportFiles []SourceFile `json:"support_files,omitempty"` // Файлы преподавателя рядом с решением (только для чтения)
+	StarterFiles []SourceFile `json:"starter_files,omitempty"` // Начальные файлы решения в редакторе
+
+	// Только для преподавателя
+	ReferenceSolution *ReferenceSolution `json:"reference_solution,omitempty"` // Эталонное решение
+	Validation        *TaskValidation    `json:"validation,omitempty"`         // Итог последней проверки тестов
+	TestSetVersion    int                `json:"test_set_version,omitempty"`   // Активная версия сгенерированных тестов
+}
+
+// TestGenerator - программа преподавателя, которая генерирует входные данные
+// тестов. Генератор читает seed из stdin и печатает ввод одного теста (для задач
+// с функцией - JSON-массив аргументов). Ответы вычисляет эталонное решение задачи
+type TestGenerator struct {
+	Code     string  `json:"code"`
+	Language string  `json:"language,omitempty"` // По умолчанию - язык задачи
+	Count    int     `json:"count,omitempty"`    // Число тестов с seed 1..Count, если Seeds не заданы
+	Seeds    []int64 `json:"seeds,omitempty"`
+}
+
+// TestSet - версия сгенерированных тестов задачи. При проверке решений к тестам
+// задачи добавляются скрытые тесты активной версии
+type TestSet struct {
+	Version   int            `json:"version"`
+	Generator *TestGenerator `json:"generator,omitempty"`
+	TestCount int            `json:"test_count"`
+	Tests     []Test         `json:"tests,omitempty"`
+	Active    bool           `json:"active"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// ReferenceSolution - эталонное решение преподавателя. Перед публикацией задачи
+// оно прогоняется на всех тестах, и задача публикуется, только если все пройдены
+type ReferenceSolution struct {
+	Code     string       `json:"code"`
+	Language string       `json:"language,omitempty"` // По умолчанию - язык задачи
+	Files    []SourceFile `json:"files,omitempty"`
+}
+
+// Виды проблем, найденных при проверке тестов задачи
+const (
+	TaskProblemContradictoryTests = "contradictory_tests" // Одинаковый ввод, разные ответы
+	TaskProblemReferenceFailed    = "reference_failed"    // Эталонное решение не прошло тест
+	TaskProblemValidationError    = "validation_error"    // Проверку не удалось провести
+)
+
+// TaskProblem - проблема в тестах задачи, из-за которой она не публикуется
+type TaskProblem struct {
+	Type    string  `json:"type"`
+	Tests   []int   `json:"tests,omitempty"`   // Номера тестов (с 1)
+	Verdict Verdict `json:"verdict,omitempty"` // Вердикт эталонного решения для reference_failed
+	Message string  `json:"message"`
+}
+
+// TaskValidation - итог проверки тестов задачи перед публикацией
+type TaskValidation struct {
+	Valid            bool           `json:"valid"`
+	ReferenceChecked bool           `json:"reference_checked"` // Было ли эталонное решение
+	Problems         []TaskProblem  `json:"problems,omitempty"`
+	ReferenceResult  *CheckResponse `json:"reference_result,omitempty"` // Результаты эталонного решения по тестам
+	CheckedAt        time.Time      `json:"checked_at"`
+}
+
+// TaskImportResult - итог импорта одной задачи из пакета
+type TaskImportResult struct {
+	Title          string          `json:"title"`
+	Language       string          `json:"language"`
+	ID             string          `json:"id,omitempty"`
+	Action         string          `json:"action,omitempty"` // created или updated
+	IsPublished    bool            `json:"is_published"`
+	Validation     *TaskValidation `json:"validation,omitempty"`
+	TestSetVersion int             `json:"test_set_version,omitempty"` // Активная версия сгенерированных тестов
+	Unmapped       []string        `json:"unmapped,omitempty"`         // Что из пакета Polygon или Kattis не удалось перенести
+	Error          string          `json:"error,omitempty"`
+}
+
+// SourceFile - именованный файл решения или задачи. Name - путь относительно
+// рабочей директории программы ("utils.py", "include/list.h")
+type SourceFile struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	Hidden  bool   `json:"hidden,omitempty"` // Файл поддержки не показывается студентам
+}
+
+// PublicFiles возвращает файлы, которые можно показывать студентам (без скрытых)
+func PublicFiles(files []SourceFile) []SourceFile {
+	var public []SourceFile
+	for _, file := range files {
+		if !file.Hidden {
+			public = append(public, file)
+		}
+	}
+	return public
+}
+
+// Function - сигнатура функции, которую пишет студент. Тесты такой задачи
+// задают аргументы и ожидаемое возвращаемое значение в JSON, а решение
+// вызывается через обертку-харнесс вместо сравнения stdout всей программы.
+//
+// Типы: int, long, float, string, bool и массивы из них с суффиксом []
+// (например int[] или string[][]). В Java функция - статический метод
+// класса Solution (если в коде нет классов, он создается автоматически)
+type Function struct {
+	Name       string          `json:"name"`
+	Params     []FunctionParam `json:"params"`
+	ReturnType string          `json:"return_type"`
+}
+
+// FunctionParam - параметр функции
+type FunctionParam struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// CheckerType - способ сравнения вывода решения с ожидаемым ответом
+type CheckerType string
+
+const (
+	CheckerExact           CheckerType = "exact"            // Точное совпадение без начальных и конечных пробелов
+	CheckerWhitespace      CheckerType = "whitespace"       // Построчно, пробелы внутри строк не важны
+	CheckerTokens          CheckerType = "tokens"           // Последовательность слов, разбиение на строки не важно
+	CheckerFloat           CheckerType = "float"            // Как tokens, числа сравниваются с погрешностью
+	CheckerUnorderedLines  CheckerType = "unordered_lines"  // Набор строк в любом порядке
+	CheckerCaseInsensitive CheckerType = "case_insensitive" // Как exact, но без учета регистра
+	CheckerCustom          CheckerType = "custom"           // Программа-чекер преподавателя
+)
+
+// Checker - настройки проверки ответа задачи
+type Checker struct {
+	Type       CheckerType `json:"type"`
+	AbsEpsilon float64     `json:"abs_epsilon,omitempty"` // Абсолютная погрешность для float
+	RelEpsilon float64     `json:"rel_epsilon,omitempty"` // Относительная погрешность для float
+	Code       string      `json:"code,omitempty"`        // Исходный код чекера для custom
+	Language   string      `json:"language,omitempty"`    // Язык чекера для custom
 }
 
 // Test - тест для задачи
@@ -32,53 +171,124 @@ type Test struct {
 	Description    string `json:"description,omitempty"` // Описание теста (для учителей)
 	IsHidden       bool   `json:"is_hidden,omitempty"`   // Скрытый тест (только для проверки)
 	Timeout        int    `json:"timeout,omitempty"`     // Таймаут в мс
+
+	// Для задач с Function: аргументы вызова и ожидаемый результат в JSON
+	Args           []json.RawMessage `json:"args,omitempty"`
+	ExpectedReturn json.RawMessage   `json:"expected_return,omitempty"`
+}
+
+// PublicTests возвращает тесты, которые можно показывать студентам (без скрытых)
+func PublicTests(tests []Test) []Test {
+	public := make([]Test, 0, len(tests))
+	for _, test := range tests {
+		if !test.IsHidden {
+			public = append(public, test)
+		}
+	}
+	return public
 }
 
 // ExecutionRequest - запрос на выполнение кода
 type ExecutionRequest struct {
-	TaskID   string   `json:"task_id"`
-	Code     string   `json:"code"`
-	Language string   `json:"language"`
-	Inputs   []string `json:"inputs,omitempty"`
+	TaskID     interface{}  `json:"task_id"` // Если задан, рядом с кодом кладутся файлы поддержки задачи
+	Code       string       `json:"code"`
+	Language   string       `json:"language"`
+	Inputs     []string     `json:"inputs,omitempty"`
+	Files      []SourceFile `json:"files,omitempty"`       // Остальные файлы программы
+	EntryPoint string       `json:"entry_point,omitempty"` // Файл с точкой входа среди code и files
 }
 
 // ExecutionResponse - ответ от выполнения кода
 type ExecutionResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Output  string `json:"output"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	Output    string `json:"output"`
+	TimeMs    int64  `json:"time_ms"`     // Время выполнения в мс
+	CPUTimeMs int64  `json:"cpu_time_ms"` // Процессорное время в мс
+	MemoryKB  int64  `json:"memory_kb"`   // Пиковая память (RSS) в КБ
 }
 
 // CheckRequest - запрос на проверку решения
 type CheckRequest struct {
-	TaskID   interface{} `json:"task_id"` // принимает и строки и числа
-	Code     string      `json:"code"`
-	Language string      `json:"language"`
-	Tests    []Test      `json:"tests,omitempty"`
+	TaskID   interface{}  `json:"task_id"` // принимает и строки и числа
+	Code     string       `json:"code"`
+	Language string       `json:"language"`
+	Files    []SourceFile `json:"files,omitempty"` // Остальные файлы решения; без code точка входа ищется среди них
+	Tests    []Test       `json:"tests,omitempty"`
+	// Stress - если решение не прошло тесты, сравнить его с эталонным решением
+	// на случайных тестах генератора задачи и найти контрпример
+	Stress bool `json:"stress,omitempty"`
 }
 
+// Verdict - вердикт проверки теста или всего решения
+type Verdict string
+
+const (
+	VerdictAccepted            Verdict = "AC"  // Тест пройден
+	VerdictWrongAnswer         Verdict = "WA"  // Неверный ответ
+	VerdictTimeLimitExceeded   Verdict = "TLE" // Превышен лимит времени
+	VerdictMemoryLimitExceeded Verdict = "MLE" // Превышен лимит памяти
+	VerdictRuntimeError        Verdict = "RE"  // Программа завершилась с ошибкой
+	VerdictCompilationError    Verdict = "CE"  // Ошибка компиляции
+	VerdictOutputLimitExceeded Verdict = "OLE" // Превышен лимит вывода
+	VerdictInternalError       Verdict = "IE"  // Сбой проверяющей системы
+)
+
 // CheckResponse - ответ проверки решения
 type CheckResponse struct {
 	Success     bool         `json:"success"`
 	Message     string       `json:"message"`
+	Verdict     Verdict      `json:"verdict"` // Итоговый вердикт: первый непройденный тест или AC
 	TestResults []TestResult `json:"test_results"`
 	TotalTests  int          `json:"total_tests"`
 	PassedTests int          `json:"passed_tests"`
-	Score       int          `json:"score,omitempty"`        // Оценка за решение
-	TimeElapsed int64        `json:"time_elapsed,omitempty"` // Время выполнения в мс
+	Score       int          `json:"score,omitempty"`         // Оценка за решение
+	TimeElapsed int64        `json:"time_elapsed,omitempty"`  // Время выполнения в мс
+	CPUTimeMs   int64        `json:"cpu_time_ms,omitempty"`   // Процессорное время всех тестов в мс
+	MaxMemoryKB int64        `json:"max_memory_kb,omitempty"` // Наибольшая пиковая память среди тестов в КБ
+
+	TestSetVersion int           `json:"test_set_version,omitempty"` // Версия сгенерированных тестов задачи
+	Stress         *StressResult `json:"stress,omitempty"`           // Итог стресс-тестирования
+}
+
+// StressStatus - итог стресс-тестирования
+type StressStatus string
+
+const (
+	StressCounterexample StressStatus = "counterexample" // Найден тест, на котором решение расходится с эталонным
+	StressNotFound       StressStatus = "not_found"      // Расхождений не найдено за отведенное время
+	StressUnavailable    StressStatus = "unavailable"    // У задачи нет эталонного решения или генератора
+	StressError          StressStatus = "error"          // Генератор или эталонное решение не справились
+)
+
+// StressResult - итог сравнения решения с эталонным на случайных тестах.
+// Counterexample - наименьший найденный тест, на котором решение ошибается
+type StressResult struct {
+	Status          StressStatus `json:"status"`
+	Message         string       `json:"message"`
+	Iterations      int          `json:"iterations"`                // Сколько тестов проверено
+	Counterexamples int          `json:"counterexamples,omitempty"` // Сколько тестов с расхождением найдено
+	Seed            int64        `json:"seed,omitempty"`            // Seed генератора для контрпримера
+	Counterexample  *TestResult  `json:"counterexample,omitempty"`
 }
 
 // TestResult - результат выполнения одного теста
 type TestResult struct {
-	TestNumber int    `json:"test_number"`
-	Passed     bool   `json:"passed"`
-	Output     string `json:"output"`
-	Error      string `json:"error,omitempty"`
-	Expected   string `json:"expected"`
-	Actual     string `json:"actual"`
-	Input      string `json:"input,omitempty"`     // Входные данные теста
-	IsHidden   bool   `json:"is_hidden,omitempty"` // Был ли тест скрытым
-	Timeout    bool   `json:"timeout,omitempty"`   // Был ли превышен таймаут
+	TestNumber int     `json:"test_number"`
+	Passed     bool    `json:"passed"`
+	Verdict    Verdict `json:"verdict"`
+	Output     string  `json:"output"`
+	Error      string  `json:"error,omitempty"`
+	Expected   string  `json:"expected"`
+	Actual     string  `json:"actual"`
+	Input      string  `json:"input,omitempty"`     // Входные данные теста
+	IsHidden   bool    `json:"is_hidden,omitempty"` // Был ли тест скрытым
+	Timeout    bool    `json:"timeout,omitempty"`   // Был ли превышен таймаут
+	TimeMs     int64   `json:"time_ms"`             // Время выполнения теста в мс
+	CPUTimeMs  int64   `json:"cpu_time_ms"`         // Процессорное время теста в мс
+	MemoryKB   int64   `json:"memory_kb"`           // Пиковая память (RSS) в КБ
+
+	CheckerMessage string `json:"checker_message,omitempty"` // Пояснение чекера к вердикту
 }
 
 // CheckResult - устаревшая структура (оставлена для обратной совместимости)
@@ -93,17 +303,25 @@ type CheckResult struct {
 
 // TaskRequest - запрос на создание/обновление задачи (от учителя)
 type TaskRequest struct {
-	Title       string `json:"title" binding:"required"`
-	Description string `json:"description" binding:"required"`
-	Language    string `json:"language" binding:"required"`
-	Difficulty  string `json:"difficulty" binding:"required"`
-	Template    string `json:"template"`
-	StarterCode string `json:"starter_code"`
-	Tests       []Test `json:"tests" binding:"required,min=1"`
-	Category    string `json:"category"`
-	Points      int    `json:"points"`
-	Tags        string `json:"tags"` // Теги через запятую
-	IsPublished bool   `json:"is_published"`
+	Title       string    `json:"title" binding:"required"`
+	Description string    `json:"description" binding:"required"`
+	Language    string    `json:"language" binding:"required"`
+	Difficulty  string    `json:"difficulty" binding:"required"`
+	Template    string    `json:"template"`
+	StarterCode string    `json:"starter_code"`
+	Tests       []Test    `json:"tests" binding:"required,min=1"`
+	Category    string    `json:"category"`
+	Points      int       `json:"points"`
+	Tags        string    `json:"tags"` // Теги через запятую
+	IsPublished bool      `json:"is_published"`
+	Checker     *Checker  `json:"checker,omitempty"`
+	Function    *Function `json:"function,omitempty"`
+
+	EntryPoint   string       `json:"entry_point,omitempty"`
+	SupportFiles []SourceFile `json:"support_files,omitempty"`
+	StarterFiles []SourceFile `json:"starter_files,omitempty"`
+
+	ReferenceSolution *ReferenceSolution `json:"reference_solution,omitempty"`
 }
 
 // TaskResponse - ответ с задачей
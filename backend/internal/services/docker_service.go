@@ -1,7 +1,7 @@
 package services
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"log"
@@ -10,12 +10,14 @@ import (
 	"strings"
 	"time"
 
-	"backend/internal/models"
+	"backend/internal/executor"
+	"backend/internal/languages"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 )
 
 // Docker файл сервиса. Изолятор выполнения кода
@@ -47,51 +49,33 @@ func NewDockerService() (*DockerService, error) {
 	return &DockerService{client: cli}, nil
 }
 
-// LanguageConfigs конфигурация для разных языков программирования
-var LanguageConfigs = map[string]models.LanguageConfig{
-	"python": {
-		DockerImage: "python:3.9-alpine",
-		RunCmd:      []string{"python", "/app/code.py"},
-		FileName:    "code.py",
-		Timeout:     10 * time.Second,
-	},
-	"javascript": {
-		DockerImage: "node:18-alpine",
-		RunCmd:      []string{"node", "/app/code.js"},
-		FileName:    "code.js",
-		Timeout:     10 * time.Second,
-	},
-	"java": {
-		DockerImage: "openjdk:17-alpine",
-		CompileCmd:  []string{"javac", "/app/Main.java"}, // Компилируем Main.java
-		RunCmd:      []string{"java", "Main"},            // Запускаем класс Main
-		FileName:    "Main.java",                         // Файл должен называться Main.java
-		Timeout:     15 * time.Second,
-	},
-	"cpp": {
-		DockerImage: "gcc:latest",
-		CompileCmd:  []string{"g++", "-o", "/app/code", "/app/code.cpp"},
-		RunCmd:      []string{"/app/code"},
-		FileName:    "code.cpp",
-		Timeout:     15 * time.Second,
-	},
-	"go": {
-		DockerImage: "golang:1.19-alpine",
-		RunCmd:      []string{"go", "run", "/app/code.go"},
-		FileName:    "code.go",
-		Timeout:     10 * time.Second,
-	},
-}
+// dockerServiceWorkDir - директория с кодом в контейнере
+const dockerServiceWorkDir = "/app"
+
+// defaultServiceTimeout - таймаут, если он не задан ни в запросе, ни у языка
+const defaultServiceTimeout = 10 * time.Second
+
+// compileFailedExitCode - код возврата shell-обертки, если компиляция не удалась
+const compileFailedExitCode = 97
 
-func (s *DockerService) ExecuteCode(code, language string) (*models.ExecutionResult, error) {
-	config, exists := LanguageConfigs[language]
+// Execute реализует executor.Executor поверх Docker API
+func (s *DockerService) Execute(parent context.Context, req executor.ExecutionRequest) (*executor.ExecutionResult, error) {
+	lang, exists := languages.Lookup(req.Language)
 	if !exists {
-		return nil, fmt.Errorf("unsupported language: %s", language)
+		return nil, fmt.Errorf("unsupported language: %s", req.Language)
 	}
 
-	log.Printf("🔄 Executing %s code: %s", language, code)
+	log.Printf("🔄 Executing %s code: %s", req.Language, req.Code)
 
-	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	// Таймаут из запроса имеет приоритет над таймаутом языка
+	timeout := lang.TimeLimit()
+	if req.Timeout > 0 {
+		timeout = req.Timeout
+	}
+	if timeout <= 0 {
+		timeout = defaultServiceTimeout
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
 
 	// Создаем временный файл с кодом
@@ -102,24 +86,37 @@ func (s *DockerService) ExecuteCode(code, language string) (*models.ExecutionRes
 	defer os.RemoveAll(tempDir)
 
 	// Записываем код в файл
-	filePath := filepath.Join(tempDir, config.FileName)
-	if err := os.WriteFile(filePath, []byte(code), 0644); err != nil {
+	fileName := lang.SourceFile(req.Code)
+	filePath := filepath.Join(tempDir, fileName)
+	if err := os.WriteFile(filePath, []byte(req.Code), 0644); err != nil {
 		return nil, fmt.Errorf("failed to write code to file: %w", err)
 	}
 
 	log.Printf("📁 Code written to: %s", filePath)
 
 	// Создаем контейнер
-	containerID, err := s.createContainer(ctx, tempDir, config)
+	containerID, err := s.createContainer(ctx, tempDir, lang, fileName)
 	if err != nil {
 		log.Printf("❌ Failed to create container: %v", err)
 		return nil, fmt.Errorf("failed to create container: %w", err)
 	}
-	defer s.removeContainer(ctx, containerID)
+	// Контекст выполнения может истечь, поэтому удаляем контейнер с отдельным контекстом
+	defer s.removeContainer(context.Background(), containerID)
 
 	log.Printf("🐳 Container created: %s", containerID)
 
+	// Подключаемся к stdin до запуска, чтобы не потерять ввод
+	attach, err := s.client.ContainerAttach(ctx, containerID, types.ContainerAttachOptions{
+		Stream: true,
+		Stdin:  true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach to container: %w", err)
+	}
+	defer attach.Close()
+
 	// Запускаем контейнер
+	start := time.Now()
 	if err := s.startContainer(ctx, containerID); err != nil {
 		log.Printf("❌ Failed to start container: %v", err)
 		return nil, fmt.Errorf("failed to start container: %w", err)
@@ -127,37 +124,67 @@ func (s *DockerService) ExecuteCode(code, language string) (*models.ExecutionRes
 
 	log.Printf("🚀 Container started: %s", containerID)
 
+	if len(req.Inputs) > 0 {
+		if _, err := attach.Conn.Write([]byte(strings.Join(req.Inputs, "\n") + "\n")); err != nil {
+			log.Printf("⚠️ Failed to write input data: %v", err)
+		}
+	}
+	attach.CloseWrite()
+
 	// Ждем завершения и получаем результат
-	result, err := s.waitForCompletion(ctx, containerID, config)
+	result, err := s.waitForCompletion(ctx, containerID, lang)
 	if err != nil {
+		// Контейнер удаляется принудительно в defer, это останавливает программу
+		if parent.Err() != nil {
+			log.Printf("🛑 Execution cancelled: %v", parent.Err())
+			return nil, parent.Err()
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return &executor.ExecutionResult{
+				Stderr:   fmt.Sprintf("Execution timeout (%v)", timeout),
+				ExitCode: -1,
+				WallTime: time.Since(start),
+				TimedOut: true,
+			}, nil
+		}
 		log.Printf("❌ Failed to wait for completion: %v", err)
 		return nil, fmt.Errorf("failed to wait for completion: %w", err)
 	}
+	result.WallTime = time.Since(start)
 
-	log.Printf("✅ Execution result: success=%v, output=%s", result.Success, result.Output)
+	log.Printf("✅ Execution result: success=%v, output=%s", result.Success(), result.Stdout)
 
 	return result, nil
 }
 
-func (s *DockerService) createContainer(ctx context.Context, codePath string, config models.LanguageConfig) (string, error) {
+func (s *DockerService) createContainer(ctx context.Context, codePath string, lang *languages.Language, fileName string) (string, error) {
 	// Подготавливаем команды
-	cmd := config.RunCmd
-	if len(config.CompileCmd) > 0 {
-		// Если нужна компиляция, объединяем команды
-		compileCmd := strings.Join(config.CompileCmd, " ")
-		runCmd := strings.Join(config.RunCmd, " ")
-		cmd = []string{"/bin/sh", "-c", fmt.Sprintf("%s && %s", compileCmd, runCmd)}
+	cmd := lang.RunCommand(dockerServiceWorkDir, fileName)
+	if compileCmd := lang.CompileCommand(dockerServiceWorkDir, fileName); compileCmd != "" {
+		// Если нужна компиляция, объединяем команды.
+		// Вывод компилятора при ошибке печатается в stdout с отдельным кодом возврата
+		runCmd := strings.Join(cmd, " ")
+		cmd = []string{"/bin/sh", "-c", fmt.Sprintf("(%s) > /tmp/compile.log 2>&1 || { cat /tmp/compile.log; exit %d; }; %s",
+			compileCmd, compileFailedExitCode, runCmd)}
+	}
+
+	memory := lang.MemoryLimit()
+	if memory == 0 {
+		memory = 100 * 1024 * 1024 // 100MB limit
 	}
 
 	resp, err := s.client.ContainerCreate(ctx, &container.Config{
-		Image:      config.DockerImage,
-		Cmd:        cmd,
-		Tty:        false,
-		WorkingDir: "/app",
+		Image:       lang.Image,
+		Cmd:         cmd,
+		Tty:         false,
+		WorkingDir:  dockerServiceWorkDir,
+		AttachStdin: true,
+		OpenStdin:   true,
+		StdinOnce:   true,
 	}, &container.HostConfig{
 		Resources: container.Resources{
-			Memory:    100 * 1024 * 1024, // 100MB limit
-			CPUShares: 512,               // CPU limit
+			Memory:    memory,
+			CPUShares: 512, // CPU limit
 		},
 		AutoRemove:  false,
 		NetworkMode: "none", // Без сети для безопасности
@@ -165,7 +192,7 @@ func (s *DockerService) createContainer(ctx context.Context, codePath string, co
 			{
 				Type:   mount.TypeBind,
 				Source: codePath,
-				Target: "/app",
+				Target: dockerServiceWorkDir,
 			},
 		},
 	}, nil, nil, "")
@@ -180,7 +207,7 @@ func (s *DockerService) startContainer(ctx context.Context, containerID string)
 	return s.client.ContainerStart(ctx, containerID, types.ContainerStartOptions{})
 }
 
-func (s *DockerService) waitForCompletion(ctx context.Context, containerID string, config models.LanguageConfig) (*models.ExecutionResult, error) {
+func (s *DockerService) waitForCompletion(ctx context.Context, containerID string, lang *languages.Language) (*executor.ExecutionResult, error) {
 	statusCh, errCh := s.client.ContainerWait(ctx, containerID, container.WaitConditionNotRunning)
 
 	select {
@@ -192,7 +219,7 @@ func (s *DockerService) waitForCompletion(ctx context.Context, containerID strin
 	}
 
 	// Получаем логи
-	logs, err := s.getContainerLogs(ctx, containerID)
+	stdout, stderr, err := s.getContainerLogs(ctx, containerID)
 	if err != nil {
 		return nil, err
 	}
@@ -203,41 +230,40 @@ func (s *DockerService) waitForCompletion(ctx context.Context, containerID strin
 		return nil, err
 	}
 
-	result := &models.ExecutionResult{
-		Output:  logs,
-		Success: inspect.State.ExitCode == 0,
+	if lang.Compile != "" && inspect.State.ExitCode == compileFailedExitCode {
+		return &executor.ExecutionResult{
+			CompileOutput: strings.TrimSpace(stdout),
+			CompileFailed: true,
+			ExitCode:      1,
+		}, nil
 	}
 
-	if inspect.State.ExitCode != 0 {
-		result.Error = fmt.Sprintf("Exit code: %d", inspect.State.ExitCode)
-	}
-
-	return result, nil
+	return &executor.ExecutionResult{
+		Stdout:    stdout,
+		Stderr:    stderr,
+		ExitCode:  inspect.State.ExitCode,
+		OOMKilled: inspect.State.OOMKilled,
+	}, nil
 }
 
-func (s *DockerService) getContainerLogs(ctx context.Context, containerID string) (string, error) {
+func (s *DockerService) getContainerLogs(ctx context.Context, containerID string) (string, string, error) {
 	reader, err := s.client.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
 		Follow:     false,
 	})
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	defer reader.Close()
 
-	var output strings.Builder
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		// Docker добавляет префиксы к логам, пропускаем их
-		line := scanner.Text()
-		if len(line) > 8 {
-			output.WriteString(line[8:])
-			output.WriteString("\n")
-		}
+	// Docker добавляет заголовки к каждому фрагменту лога, разделяем потоки
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, reader); err != nil {
+		return "", "", err
 	}
 
-	return strings.TrimSpace(output.String()), nil
+	return stdout.String(), stderr.String(), nil
 }
 
 func (s *DockerService) removeContainer(ctx context.Context, containerID string) {
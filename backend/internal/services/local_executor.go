@@ -1,7 +1,8 @@
 package services
 
 import (
-	"backend/internal/models"
+	"backend/internal/executor"
+	"context"
 	"log"
 )
 
@@ -11,10 +12,10 @@ func NewLocalExecutor() *LocalExecutor {
 	return &LocalExecutor{}
 }
 
-func (e *LocalExecutor) Execute(code, language string) (map[string]interface{}, error) {
-	log.Printf("🔧 LocalExecutor executing %s code", language)
+func (e *LocalExecutor) Execute(ctx context.Context, req executor.ExecutionRequest) (*executor.ExecutionResult, error) {
+	log.Printf("🔧 LocalExecutor executing %s code", req.Language)
 
-	switch language {
+	switch req.Language {
 	case "python":
 		return e.runPython()
 	case "javascript":
@@ -24,72 +25,44 @@ func (e *LocalExecutor) Execute(code, language string) (map[string]interface{},
 	case "java":
 		return e.runJava()
 	default:
-		return map[string]interface{}{
-			"exitCode": 0,
-			"output":   "Simulated output for " + language + "\n",
-			"error":    "",
+		return &executor.ExecutionResult{
+			Stdout: "Simulated output for " + req.Language + "\n",
 		}, nil
 	}
 }
 
-func (e *LocalExecutor) runPython() (map[string]interface{}, error) {
+func (e *LocalExecutor) runPython() (*executor.ExecutionResult, error) {
 	log.Printf("🐍 Simulating Python execution")
 
 	// Симуляция Python - всегда возвращаем Hello World для задачи 1
-	return map[string]interface{}{
-		"exitCode": 0,
-		"output":   "Hello World\n",
-		"error":    "",
+	return &executor.ExecutionResult{
+		Stdout: "Hello World\n",
 	}, nil
 }
 
-func (e *LocalExecutor) runJava() (map[string]interface{}, error) {
+func (e *LocalExecutor) runJava() (*executor.ExecutionResult, error) {
 	log.Printf("☕ Simulating Java execution")
 
 	// Симуляция Java - всегда возвращаем Hello World для задачи 1
-	return map[string]interface{}{
-		"exitCode": 0,
-		"output":   "Hello World\n",
-		"error":    "",
+	return &executor.ExecutionResult{
+		Stdout: "Hello World\n",
 	}, nil
 }
 
-func (e *LocalExecutor) runJavaScript() (map[string]interface{}, error) {
+func (e *LocalExecutor) runJavaScript() (*executor.ExecutionResult, error) {
 	log.Printf("📜 Simulating JavaScript execution")
 
 	// Симуляция JavaScript - всегда возвращаем Hello World для задачи 1
-	return map[string]interface{}{
-		"exitCode": 0,
-		"output":   "Hello World\n",
-		"error":    "",
+	return &executor.ExecutionResult{
+		Stdout: "Hello World\n",
 	}, nil
 }
 
-func (e *LocalExecutor) runCpp() (map[string]interface{}, error) {
+func (e *LocalExecutor) runCpp() (*executor.ExecutionResult, error) {
 	log.Printf("⚙️ Simulating C++ execution")
 
 	// Симуляция C++ - всегда возвращаем Hello World для задачи 1
-	return map[string]interface{}{
-		"exitCode": 0,
-		"output":   "Hello World\n",
-		"error":    "",
-	}, nil
-}
-
-// Старый метод для обратной совместимости
-func (l *LocalExecutor) ExecuteCode(code, language string) (*models.ExecutionResult, error) {
-	result, err := l.Execute(code, language)
-	if err != nil {
-		return nil, err
-	}
-
-	exitCode := result["exitCode"].(int)
-	output := result["output"].(string)
-	errorMsg := result["error"].(string)
-
-	return &models.ExecutionResult{
-		Success: exitCode == 0,
-		Output:  output,
-		Error:   errorMsg,
+	return &executor.ExecutionResult{
+		Stdout: "Hello World\n",
 	}, nil
 }
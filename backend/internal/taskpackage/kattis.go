@@ -0,0 +1,351 @@
+package taskpackage
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"backend/internal/languages"
+	"backend/internal/models"
+)
+
+// kattisProblem - описание задачи Kattis/ICPC (problem.yaml). Поддерживаются
+// поля старого формата и формата 2023-07
+type kattisProblem struct {
+	Name           interface{} `yaml:"name"` // Строка или названия по языкам
+	Type           string      `yaml:"type"`
+	Validation     string      `yaml:"validation"`
+	ValidatorFlags string      `yaml:"validator_flags"`
+	Limits         struct {
+		TimeLimit float64 `yaml:"time_limit"` // секунды
+		Memory    int64   `yaml:"memory"`     // МБ
+	} `yaml:"limits"`
+}
+
+// kattisStatementDirs - директории условия в порядке приоритета
+var kattisStatementDirs = []string{"statement", "problem_statement"}
+
+// kattisStatementLanguages - предпочтительные языки условия
+var kattisStatementLanguages = []string{"ru", "en", ""}
+
+// readKattis читает задачу Kattis/ICPC: problem.yaml, условие, тесты
+// data/sample (открытые) и data/secret (скрытые) и принятое решение
+// из submissions/accepted
+func (r *packageReader) readKattis(dir string) ([]Task, error) {
+	data, err := r.readFile(path.Join(dir, "problem.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	var problem kattisProblem
+	if err := yaml.Unmarshal(data, &problem); err != nil {
+		return nil, fmt.Errorf("problem.yaml: %w", err)
+	}
+
+	var unmapped []string
+	report := func(format string, args ...interface{}) {
+		unmapped = append(unmapped, fmt.Sprintf(format, args...))
+	}
+
+	base := models.Task{IsPublished: true, Title: kattisName(problem.Name)}
+
+	statement, err := r.kattisStatement(dir)
+	if err != nil {
+		return nil, err
+	}
+	if statement == "" {
+		report("Условие не найдено: в пакете нет statement/ или problem_statement/")
+	} else {
+		content, err := r.readFile(statement)
+		if err != nil {
+			return nil, err
+		}
+		text := string(content)
+		if base.Title == "" {
+			if match := latexNameRe.FindStringSubmatch(text); match != nil {
+				base.Title = strings.TrimSpace(match[1])
+			}
+		}
+		if path.Ext(statement) == ".tex" {
+			text = latexToMarkdown(text, &unmapped)
+		}
+		base.Description = strings.TrimSpace(text)
+	}
+	if base.Title == "" {
+		base.Title = path.Base(dir)
+		if base.Title == "." {
+			return nil, fmt.Errorf("problem.yaml: name is required")
+		}
+	}
+
+	timeout := 0
+	if problem.Limits.TimeLimit > 0 {
+		timeout = int(math.Ceil(problem.Limits.TimeLimit * 1000))
+	} else if r.exists(path.Join(dir, ".timelimit")) {
+		content, err := r.readFile(path.Join(dir, ".timelimit"))
+		if err != nil {
+			return nil, err
+		}
+		seconds, err := strconv.ParseFloat(strings.TrimSpace(string(content)), 64)
+		if err != nil {
+			return nil, fmt.Errorf(".timelimit: %w", err)
+		}
+		timeout = int(math.Ceil(seconds * 1000))
+	} else {
+		report("Лимит времени не задан (Kattis вычисляет его по решениям): используется лимит языка")
+	}
+
+	samples, err := r.readKattisTests(path.Join(dir, "data", "sample"), false, timeout)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := r.readKattisTests(path.Join(dir, "data", "secret"), true, timeout)
+	if err != nil {
+		return nil, err
+	}
+	base.Tests = append(samples, secret...)
+	if r.hasSubdirs(path.Join(dir, "data", "secret")) {
+		report("Группы тестов data/secret и их баллы не перенесены: решение проверяется на всех тестах")
+	}
+
+	validation := strings.Fields(problem.Validation + " " + problem.Type)
+	for _, item := range validation {
+		switch item {
+		case "custom":
+			report("Проверяющая программа из output_validators не перенесена: ответы сравниваются по словам")
+		case "interactive":
+			report("Интерактивные задачи не поддерживаются")
+		case "multi-pass", "scoring":
+			report("Тип задачи %s не поддерживается", item)
+		}
+	}
+	base.Checker = kattisChecker(problem.ValidatorFlags, base.Tests, &unmapped)
+
+	if base.ReferenceSolution, err = r.kattisSolution(dir); err != nil {
+		return nil, err
+	}
+	if base.ReferenceSolution == nil && r.exists(path.Join(dir, "submissions", "accepted")) {
+		report("Принятые решения не перенесены: их языки не поддерживаются")
+	}
+
+	return r.problemTasks(base, problem.Limits.Memory, unmapped)
+}
+
+// kattisName возвращает название задачи: строку или название на предпочтительном языке
+func kattisName(name interface{}) string {
+	switch value := name.(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case map[string]interface{}:
+		for _, language := range kattisStatementLanguages {
+			if text, ok := value[language].(string); ok {
+				return strings.TrimSpace(text)
+			}
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if text, ok := value[key].(string); ok {
+				return strings.TrimSpace(text)
+			}
+		}
+	}
+	return ""
+}
+
+// kattisStatement возвращает путь к файлу условия: problem.<язык>.md или .tex
+// на предпочтительном языке, markdown - в первую очередь. "" - условия нет
+func (r *packageReader) kattisStatement(dir string) (string, error) {
+	for _, statementDir := range kattisStatementDirs {
+		full := path.Join(dir, statementDir)
+		if !r.exists(full) {
+			continue
+		}
+		for _, language := range kattisStatementLanguages {
+			for _, ext := range []string{".md", ".tex"} {
+				name := "problem" + ext
+				if language != "" {
+					name = "problem." + language + ext
+				}
+				if r.exists(path.Join(full, name)) {
+					return path.Join(full, name), nil
+				}
+			}
+		}
+		// Условие на другом языке
+		matches, err := fs.Glob(r.fsys, path.Join(full, "problem.*"))
+		if err != nil {
+			return "", err
+		}
+		for _, match := range matches {
+			if ext := path.Ext(match); ext == ".md" || ext == ".tex" {
+				return match, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// readKattisTests читает пары *.in и *.ans директории и её поддиректорий
+// (групп тестов) в порядке путей
+func (r *packageReader) readKattisTests(dir string, hidden bool, timeout int) ([]models.Test, error) {
+	if !r.exists(dir) {
+		return nil, nil
+	}
+	var tests []models.Test
+	err := fs.WalkDir(r.fsys, dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || path.Ext(name) != ".in" {
+			return err
+		}
+		answer := strings.TrimSuffix(name, ".in") + ".ans"
+		if !r.exists(answer) {
+			return fmt.Errorf("%s: no answer file", name)
+		}
+
+		test := models.Test{
+			Description: strings.TrimPrefix(strings.TrimSuffix(name, ".in"), dir+"/"),
+			IsHidden:    hidden,
+			Timeout:     timeout,
+		}
+		if test.Input, err = r.readText(name); err != nil {
+			return err
+		}
+		if test.ExpectedOutput, err = r.readText(answer); err != nil {
+			return err
+		}
+		tests = append(tests, test)
+		return nil
+	})
+	return tests, err
+}
+
+// hasSubdirs сообщает, есть ли в директории поддиректории
+func (r *packageReader) hasSubdirs(dir string) bool {
+	entries, err := fs.ReadDir(r.fsys, dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// kattisChecker переводит флаги стандартной проверки Kattis в чекер платформы
+func kattisChecker(flags string, tests []models.Test, report *[]string) *models.Checker {
+	config := &models.Checker{Type: models.CheckerTokens}
+	caseSensitive, spaceSensitive := false, false
+
+	fields := strings.Fields(flags)
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "case_sensitive":
+			caseSensitive = true
+		case "space_change_sensitive":
+			spaceSensitive = true
+		case "float_tolerance", "float_absolute_tolerance", "float_relative_tolerance":
+			if i+1 == len(fields) {
+				*report = append(*report, fmt.Sprintf("Флаг проверки %s без значения пропущен", fields[i]))
+				continue
+			}
+			epsilon, err := strconv.ParseFloat(fields[i+1], 64)
+			if err != nil {
+				*report = append(*report, fmt.Sprintf("Флаг проверки %s %s пропущен", fields[i], fields[i+1]))
+				i++
+				continue
+			}
+			config.Type = models.CheckerFloat
+			if fields[i] != "float_relative_tolerance" {
+				config.AbsEpsilon = epsilon
+			}
+			if fields[i] != "float_absolute_tolerance" {
+				config.RelEpsilon = epsilon
+			}
+			i++
+		default:
+			*report = append(*report, fmt.Sprintf("Флаг проверки %s не поддерживается", fields[i]))
+		}
+	}
+
+	if spaceSensitive {
+		if config.Type == models.CheckerFloat {
+			*report = append(*report, "Флаг space_change_sensitive не перенесен: пробелы не учитываются при сравнении чисел")
+		} else {
+			config.Type = models.CheckerExact
+		}
+	}
+	if !caseSensitive && hasLetters(tests) {
+		*report = append(*report, "Регистр букв в ответах учитывается, хотя в Kattis по умолчанию не учитывается")
+	}
+	return config
+}
+
+// kattisSolution возвращает принятое решение: файл или директорию из
+// submissions/accepted на поддерживаемом языке. nil - такого решения нет
+func (r *packageReader) kattisSolution(dir string) (*models.ReferenceSolution, error) {
+	accepted := path.Join(dir, "submissions", "accepted")
+	entries, err := fs.ReadDir(r.fsys, accepted)
+	if err != nil {
+		return nil, nil
+	}
+
+	// Решение на языке задачи - в первую очередь
+	var preferred string
+	if len(r.options.Languages) > 0 {
+		if lang, ok := languages.Lookup(strings.TrimSpace(r.options.Languages[0])); ok {
+			preferred = lang.ID
+		}
+	}
+
+	var solution *models.ReferenceSolution
+	for _, entry := range entries {
+		var files []models.SourceFile
+		if entry.IsDir() {
+			if files, err = r.readFiles(path.Join(accepted, entry.Name())); err != nil {
+				return nil, err
+			}
+		} else {
+			content, err := r.readFile(path.Join(accepted, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			files = []models.SourceFile{{Name: entry.Name(), Content: string(content)}}
+		}
+
+		language := ""
+		for _, file := range files {
+			if id, ok := languageByFile(file.Name); ok {
+				language = id
+				break
+			}
+		}
+		if language == "" {
+			continue
+		}
+		code, rest := files[0].Content, files[1:]
+		if entry.IsDir() {
+			if code, rest, err = splitEntry(files, language, ""); err != nil || code == "" {
+				continue
+			}
+		}
+
+		candidate := &models.ReferenceSolution{Code: code, Language: language, Files: rest}
+		if language == preferred {
+			return candidate, nil
+		}
+		if solution == nil {
+			solution = candidate
+		}
+	}
+	return solution, nil
+}
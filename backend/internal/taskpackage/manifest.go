@@ -0,0 +1,126 @@
+package taskpackage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"backend/internal/models"
+)
+
+// manifest - содержимое task.yaml (или task.json). Пути файлов - относительно
+// директории задачи
+type manifest struct {
+	Title      string   `json:"title"`
+	Language   string   `json:"language,omitempty"`  // Язык задачи
+	Languages  []string `json:"languages,omitempty"` // Или несколько языков - по задаче на каждый
+	Difficulty string   `json:"difficulty,omitempty"`
+	Published  *bool    `json:"published,omitempty"` // По умолчанию true
+	Statement  string   `json:"statement,omitempty"` // Файл условия, по умолчанию statement.md
+	Template   string   `json:"template,omitempty"`
+
+	EntryPoint  string            `json:"entry_point,omitempty"`
+	Checker     *manifestChecker  `json:"checker,omitempty"`
+	Function    *models.Function  `json:"function,omitempty"`
+	HiddenFiles []string          `json:"hidden_files,omitempty"` // Скрытые файлы из support/
+	Solution    *manifestSolution `json:"solution,omitempty"`
+
+	// Без списка тестами считаются пары tests/*.in и tests/*.out
+	Tests     []manifestTest     `json:"tests,omitempty"`
+	Generator *manifestGenerator `json:"generator,omitempty"`
+}
+
+// manifestChecker - настройки чекера; код пользовательского чекера лежит в File
+type manifestChecker struct {
+	models.Checker
+	File string `json:"file,omitempty"`
+}
+
+// manifestSolution - эталонное решение из директории solution/
+type manifestSolution struct {
+	Language string `json:"language,omitempty"` // По умолчанию - язык задачи
+}
+
+// manifestTest - тест: файлы ввода и ответа. Для задач с функцией ввод -
+// JSON-массив аргументов, ответ - возвращаемое значение в JSON
+type manifestTest struct {
+	Input       string `json:"input"`
+	Output      string `json:"output"`
+	Description string `json:"description,omitempty"`
+	Hidden      bool   `json:"hidden,omitempty"`
+	Timeout     int    `json:"timeout,omitempty"` // мс
+}
+
+// manifestGenerator - генератор тестов и сгенерированные им тесты. Если тестов
+// нет, их генерирует импорт
+type manifestGenerator struct {
+	File     string         `json:"file"`
+	Language string         `json:"language,omitempty"`
+	Count    int            `json:"count,omitempty"`
+	Seeds    []int64        `json:"seeds,omitempty"`
+	Tests    []manifestTest `json:"tests,omitempty"`
+}
+
+// parseManifest разбирает манифест в YAML или JSON. Неизвестные поля - ошибка,
+// чтобы опечатка в названии поля не терялась молча
+func parseManifest(name string, data []byte) (*manifest, error) {
+	if !strings.HasSuffix(name, ".json") {
+		// YAML переводится в JSON, чтобы манифест использовал json-теги моделей
+		var value interface{}
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var m manifest
+	if err := decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+	if m.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	return &m, nil
+}
+
+// marshalManifest записывает манифест в YAML в порядке полей структуры
+func marshalManifest(m *manifest) ([]byte, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	// JSON - подмножество YAML: узлы сохраняют порядок полей, остается
+	// перевести их из JSON-стиля в блочный
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// blockStyle переводит узлы в блочный стиль, многострочные строки - в литералы
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" && strings.Contains(node.Value, "\n") {
+		node.Style = yaml.LiteralStyle
+	}
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,708 @@
+// Package taskpackage - переносимый формат задач для импорта и экспорта.
+//
+// Пакет - zip-архив или директория, в которой каждая задача лежит в своей
+// директории:
+//
+//	task.yaml           манифест (или task.json)
+//	statement.md        условие
+//	tests/01.in         ввод теста
+//	tests/01.out        ожидаемый ответ
+//	starter/<язык>/     начальный код для каждого языка задачи
+//	support/            файлы поддержки рядом с решением
+//	solution/           эталонное решение
+//	checker/            код пользовательского чекера
+//	generator/          генератор тестов, tests/generated/ - его тесты
+//
+// Задача платформы написана на одном языке, поэтому задача пакета с несколькими
+// языками читается как несколько задач с общими условием и тестами.
+//
+// Кроме этого формата читаются пакеты задач Codeforces Polygon (problem.xml)
+// и Kattis/ICPC (problem.yaml), в том числе вперемешку в одном архиве
+package taskpackage
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"backend/internal/languages"
+	"backend/internal/models"
+)
+
+const (
+	// MaxFileSize ограничивает размер одного файла пакета
+	MaxFileSize = 16 << 20
+	// MaxPackageSize ограничивает суммарный размер файлов пакета
+	MaxPackageSize = 128 << 20
+)
+
+// Task - задача пакета на одном языке
+type Task struct {
+	models.Task
+	Generator      *models.TestGenerator // Генератор активной версии тестов
+	GeneratedTests []models.Test         // Тесты генератора (пусто - сгенерировать при импорте)
+	Unmapped       []string              // Что из пакета Polygon или Kattis не удалось перенести
+}
+
+// Options - настройки чтения пакета
+type Options struct {
+	// Languages - языки задач Polygon и Kattis, в которых язык задачи не задан:
+	// по задаче на каждый. По умолчанию - язык главного решения
+	Languages []string
+}
+
+const (
+	formatPackage = "package" // Формат этого пакета
+	formatPolygon = "polygon" // Codeforces Polygon
+	formatKattis  = "kattis"  // Kattis/ICPC problem package
+)
+
+// formatFiles - файлы описания задачи и их форматы
+var formatFiles = []struct {
+	name   string
+	format string
+}{
+	{"task.yaml", formatPackage},
+	{"task.yml", formatPackage},
+	{"task.json", formatPackage},
+	{"problem.xml", formatPolygon},
+	{"problem.yaml", formatKattis},
+}
+
+// manifestNames - имена файла манифеста в порядке приоритета
+var manifestNames = []string{"task.yaml", "task.yml", "task.json"}
+
+// Read читает пакет из zip-архива
+func Read(data []byte, options Options) ([]Task, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid zip archive: %w", err)
+	}
+	return ReadFS(archive, options)
+}
+
+// Open читает пакет из zip-архива или директории
+func Open(name string, options Options) ([]Task, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return ReadFS(os.DirFS(name), options)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Read(data, options)
+}
+
+// ReadFS читает пакет из файловой системы (директории или архива).
+// Задачи возвращаются в порядке путей их директорий
+func ReadFS(fsys fs.FS, options Options) ([]Task, error) {
+	reader := &packageReader{fsys: fsys, options: options}
+
+	formats := make(map[string]string)
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if name != "." && strings.HasPrefix(entry.Name(), ".") {
+				return fs.SkipDir // .git и подобные
+			}
+			return nil
+		}
+		for _, file := range formatFiles {
+			if entry.Name() == file.name && formats[path.Dir(name)] == "" {
+				formats[path.Dir(name)] = file.format
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(formats) == 0 {
+		return nil, fmt.Errorf("no tasks found: expected task.yaml, Polygon problem.xml or Kattis problem.yaml in task directories")
+	}
+
+	dirs := make([]string, 0, len(formats))
+	for dir := range formats {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var tasks []Task
+	for i, dir := range dirs {
+		// Файлы внутри задачи Polygon или Kattis - не отдельные задачи
+		if nestedDir(dir, dirs[:i], formats) {
+			continue
+		}
+
+		var dirTasks []Task
+		switch formats[dir] {
+		case formatPolygon:
+			dirTasks, err = reader.readPolygon(dir)
+		case formatKattis:
+			dirTasks, err = reader.readKattis(dir)
+		default:
+			dirTasks, err = reader.readTask(dir)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dir, err)
+		}
+		tasks = append(tasks, dirTasks...)
+	}
+	return tasks, nil
+}
+
+// nestedDir сообщает, лежит ли dir внутри директории задачи Polygon или Kattis
+func nestedDir(dir string, parents []string, formats map[string]string) bool {
+	for _, parent := range parents {
+		if formats[parent] != formatPackage && (parent == "." || strings.HasPrefix(dir, parent+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// packageReader читает файлы пакета, следя за суммарным размером
+type packageReader struct {
+	fsys    fs.FS
+	options Options
+	size    int64
+}
+
+// readFile читает файл пакета. Размер проверяется до чтения, чтобы архив
+// с огромным распакованным файлом не занял всю память
+func (r *packageReader) readFile(name string) ([]byte, error) {
+	info, err := fs.Stat(r.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > MaxFileSize {
+		return nil, fmt.Errorf("%s: file is too large (max %d MB)", name, MaxFileSize>>20)
+	}
+	r.size += info.Size()
+	if r.size > MaxPackageSize {
+		return nil, fmt.Errorf("package is too large (max %d MB)", MaxPackageSize>>20)
+	}
+
+	file, err := r.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(io.LimitReader(file, MaxFileSize+1))
+}
+
+// exists сообщает, есть ли в пакете файл или директория
+func (r *packageReader) exists(name string) bool {
+	_, err := fs.Stat(r.fsys, name)
+	return err == nil
+}
+
+// readFiles читает все файлы директории пакета с именами относительно неё
+func (r *packageReader) readFiles(dir string) ([]models.SourceFile, error) {
+	if !r.exists(dir) {
+		return nil, nil
+	}
+	var files []models.SourceFile
+	err := fs.WalkDir(r.fsys, dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		content, err := r.readFile(name)
+		if err != nil {
+			return err
+		}
+		files = append(files, models.SourceFile{
+			Name:    strings.TrimPrefix(name, dir+"/"),
+			Content: string(content),
+		})
+		return nil
+	})
+	return files, err
+}
+
+// readText читает текстовый файл теста без завершающего перевода строки
+func (r *packageReader) readText(name string) (string, error) {
+	content, err := r.readFile(name)
+	if err != nil {
+		return "", err
+	}
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	return strings.TrimSuffix(text, "\n"), nil
+}
+
+// readTask читает задачу из директории - по одной на каждый язык манифеста
+func (r *packageReader) readTask(dir string) ([]Task, error) {
+	var m *manifest
+	for _, manifestName := range manifestNames {
+		name := path.Join(dir, manifestName)
+		if !r.exists(name) {
+			continue
+		}
+		data, err := r.readFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if m, err = parseManifest(manifestName, data); err != nil {
+			return nil, fmt.Errorf("%s: %w", manifestName, err)
+		}
+		break
+	}
+
+	langIDs := m.Languages
+	if m.Language != "" {
+		langIDs = append([]string{m.Language}, langIDs...)
+	}
+	if len(langIDs) == 0 {
+		return nil, fmt.Errorf("language is required")
+	}
+
+	base := models.Task{
+		Title:       m.Title,
+		Difficulty:  m.Difficulty,
+		Template:    m.Template,
+		IsPublished: m.Published == nil || *m.Published,
+		Function:    m.Function,
+		EntryPoint:  m.EntryPoint,
+	}
+
+	statement := m.Statement
+	if statement == "" {
+		statement = "statement.md"
+	}
+	description, err := r.readFile(path.Join(dir, statement))
+	if err != nil {
+		return nil, fmt.Errorf("statement: %w", err)
+	}
+	base.Description = strings.TrimSpace(string(description))
+
+	if base.Tests, err = r.readTests(dir, m.Tests, "tests", m.Function != nil); err != nil {
+		return nil, err
+	}
+
+	if m.Checker != nil {
+		config := m.Checker.Checker
+		if m.Checker.File != "" {
+			code, err := r.readFile(path.Join(dir, m.Checker.File))
+			if err != nil {
+				return nil, fmt.Errorf("checker: %w", err)
+			}
+			config.Code = string(code)
+		}
+		base.Checker = &config
+	}
+
+	if base.SupportFiles, err = r.readFiles(path.Join(dir, "support")); err != nil {
+		return nil, err
+	}
+	hidden := make(map[string]bool)
+	for _, name := range m.HiddenFiles {
+		hidden[name] = true
+	}
+	for i := range base.SupportFiles {
+		base.SupportFiles[i].Hidden = hidden[base.SupportFiles[i].Name]
+	}
+
+	solutionFiles, err := r.readFiles(path.Join(dir, "solution"))
+	if err != nil {
+		return nil, err
+	}
+	if len(solutionFiles) > 0 {
+		language := langIDs[0]
+		if m.Solution != nil && m.Solution.Language != "" {
+			language = m.Solution.Language
+		}
+		code, files, err := splitEntry(solutionFiles, language, m.EntryPoint)
+		if err != nil {
+			return nil, fmt.Errorf("solution: %w", err)
+		}
+		base.ReferenceSolution = &models.ReferenceSolution{Code: code, Language: language, Files: files}
+	}
+
+	var generator *models.TestGenerator
+	var generatedTests []models.Test
+	if m.Generator != nil {
+		code, err := r.readFile(path.Join(dir, m.Generator.File))
+		if err != nil {
+			return nil, fmt.Errorf("generator: %w", err)
+		}
+		generator = &models.TestGenerator{
+			Code:     string(code),
+			Language: m.Generator.Language,
+			Count:    m.Generator.Count,
+			Seeds:    m.Generator.Seeds,
+		}
+		if generatedTests, err = r.readTests(dir, m.Generator.Tests, "tests/generated", m.Function != nil); err != nil {
+			return nil, err
+		}
+		for i := range generatedTests {
+			generatedTests[i].IsHidden = true
+		}
+	}
+
+	var tasks []Task
+	for _, langID := range langIDs {
+		task := Task{Task: base, Generator: generator, GeneratedTests: generatedTests}
+		task.Language = langID
+
+		starterFiles, err := r.readFiles(path.Join(dir, "starter", langID))
+		if err != nil {
+			return nil, err
+		}
+		if len(starterFiles) > 0 {
+			if task.StarterCode, task.StarterFiles, err = splitEntry(starterFiles, langID, m.EntryPoint); err != nil {
+				return nil, fmt.Errorf("starter %s: %w", langID, err)
+			}
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// readTests читает тесты по списку манифеста, а без списка - все пары
+// <dir>/*.in и <dir>/*.out (.ans) в порядке имен
+func (r *packageReader) readTests(taskDir string, list []manifestTest, testsDir string, function bool) ([]models.Test, error) {
+	if len(list) == 0 {
+		entries, err := fs.ReadDir(r.fsys, path.Join(taskDir, testsDir))
+		if err != nil && !r.exists(path.Join(taskDir, testsDir)) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || path.Ext(entry.Name()) != ".in" {
+				continue
+			}
+			stem := strings.TrimSuffix(entry.Name(), ".in")
+			output := path.Join(testsDir, stem+".out")
+			if !r.exists(path.Join(taskDir, output)) {
+				output = path.Join(testsDir, stem+".ans")
+			}
+			list = append(list, manifestTest{Input: path.Join(testsDir, entry.Name()), Output: output})
+		}
+	}
+
+	tests := make([]models.Test, 0, len(list))
+	for i, item := range list {
+		input, err := r.readText(path.Join(taskDir, item.Input))
+		if err != nil {
+			return nil, fmt.Errorf("test %d: %w", i+1, err)
+		}
+		output, err := r.readText(path.Join(taskDir, item.Output))
+		if err != nil {
+			return nil, fmt.Errorf("test %d: %w", i+1, err)
+		}
+
+		test := models.Test{
+			Description: item.Description,
+			IsHidden:    item.Hidden,
+			Timeout:     item.Timeout,
+		}
+		if function {
+			if err := json.Unmarshal([]byte(input), &test.Args); err != nil {
+				return nil, fmt.Errorf("test %d: %s must be a JSON array of args: %w", i+1, item.Input, err)
+			}
+			var expected bytes.Buffer
+			if err := json.Compact(&expected, []byte(output)); err != nil {
+				return nil, fmt.Errorf("test %d: %s must be JSON: %w", i+1, item.Output, err)
+			}
+			test.ExpectedReturn = json.RawMessage(expected.Bytes())
+		} else {
+			test.Input = input
+			test.ExpectedOutput = output
+		}
+		tests = append(tests, test)
+	}
+	return tests, nil
+}
+
+// splitEntry отделяет файл с точкой входа от остальных: это entryPoint, файл
+// языка по умолчанию или единственный файл с расширением языка
+func splitEntry(files []models.SourceFile, language, entryPoint string) (string, []models.SourceFile, error) {
+	lang, ok := languages.Lookup(language)
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported language: %s", language)
+	}
+
+	entry := -1
+	for i, file := range files {
+		if file.Name == entryPoint || (entryPoint == "" && file.Name == lang.FileName) {
+			entry = i
+		}
+	}
+	if entry < 0 && entryPoint == "" {
+		for i, file := range files {
+			if path.Ext(file.Name) == path.Ext(lang.FileName) {
+				if entry >= 0 {
+					return "", nil, fmt.Errorf("several %s files, set entry_point", path.Ext(lang.FileName))
+				}
+				entry = i
+			}
+		}
+	}
+	if entry < 0 {
+		// Многофайловое решение без файла с точкой входа - точку входа задает задача
+		return "", files, nil
+	}
+
+	rest := make([]models.SourceFile, 0, len(files)-1)
+	rest = append(rest, files[:entry]...)
+	rest = append(rest, files[entry+1:]...)
+	return files[entry].Content, rest, nil
+}
+
+// Write записывает задачи в zip-архив
+func Write(w io.Writer, tasks []Task) error {
+	archive := zip.NewWriter(w)
+	err := WriteFiles(tasks, func(name string, content []byte) error {
+		file, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = file.Write(content)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// WriteDir записывает задачи в директорию, создавая её
+func WriteDir(dir string, tasks []Task) error {
+	return WriteFiles(tasks, func(name string, content []byte) error {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, content, 0644)
+	})
+}
+
+// WriteFiles передает файлы пакета в write - например, чтобы записать пакет
+// в директорию под контролем версий. Каждая задача лежит в директории
+// с номером и названием
+func WriteFiles(tasks []Task, write func(name string, content []byte) error) error {
+	for i, task := range tasks {
+		dir := fmt.Sprintf("%02d-%s", i+1, slug(task.Title+" "+task.Language))
+		if err := writeTask(dir, task, write); err != nil {
+			return fmt.Errorf("%s: %w", task.Title, err)
+		}
+	}
+	return nil
+}
+
+// writeTask записывает файлы одной задачи
+func writeTask(dir string, task Task, write func(name string, content []byte) error) error {
+	put := func(name, content string) error {
+		return write(path.Join(dir, name), []byte(content))
+	}
+
+	m := &manifest{
+		Title:      task.Title,
+		Language:   task.Language,
+		Difficulty: task.Difficulty,
+		Published:  &task.IsPublished,
+		Template:   task.Template,
+		EntryPoint: task.EntryPoint,
+		Function:   task.Function,
+	}
+
+	if err := put("statement.md", task.Description+"\n"); err != nil {
+		return err
+	}
+
+	var err error
+	if m.Tests, err = writeTests(task.Tests, "tests", task.Function != nil, put); err != nil {
+		return err
+	}
+
+	if task.Checker != nil {
+		m.Checker = &manifestChecker{Checker: *task.Checker}
+		if task.Checker.Code != "" {
+			m.Checker.File = path.Join("checker", sourceName(task.Checker.Language, "", task.Checker.Code))
+			m.Checker.Code = ""
+			if err := put(m.Checker.File, task.Checker.Code); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, file := range task.SupportFiles {
+		if file.Hidden {
+			m.HiddenFiles = append(m.HiddenFiles, file.Name)
+		}
+		if err := put(path.Join("support", file.Name), file.Content); err != nil {
+			return err
+		}
+	}
+
+	if task.StarterCode != "" {
+		name := sourceName(task.Language, task.EntryPoint, task.StarterCode)
+		if err := put(path.Join("starter", task.Language, name), task.StarterCode); err != nil {
+			return err
+		}
+	}
+	for _, file := range task.StarterFiles {
+		if err := put(path.Join("starter", task.Language, file.Name), file.Content); err != nil {
+			return err
+		}
+	}
+
+	if reference := task.ReferenceSolution; reference != nil {
+		language := reference.Language
+		if language == "" {
+			language = task.Language
+		}
+		if language != task.Language {
+			m.Solution = &manifestSolution{Language: language}
+		}
+		if reference.Code != "" {
+			name := sourceName(language, task.EntryPoint, reference.Code)
+			if err := put(path.Join("solution", name), reference.Code); err != nil {
+				return err
+			}
+		}
+		for _, file := range reference.Files {
+			if err := put(path.Join("solution", file.Name), file.Content); err != nil {
+				return err
+			}
+		}
+	}
+
+	if generator := task.Generator; generator != nil {
+		language := generator.Language
+		if language == "" {
+			language = task.Language
+		}
+		m.Generator = &manifestGenerator{
+			File:     path.Join("generator", sourceName(language, "", generator.Code)),
+			Language: generator.Language,
+			Count:    generator.Count,
+			Seeds:    generator.Seeds,
+		}
+		if err := put(m.Generator.File, generator.Code); err != nil {
+			return err
+		}
+		if m.Generator.Tests, err = writeTests(task.GeneratedTests, "tests/generated", task.Function != nil, put); err != nil {
+			return err
+		}
+		// Сгенерированные тесты скрыты всегда, флаг в манифесте не нужен
+		for i := range m.Generator.Tests {
+			m.Generator.Tests[i].Hidden = false
+		}
+	}
+
+	data, err := marshalManifest(m)
+	if err != nil {
+		return err
+	}
+	return put("task.yaml", string(data))
+}
+
+// writeTests записывает файлы тестов и возвращает их список для манифеста
+func writeTests(tests []models.Test, dir string, function bool, put func(name, content string) error) ([]manifestTest, error) {
+	width := len(fmt.Sprint(len(tests)))
+	if width < 2 {
+		width = 2
+	}
+
+	list := make([]manifestTest, 0, len(tests))
+	for i, test := range tests {
+		input, output := test.Input, test.ExpectedOutput
+		if function {
+			args := test.Args
+			if args == nil {
+				args = []json.RawMessage{}
+			}
+			data, err := json.Marshal(args)
+			if err != nil {
+				return nil, fmt.Errorf("test %d: %w", i+1, err)
+			}
+			input, output = string(data), string(test.ExpectedReturn)
+		}
+
+		item := manifestTest{
+			Input:       path.Join(dir, fmt.Sprintf("%0*d.in", width, i+1)),
+			Output:      path.Join(dir, fmt.Sprintf("%0*d.out", width, i+1)),
+			Description: test.Description,
+			Hidden:      test.IsHidden,
+			Timeout:     test.Timeout,
+		}
+		if err := put(item.Input, textFile(input)); err != nil {
+			return nil, err
+		}
+		if err := put(item.Output, textFile(output)); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+// textFile добавляет завершающий перевод строки, который снимается при чтении
+func textFile(text string) string {
+	if text == "" {
+		return ""
+	}
+	return text + "\n"
+}
+
+// sourceName возвращает имя файла с кодом на языке: точку входа задачи или
+// имя файла языка для этого кода
+func sourceName(language, entryPoint, code string) string {
+	if entryPoint != "" {
+		return entryPoint
+	}
+	lang, ok := languages.Lookup(language)
+	if !ok {
+		return "main.txt"
+	}
+	return lang.SourceFile(code)
+}
+
+// translit - латинская запись русских букв для имен директорий
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
+
+var slugSeparatorRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slug переводит название задачи в имя директории из латиницы, цифр и '-'
+func slug(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if latin, ok := translit[r]; ok {
+			b.WriteString(latin)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	result := strings.Trim(slugSeparatorRe.ReplaceAllString(b.String(), "-"), "-")
+	if len(result) > 60 {
+		result = strings.TrimRight(result[:60], "-")
+	}
+	if result == "" {
+		return "task"
+	}
+	return result
+}
@@ -0,0 +1,291 @@
+package taskpackage
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"backend/internal/models"
+)
+
+// packageFS собирает пакет из пар имя - содержимое
+func packageFS(files map[string]string) fstest.MapFS {
+	fsys := make(fstest.MapFS, len(files))
+	for name, content := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return fsys
+}
+
+func TestReadManifestErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string // Часть текста ошибки
+	}{
+		{"no tasks", map[string]string{"readme.md": "empty"}, "no tasks found"},
+		{"missing title", map[string]string{
+			"a/task.yaml":    "language: python\n",
+			"a/statement.md": "Sum",
+		}, "a: task.yaml: title is required"},
+		{"missing language", map[string]string{
+			"a/task.yaml":    "title: Sum\n",
+			"a/statement.md": "Sum",
+		}, "a: language is required"},
+		{"unknown field", map[string]string{
+			"a/task.yaml":    "title: Sum\nlanguage: python\ntimelimit: 1\n",
+			"a/statement.md": "Sum",
+		}, `unknown field "timelimit"`},
+		{"unknown field in json", map[string]string{
+			"a/task.json":    `{"title": "Sum", "language": "python", "test": []}`,
+			"a/statement.md": "Sum",
+		}, `a: task.json: json: unknown field "test"`},
+		{"missing statement", map[string]string{
+			"a/task.yaml": "title: Sum\nlanguage: python\n",
+		}, "a: statement:"},
+		{"missing statement file", map[string]string{
+			"a/task.yaml":    "title: Sum\nlanguage: python\nstatement: README.md\n",
+			"a/statement.md": "Sum",
+		}, "a: statement:"},
+		{"missing test output", map[string]string{
+			"a/task.yaml":    "title: Sum\nlanguage: python\ntests:\n  - input: 1.in\n    output: 1.out\n",
+			"a/statement.md": "Sum",
+			"a/1.in":         "1 2",
+		}, "a: test 1:"},
+		{"function args are not an array", map[string]string{
+			"a/task.yaml":    "title: Add\nlanguage: python\nfunction:\n  name: add\n  return_type: int\n",
+			"a/statement.md": "Add",
+			"a/tests/01.in":  "1 2",
+			"a/tests/01.out": "3",
+		}, "must be a JSON array of args"},
+		{"missing checker file", map[string]string{
+			"a/task.yaml":    "title: Sum\nlanguage: python\nchecker:\n  type: custom\n  language: python\n  file: checker/check.py\n",
+			"a/statement.md": "Sum",
+		}, "a: checker:"},
+		{"missing generator file", map[string]string{
+			"a/task.yaml":    "title: Sum\nlanguage: python\ngenerator:\n  file: gen.py\n  count: 3\n",
+			"a/statement.md": "Sum",
+		}, "a: generator:"},
+		{"ambiguous solution", map[string]string{
+			"a/task.yaml":       "title: Sum\nlanguage: python\n",
+			"a/statement.md":    "Sum",
+			"a/solution/one.py": "print(1)",
+			"a/solution/two.py": "print(2)",
+		}, "a: solution: several .py files, set entry_point"},
+		{"unsupported solution language", map[string]string{
+			"a/task.yaml":        "title: Sum\nlanguage: python\nsolution:\n  language: cobol\n",
+			"a/statement.md":     "Sum",
+			"a/solution/main.cb": "DISPLAY 1",
+		}, "a: solution: unsupported language: cobol"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadFS(packageFS(tt.files), Options{})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("ReadFS() error = %v, want error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestReadTask(t *testing.T) {
+	tasks, err := ReadFS(packageFS(map[string]string{
+		"sum/task.yml": `title: Сумма
+languages: [python, cpp]
+difficulty: easy
+published: false
+hidden_files: [data.txt]
+tests:
+  - input: tests/a.in
+    output: tests/a.out
+    description: small
+  - input: tests/b.in
+    output: tests/b.ans
+    hidden: true
+    timeout: 500
+`,
+		"sum/statement.md":         "\nСложите числа\n\n",
+		"sum/tests/a.in":           "1 2\r\n",
+		"sum/tests/a.out":          "3\n",
+		"sum/tests/b.in":           "2 2",
+		"sum/tests/b.ans":          "4",
+		"sum/support/data.txt":     "secret",
+		"sum/support/helper.py":    "X = 1",
+		"sum/starter/cpp/main.cpp": "int main() {}",
+		"sum/solution/main.py":     "print(sum(map(int, input().split())))",
+		"sum/.git/config":          "ignored",
+	}), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 || tasks[0].Language != "python" || tasks[1].Language != "cpp" {
+		t.Fatalf("tasks = %+v, want python and cpp tasks", tasks)
+	}
+
+	task := tasks[0]
+	if task.Title != "Сумма" || task.Description != "Сложите числа" || task.Difficulty != "easy" || task.IsPublished {
+		t.Errorf("task fields = %q, %q, %q, published %v", task.Title, task.Description, task.Difficulty, task.IsPublished)
+	}
+	wantTests := []models.Test{
+		{Input: "1 2", ExpectedOutput: "3", Description: "small"},
+		{Input: "2 2", ExpectedOutput: "4", IsHidden: true, Timeout: 500},
+	}
+	if !reflect.DeepEqual(task.Tests, wantTests) {
+		t.Errorf("tests = %+v, want %+v", task.Tests, wantTests)
+	}
+	wantSupport := []models.SourceFile{
+		{Name: "data.txt", Content: "secret", Hidden: true},
+		{Name: "helper.py", Content: "X = 1"},
+	}
+	if !reflect.DeepEqual(task.SupportFiles, wantSupport) {
+		t.Errorf("support files = %+v, want %+v", task.SupportFiles, wantSupport)
+	}
+	if task.ReferenceSolution == nil || task.ReferenceSolution.Language != "python" || task.ReferenceSolution.Code == "" {
+		t.Errorf("reference solution = %+v", task.ReferenceSolution)
+	}
+	if task.StarterCode != "" || tasks[1].StarterCode != "int main() {}" {
+		t.Errorf("starter code = %q, %q, want only cpp starter", task.StarterCode, tasks[1].StarterCode)
+	}
+}
+
+// roundTripTasks - задачи, которые экспорт и импорт должны сохранить без потерь
+var roundTripTasks = []Task{
+	{
+		Task: models.Task{
+			Title:       "Сумма чисел",
+			Description: "Сложите два числа.\n\n## Ввод\n\nДва числа",
+			Language:    "python",
+			Difficulty:  "easy",
+			Template:    "# решение",
+			IsPublished: true,
+			Tests: []models.Test{
+				{Input: "1 2", ExpectedOutput: "3", Description: "пример"},
+				{Input: "10 -3\n", ExpectedOutput: "7", IsHidden: true, Timeout: 2000},
+				{Input: "", ExpectedOutput: "0"},
+			},
+			Checker: &models.Checker{Type: models.CheckerFloat, AbsEpsilon: 1e-6},
+			SupportFiles: []models.SourceFile{
+				{Name: "data/answers.txt", Content: "3\n7\n", Hidden: true},
+				{Name: "helper.py", Content: "def read():\n    return input()\n"},
+			},
+			StarterCode:       "a, b = 0, 0\n",
+			ReferenceSolution: &models.ReferenceSolution{Code: "print(sum(map(int, input().split())))\n", Language: "python"},
+		},
+		Generator: &models.TestGenerator{Code: "print(1, 2)\n", Count: 2, Seeds: []int64{5, 7}},
+		GeneratedTests: []models.Test{
+			{Input: "5 7", ExpectedOutput: "12", IsHidden: true},
+		},
+	},
+	{
+		Task: models.Task{
+			Title:       "Add",
+			Description: "Write add",
+			Language:    "cpp",
+			Function: &models.Function{Name: "add", ReturnType: "int", Params: []models.FunctionParam{
+				{Name: "a", Type: "int"}, {Name: "b", Type: "int"},
+			}},
+			Tests: []models.Test{
+				{Args: []json.RawMessage{json.RawMessage("1"), json.RawMessage("2")}, ExpectedReturn: json.RawMessage("3")},
+				{Args: []json.RawMessage{}, ExpectedReturn: json.RawMessage(`"x"`), IsHidden: true},
+			},
+			Checker:           &models.Checker{Type: models.CheckerCustom, Language: "python", Code: "print('OK')\n"},
+			ReferenceSolution: &models.ReferenceSolution{Code: "def add(a, b):\n    return a + b\n", Language: "python"},
+		},
+	},
+	{
+		Task: models.Task{
+			Title:       "Multi file",
+			Description: "Split the code",
+			Language:    "python",
+			EntryPoint:  "app.py",
+			IsPublished: true,
+			Tests:       []models.Test{{Input: "x", ExpectedOutput: "x"}},
+			StarterCode: "import util\n",
+			StarterFiles: []models.SourceFile{
+				{Name: "util.py", Content: "pass\n"},
+			},
+			ReferenceSolution: &models.ReferenceSolution{
+				Code:     "import util\nprint(input())\n",
+				Language: "python",
+				Files:    []models.SourceFile{{Name: "util.py", Content: "pass\n"}},
+			},
+		},
+	},
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		read func(t *testing.T, tasks []Task) ([]Task, error)
+	}{
+		{"files", func(t *testing.T, tasks []Task) ([]Task, error) {
+			fsys := make(fstest.MapFS)
+			err := WriteFiles(tasks, func(name string, content []byte) error {
+				fsys[name] = &fstest.MapFile{Data: content}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return ReadFS(fsys, Options{})
+		}},
+		{"zip", func(t *testing.T, tasks []Task) ([]Task, error) {
+			var buf bytes.Buffer
+			if err := Write(&buf, tasks); err != nil {
+				t.Fatal(err)
+			}
+			return Read(buf.Bytes(), Options{})
+		}},
+		{"directory", func(t *testing.T, tasks []Task) ([]Task, error) {
+			dir := t.TempDir()
+			if err := WriteDir(dir, tasks); err != nil {
+				t.Fatal(err)
+			}
+			return Open(dir, Options{})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.read(t, roundTripTasks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(roundTripTasks) {
+				t.Fatalf("read %d tasks, want %d", len(got), len(roundTripTasks))
+			}
+			for i, want := range roundTripTasks {
+				// Пустые и nil-срезы не различаются, поэтому задачи сравниваются в JSON
+				gotJSON, _ := json.Marshal(got[i])
+				wantJSON, _ := json.Marshal(want)
+				if !bytes.Equal(gotJSON, wantJSON) {
+					t.Errorf("task %d:\n got %s\nwant %s", i+1, gotJSON, wantJSON)
+				}
+			}
+		})
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Сумма чисел python", "summa-chisel-python"},
+		{"A + B", "a-b"},
+		{"  Щука, ёж!  ", "schuka-ezh"},
+		{"!!!", "task"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := slug(tt.title); got != tt.want {
+				t.Errorf("slug(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package taskpackage
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+
+	"backend/internal/models"
+)
+
+// polygonProblem - описание задачи Codeforces Polygon (problem.xml)
+type polygonProblem struct {
+	ShortName string `xml:"short-name,attr"`
+	Names     []struct {
+		Language string `xml:"language,attr"`
+		Value    string `xml:"value,attr"`
+	} `xml:"names>name"`
+	Judging struct {
+		InputFile  string           `xml:"input-file,attr"`
+		OutputFile string           `xml:"output-file,attr"`
+		Testsets   []polygonTestset `xml:"testset"`
+	} `xml:"judging"`
+	Assets struct {
+		Checker *struct {
+			Name   string        `xml:"name,attr"`
+			Type   string        `xml:"type,attr"`
+			Source polygonSource `xml:"source"`
+		} `xml:"checker"`
+		Interactor *struct {
+			Source polygonSource `xml:"source"`
+		} `xml:"interactor"`
+		Validators []struct {
+			Source polygonSource `xml:"source"`
+		} `xml:"validators>validator"`
+		Solutions []struct {
+			Tag    string        `xml:"tag,attr"`
+			Source polygonSource `xml:"source"`
+		} `xml:"solutions>solution"`
+	} `xml:"assets"`
+}
+
+// polygonTestset - набор тестов Polygon; импортируется набор tests
+type polygonTestset struct {
+	Name          string `xml:"name,attr"`
+	TimeLimit     int    `xml:"time-limit"`   // мс
+	MemoryLimit   int64  `xml:"memory-limit"` // байты
+	InputPattern  string `xml:"input-path-pattern"`
+	AnswerPattern string `xml:"answer-path-pattern"`
+	Tests         []struct {
+		Method string  `xml:"method,attr"`
+		Cmd    string  `xml:"cmd,attr"`
+		Sample bool    `xml:"sample,attr"`
+		Points float64 `xml:"points,attr"`
+		Group  string  `xml:"group,attr"`
+	} `xml:"tests>test"`
+}
+
+// polygonSource - файл исходника Polygon; Type - компилятор, например cpp.g++17
+type polygonSource struct {
+	Path string `xml:"path,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// polygonLanguages - языки платформы по префиксу типа исходника Polygon
+var polygonLanguages = []struct{ prefix, language string }{
+	{"cpp.", "cpp"},
+	{"c.", "c"},
+	{"java", "java"},
+	{"kotlin", "kotlin"},
+	{"python.3", "python"},
+	{"python.pypy3", "python"},
+	{"rust", "rust"},
+	{"go", "go"},
+	{"csharp", "csharp"},
+	{"js", "javascript"},
+}
+
+// language возвращает язык платформы для исходника
+func (s polygonSource) language() (string, bool) {
+	for _, item := range polygonLanguages {
+		if strings.HasPrefix(s.Type, item.prefix) {
+			return item.language, true
+		}
+	}
+	if s.Type == "" {
+		return languageByFile(s.Path)
+	}
+	return "", false
+}
+
+// polygonCheckers - стандартные чекеры testlib, которые есть среди чекеров платформы
+var polygonCheckers = map[string]models.Checker{
+	"wcmp":  {Type: models.CheckerTokens},
+	"ncmp":  {Type: models.CheckerTokens},
+	"icmp":  {Type: models.CheckerTokens},
+	"acmp":  {Type: models.CheckerTokens},
+	"hcmp":  {Type: models.CheckerTokens},
+	"lcmp":  {Type: models.CheckerWhitespace},
+	"fcmp":  {Type: models.CheckerExact},
+	"yesno": {Type: models.CheckerCaseInsensitive},
+	"rcmp":  {Type: models.CheckerFloat, AbsEpsilon: 1.5e-6, RelEpsilon: 1.5e-6},
+	"dcmp":  {Type: models.CheckerFloat, AbsEpsilon: 1e-6, RelEpsilon: 1e-6},
+	"rcmp4": {Type: models.CheckerFloat, AbsEpsilon: 1e-4, RelEpsilon: 1e-4},
+	"rcmp6": {Type: models.CheckerFloat, AbsEpsilon: 1e-6, RelEpsilon: 1e-6},
+	"rcmp9": {Type: models.CheckerFloat, AbsEpsilon: 1e-9, RelEpsilon: 1e-9},
+}
+
+// polygonStatementSections - разделы условия Polygon и их заголовки
+var polygonStatementSections = []struct {
+	name, property, russian, english string
+}{
+	{"legend.tex", "legend", "", ""},
+	{"input.tex", "input", "Входные данные", "Input"},
+	{"output.tex", "output", "Выходные данные", "Output"},
+	{"interaction.tex", "interaction", "Протокол взаимодействия", "Interaction"},
+	{"notes.tex", "notes", "Примечание", "Note"},
+}
+
+// readPolygon читает задачу из пакета Polygon: problem.xml, условие из
+// statement-sections (или statements/<язык>/problem-properties.json), тесты
+// набора tests, стандартный чекер и главное решение
+func (r *packageReader) readPolygon(dir string) ([]Task, error) {
+	data, err := r.readFile(path.Join(dir, "problem.xml"))
+	if err != nil {
+		return nil, err
+	}
+	var problem polygonProblem
+	if err := xml.Unmarshal(data, &problem); err != nil {
+		return nil, fmt.Errorf("problem.xml: %w", err)
+	}
+
+	var unmapped []string
+	report := func(format string, args ...interface{}) {
+		unmapped = append(unmapped, fmt.Sprintf(format, args...))
+	}
+
+	// Условие - на русском, если оно есть
+	statementLanguage := ""
+	for _, language := range []string{"russian", "english"} {
+		if r.exists(path.Join(dir, "statement-sections", language)) ||
+			r.exists(path.Join(dir, "statements", language, "problem-properties.json")) {
+			statementLanguage = language
+			break
+		}
+	}
+
+	base := models.Task{IsPublished: true}
+	for _, name := range problem.Names {
+		if base.Title == "" || name.Language == statementLanguage {
+			base.Title = name.Value
+		}
+	}
+	if base.Title == "" {
+		base.Title = problem.ShortName
+	}
+	if base.Title == "" {
+		return nil, fmt.Errorf("problem.xml: problem name is required")
+	}
+
+	if statementLanguage == "" {
+		report("Условие не найдено: в пакете нет statement-sections/<язык> или problem-properties.json")
+	} else if base.Description, err = r.readPolygonStatement(dir, statementLanguage, &unmapped); err != nil {
+		return nil, err
+	}
+
+	if problem.Judging.InputFile != "" || problem.Judging.OutputFile != "" {
+		report("Ввод и вывод через файлы (%s, %s) не поддерживаются: решения читают stdin и пишут в stdout",
+			problem.Judging.InputFile, problem.Judging.OutputFile)
+	}
+
+	var testset *polygonTestset
+	for i := range problem.Judging.Testsets {
+		if problem.Judging.Testsets[i].Name == "tests" {
+			testset = &problem.Judging.Testsets[i]
+		} else {
+			report("Набор тестов %s пропущен: импортируется только набор tests", problem.Judging.Testsets[i].Name)
+		}
+	}
+	var memoryLimitMB int64
+	if testset != nil {
+		if base.Tests, err = r.readPolygonTests(dir, testset, &unmapped); err != nil {
+			return nil, err
+		}
+		memoryLimitMB = testset.MemoryLimit >> 20
+	}
+
+	if checker := problem.Assets.Checker; checker != nil {
+		name := strings.TrimSuffix(strings.TrimPrefix(checker.Name, "std::"), ".cpp")
+		if config, ok := polygonCheckers[name]; ok && strings.HasPrefix(checker.Name, "std::") {
+			base.Checker = &config
+		} else {
+			base.Checker = &models.Checker{Type: models.CheckerTokens}
+			report("Чекер %s не перенесен: чекеры testlib не поддерживаются, ответы сравниваются по словам",
+				firstNonEmpty(checker.Name, checker.Source.Path))
+		}
+	}
+	if interactor := problem.Assets.Interactor; interactor != nil {
+		report("Интерактор %s не перенесен: интерактивные задачи не поддерживаются", interactor.Source.Path)
+	}
+	for _, validator := range problem.Assets.Validators {
+		report("Валидатор %s не перенесен: ввод тестов не проверяется", validator.Source.Path)
+	}
+
+	for _, solution := range problem.Assets.Solutions {
+		if solution.Tag != "main" {
+			continue
+		}
+		language, ok := solution.Source.language()
+		if !ok {
+			report("Главное решение %s (%s) не перенесено: язык не поддерживается", solution.Source.Path, solution.Source.Type)
+			break
+		}
+		code, err := r.readFile(path.Join(dir, solution.Source.Path))
+		if err != nil {
+			return nil, fmt.Errorf("main solution: %w", err)
+		}
+		base.ReferenceSolution = &models.ReferenceSolution{Code: string(code), Language: language}
+	}
+
+	return r.problemTasks(base, memoryLimitMB, unmapped)
+}
+
+// readPolygonStatement собирает условие в markdown из разделов в LaTeX
+func (r *packageReader) readPolygonStatement(dir, language string, report *[]string) (string, error) {
+	sections := make(map[string]string)
+	sectionsDir := path.Join(dir, "statement-sections", language)
+	if r.exists(sectionsDir) {
+		for _, section := range polygonStatementSections {
+			name := path.Join(sectionsDir, section.name)
+			if !r.exists(name) {
+				continue
+			}
+			content, err := r.readFile(name)
+			if err != nil {
+				return "", err
+			}
+			sections[section.property] = string(content)
+		}
+	} else {
+		data, err := r.readFile(path.Join(dir, "statements", language, "problem-properties.json"))
+		if err != nil {
+			return "", err
+		}
+		var properties map[string]interface{}
+		if err := json.Unmarshal(data, &properties); err != nil {
+			return "", fmt.Errorf("problem-properties.json: %w", err)
+		}
+		for _, section := range polygonStatementSections {
+			if text, ok := properties[section.property].(string); ok {
+				sections[section.property] = text
+			}
+		}
+	}
+
+	var parts []string
+	for _, section := range polygonStatementSections {
+		text := strings.TrimSpace(sections[section.property])
+		if text == "" {
+			continue
+		}
+		heading := section.english
+		if language == "russian" {
+			heading = section.russian
+		}
+		if heading != "" {
+			parts = append(parts, "## "+heading)
+		}
+		parts = append(parts, text)
+	}
+	return latexToMarkdown(strings.Join(parts, "\n\n"), report), nil
+}
+
+// readPolygonTests читает тесты набора. Примеры из условия открыты, остальные
+// тесты скрыты. Тест без файла ввода или ответа (пакет не полный) пропускается
+func (r *packageReader) readPolygonTests(dir string, testset *polygonTestset, report *[]string) ([]models.Test, error) {
+	var tests []models.Test
+	var missing []string
+	scored := false
+	for i, item := range testset.Tests {
+		number := i + 1
+		input := path.Join(dir, fmt.Sprintf(testset.InputPattern, number))
+		answer := path.Join(dir, fmt.Sprintf(testset.AnswerPattern, number))
+		if !r.exists(input) || !r.exists(answer) {
+			missing = append(missing, fmt.Sprint(number))
+			continue
+		}
+		scored = scored || item.Points != 0 || item.Group != ""
+
+		test := models.Test{
+			IsHidden: !item.Sample,
+			Timeout:  testset.TimeLimit,
+		}
+		if item.Method == "generated" && item.Cmd != "" {
+			test.Description = item.Cmd
+		}
+		var err error
+		if test.Input, err = r.readText(input); err != nil {
+			return nil, fmt.Errorf("test %d: %w", number, err)
+		}
+		if test.ExpectedOutput, err = r.readText(answer); err != nil {
+			return nil, fmt.Errorf("test %d: %w", number, err)
+		}
+		tests = append(tests, test)
+	}
+
+	if len(missing) > 0 {
+		*report = append(*report, fmt.Sprintf(
+			"Тесты %s пропущены: в пакете нет их ввода или ответа (нужен полный пакет Polygon)", strings.Join(missing, ", ")))
+	}
+	if scored {
+		*report = append(*report, "Баллы и группы тестов не перенесены: решение проверяется на всех тестах")
+	}
+	return tests, nil
+}
+
+// firstNonEmpty возвращает первую непустую строку
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
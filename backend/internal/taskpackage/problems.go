@@ -0,0 +1,148 @@
+package taskpackage
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"backend/internal/languages"
+	"backend/internal/models"
+)
+
+// Пакеты задач олимпиадных систем (Codeforces Polygon, Kattis/ICPC problem
+// package) переводятся в задачи платформы. То, что перенести нельзя (чекеры
+// testlib, интерактивность, лимиты памяти, баллы за группы), не прерывает
+// импорт, а попадает в отчет задачи Task.Unmapped
+
+// problemLanguages возвращает языки задачи из формата без языка задачи: из
+// Options.Languages, а без них - язык эталонного решения
+func (r *packageReader) problemLanguages(reference *models.ReferenceSolution) ([]*languages.Language, error) {
+	ids := r.options.Languages
+	if len(ids) == 0 && reference != nil {
+		ids = []string{reference.Language}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("task language is unknown: the package has no supported main solution, set languages")
+	}
+
+	langs := make([]*languages.Language, 0, len(ids))
+	for _, id := range ids {
+		lang, ok := languages.Lookup(strings.TrimSpace(id))
+		if !ok {
+			return nil, fmt.Errorf("unsupported language: %s", id)
+		}
+		langs = append(langs, lang)
+	}
+	return langs, nil
+}
+
+// problemTasks размножает задачу формата олимпиадной системы по языкам.
+// memoryLimitMB - лимит памяти задачи (0 - не задан): лимиты платформы задаются
+// языком, поэтому отличающийся лимит попадает в отчет
+func (r *packageReader) problemTasks(base models.Task, memoryLimitMB int64, unmapped []string) ([]Task, error) {
+	langs, err := r.problemLanguages(base.ReferenceSolution)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]Task, 0, len(langs))
+	for _, lang := range langs {
+		task := Task{Task: base, Unmapped: append([]string(nil), unmapped...)}
+		task.Language = lang.ID
+
+		if memoryLimitMB > 0 && memoryLimitMB != int64(lang.MemoryLimitMB) {
+			if lang.MemoryLimitMB > 0 {
+				task.Unmapped = append(task.Unmapped, fmt.Sprintf(
+					"Лимит памяти %d МБ не перенесен: решениям на %s доступно %d МБ", memoryLimitMB, lang.Name, lang.MemoryLimitMB))
+			} else {
+				task.Unmapped = append(task.Unmapped, fmt.Sprintf(
+					"Лимит памяти %d МБ не перенесен: лимит памяти задается языком", memoryLimitMB))
+			}
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// extraExtensions - расширения исходников, которых нет среди файлов языков по умолчанию
+var extraExtensions = map[string]string{
+	".cc":  "cpp",
+	".cxx": "cpp",
+	".c++": "cpp",
+	".py3": "python",
+}
+
+// languageByFile определяет язык исходника по расширению имени файла
+func languageByFile(name string) (string, bool) {
+	ext := strings.ToLower(path.Ext(name))
+	if id, ok := extraExtensions[ext]; ok {
+		return id, true
+	}
+	for _, lang := range languages.All() {
+		if ext != "" && path.Ext(lang.FileName) == ext {
+			return lang.ID, true
+		}
+	}
+	return "", false
+}
+
+var (
+	latexBoldRe     = regexp.MustCompile(`\\(?:textbf|bf)\{([^{}]*)\}`)
+	latexItalicRe   = regexp.MustCompile(`\\(?:textit|emph|it)\{([^{}]*)\}`)
+	latexMonoRe     = regexp.MustCompile(`\\(?:texttt|tt|t)\{([^{}]*)\}`)
+	latexSectionRe  = regexp.MustCompile(`\\(?:sub)*section\*?\{([^{}]*)\}`)
+	latexNameRe     = regexp.MustCompile(`\\problemname\{([^{}]*)\}`)
+	latexEnvRe      = regexp.MustCompile(`\\(?:begin|end)\{(?:itemize|enumerate|center|problem)\}(?:\{[^{}]*\})*`)
+	latexItemRe     = regexp.MustCompile(`(?m)^[ \t]*\\item[ \t]*`)
+	latexImageRe    = regexp.MustCompile(`\\(?:includegraphics|illustration)`)
+	latexMathRe     = regexp.MustCompile(`\$\$[^$]*\$\$|\$[^$]*\$`)
+	latexCommandRe  = regexp.MustCompile(`\\[a-zA-Z]+`)
+	latexBlankRe    = regexp.MustCompile(`\n{3,}`)
+	latexReplacer   = strings.NewReplacer("$$$$$$", "$$", "$$$", "$", "---", "—", "<<", "«", ">>", "»", "~", " ", `\ldots`, "…", `\dots`, "…")
+	latexLineBreaks = strings.NewReplacer("\\\\\n", "\n", `\\`, "\n")
+)
+
+// latexToMarkdown переводит в markdown условие в LaTeX: оформление текста,
+// заголовки и списки. Формулы остаются в $...$, остальная разметка - как есть,
+// о ней сообщает report
+func latexToMarkdown(text string, report *[]string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = latexReplacer.Replace(text)
+	text = latexNameRe.ReplaceAllString(text, "")
+	text = latexSectionRe.ReplaceAllString(text, "## $1")
+	text = latexBoldRe.ReplaceAllString(text, "**$1**")
+	text = latexItalicRe.ReplaceAllString(text, "*$1*")
+	text = latexMonoRe.ReplaceAllString(text, "`$1`")
+	text = latexEnvRe.ReplaceAllString(text, "")
+	text = latexItemRe.ReplaceAllString(text, "- ")
+
+	// Переводы строк \\ внутри формул - часть формулы
+	var b strings.Builder
+	last := 0
+	for _, loc := range latexMathRe.FindAllStringIndex(text, -1) {
+		b.WriteString(latexLineBreaks.Replace(text[last:loc[0]]))
+		b.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(latexLineBreaks.Replace(text[last:]))
+	text = strings.TrimSpace(latexBlankRe.ReplaceAllString(b.String(), "\n\n"))
+
+	if latexImageRe.MatchString(text) {
+		*report = append(*report, "Изображения из условия не перенесены")
+	} else if latexCommandRe.MatchString(latexMathRe.ReplaceAllString(text, "")) {
+		*report = append(*report, "В условии осталась разметка LaTeX, которую не удалось перевести в markdown")
+	}
+	return text
+}
+
+// hasLetters сообщает, есть ли в ответах тестов буквы
+func hasLetters(tests []models.Test) bool {
+	for _, test := range tests {
+		if strings.IndexFunc(test.ExpectedOutput, unicode.IsLetter) >= 0 {
+			return true
+		}
+	}
+	return false
+}
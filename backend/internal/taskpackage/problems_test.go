@@ -0,0 +1,675 @@
+package taskpackage
+
+import (
+	"strings"
+	"testing"
+
+	"backend/internal/models"
+)
+
+// withFiles возвращает копию файлов пакета с изменениями; пустое содержимое удаляет файл
+func withFiles(base, changes map[string]string) map[string]string {
+	files := make(map[string]string, len(base)+len(changes))
+	for name, content := range base {
+		files[name] = content
+	}
+	for name, content := range changes {
+		if content == "" {
+			delete(files, name)
+		} else {
+			files[name] = content
+		}
+	}
+	return files
+}
+
+// checkUnmapped проверяет отчет о неперенесенном: по записи на каждую ожидаемую часть текста
+func checkUnmapped(t *testing.T, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("unmapped = %q, want %d entries containing %q", got, len(want), want)
+	}
+	for i := range want {
+		if !strings.Contains(got[i], want[i]) {
+			t.Errorf("unmapped[%d] = %q, want it to contain %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLanguageByFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		ok       bool
+	}{
+		{"main.cpp", "cpp", true},
+		{"sol.cc", "cpp", true},
+		{"SOL.CXX", "cpp", true},
+		{"sol.c++", "cpp", true},
+		{"sol.py", "python", true},
+		{"sol.py3", "python", true},
+		{"Main.java", "java", true},
+		{"solutions/main.rs", "rust", true},
+		{"sol.pas", "", false},
+		{"Makefile", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			language, ok := languageByFile(tt.name)
+			if language != tt.language || ok != tt.ok {
+				t.Errorf("languageByFile(%q) = %q, %v, want %q, %v", tt.name, language, ok, tt.language, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPolygonSourceLanguage(t *testing.T) {
+	tests := []struct {
+		source   polygonSource
+		language string
+		ok       bool
+	}{
+		{polygonSource{Path: "solutions/a.cpp", Type: "cpp.g++17"}, "cpp", true},
+		{polygonSource{Path: "solutions/a.c", Type: "c.gcc"}, "c", true},
+		{polygonSource{Path: "solutions/a.py", Type: "python.3"}, "python", true},
+		{polygonSource{Path: "solutions/a.py", Type: "python.pypy3"}, "python", true},
+		{polygonSource{Path: "solutions/a.py", Type: "python.2"}, "", false},
+		{polygonSource{Path: "solutions/A.java", Type: "java21"}, "java", true},
+		{polygonSource{Path: "solutions/a.cs", Type: "csharp.mono"}, "csharp", true},
+		{polygonSource{Path: "solutions/a.pas", Type: "pascal.fpc"}, "", false},
+		{polygonSource{Path: "solutions/a.go"}, "go", true},
+		{polygonSource{Path: "solutions/a.dpr"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source.Type+" "+tt.source.Path, func(t *testing.T) {
+			language, ok := tt.source.language()
+			if language != tt.language || ok != tt.ok {
+				t.Errorf("language() = %q, %v, want %q, %v", language, ok, tt.language, tt.ok)
+			}
+		})
+	}
+}
+
+func TestLatexToMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		latex    string
+		markdown string
+		unmapped []string
+	}{
+		{"formatting", `Даны \textbf{два} числа, \emph{целые}, в файле \texttt{input}.`,
+			"Даны **два** числа, *целые*, в файле `input`.", nil},
+		{"sections", "\\section*{Ввод}\nЧисла\n\\subsection{Пример}", "## Ввод\nЧисла\n## Пример", nil},
+		{"lists", "\\begin{itemize}\n\\item один\n  \\item два\n\\end{itemize}", "- один\n- два", nil},
+		{"typography", "<<да>> --- нет~и\\ldots", "«да» — нет и…", nil},
+		{"polygon math", "$$$a \\le b$$$ и $$$$$$\\sum x$$$$$$", "$a \\le b$ и $$\\sum x$$", nil},
+		{"line breaks outside math", "$a \\\\ b$ строка\\\\\nдалее", "$a \\\\ b$ строка\nдалее", nil},
+		{"problem name", "\\problemname{Сумма}\n\nТекст", "Текст", nil},
+		{"blank lines", "a\r\n\r\n\r\n\r\nb", "a\n\nb", nil},
+		{"image", "Рисунок:\n\\includegraphics{pic.png}", "Рисунок:\n\\includegraphics{pic.png}", []string{"Изображения"}},
+		{"unknown command", "\\begin{tabular}{cc} 1 & 2 \\end{tabular}", "\\begin{tabular}{cc} 1 & 2 \\end{tabular}", []string{"разметка LaTeX"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var unmapped []string
+			if got := latexToMarkdown(tt.latex, &unmapped); got != tt.markdown {
+				t.Errorf("latexToMarkdown(%q) = %q, want %q", tt.latex, got, tt.markdown)
+			}
+			checkUnmapped(t, unmapped, tt.unmapped)
+		})
+	}
+}
+
+func TestKattisName(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"string", " Hello ", "Hello"},
+		{"russian first", map[string]interface{}{"en": "Hello", "ru": "Привет"}, "Привет"},
+		{"english", map[string]interface{}{"de": "Hallo", "en": "Hello"}, "Hello"},
+		{"other language", map[string]interface{}{"fr": "Bonjour", "de": "Hallo"}, "Hallo"},
+		{"no name", nil, ""},
+		{"not a string", 42, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kattisName(tt.value); got != tt.want {
+				t.Errorf("kattisName(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKattisChecker(t *testing.T) {
+	numbers := []models.Test{{ExpectedOutput: "1.5 2"}}
+	words := []models.Test{{ExpectedOutput: "1"}, {ExpectedOutput: "YES"}}
+
+	tests := []struct {
+		name     string
+		flags    string
+		tests    []models.Test
+		want     models.Checker
+		unmapped []string
+	}{
+		{"default", "", numbers, models.Checker{Type: models.CheckerTokens}, nil},
+		{"float tolerance", "float_tolerance 1e-6", numbers,
+			models.Checker{Type: models.CheckerFloat, AbsEpsilon: 1e-6, RelEpsilon: 1e-6}, nil},
+		{"absolute tolerance", "float_absolute_tolerance 0.01", numbers,
+			models.Checker{Type: models.CheckerFloat, AbsEpsilon: 0.01}, nil},
+		{"both tolerances", "float_relative_tolerance 1e-4 float_absolute_tolerance 1e-3", numbers,
+			models.Checker{Type: models.CheckerFloat, AbsEpsilon: 1e-3, RelEpsilon: 1e-4}, nil},
+		{"space change sensitive", "space_change_sensitive", numbers, models.Checker{Type: models.CheckerExact}, nil},
+		{"space change sensitive floats", "space_change_sensitive float_tolerance 1e-6", numbers,
+			models.Checker{Type: models.CheckerFloat, AbsEpsilon: 1e-6, RelEpsilon: 1e-6}, []string{"space_change_sensitive"}},
+		{"tolerance without value", "float_tolerance", numbers,
+			models.Checker{Type: models.CheckerTokens}, []string{"float_tolerance без значения"}},
+		{"invalid tolerance", "float_tolerance abc case_sensitive", numbers,
+			models.Checker{Type: models.CheckerTokens}, []string{"float_tolerance abc пропущен"}},
+		{"unknown flag", "strict_order", numbers,
+			models.Checker{Type: models.CheckerTokens}, []string{"strict_order не поддерживается"}},
+		{"letters in answers", "", words, models.Checker{Type: models.CheckerTokens}, []string{"Регистр букв"}},
+		{"case sensitive", "case_sensitive", words, models.Checker{Type: models.CheckerTokens}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var unmapped []string
+			got := kattisChecker(tt.flags, tt.tests, &unmapped)
+			if *got != tt.want {
+				t.Errorf("kattisChecker(%q) = %+v, want %+v", tt.flags, *got, tt.want)
+			}
+			checkUnmapped(t, unmapped, tt.unmapped)
+		})
+	}
+}
+
+// kattisFiles - пакет Kattis, который переносится без потерь
+var kattisFiles = map[string]string{
+	"hello/problem.yaml":                  "name:\n  en: Sum\n  ru: Сумма\nlimits:\n  time_limit: 1.5\n",
+	"hello/statement/problem.en.md":       "Add numbers",
+	"hello/statement/problem.ru.md":       "Сложите числа\n",
+	"hello/data/sample/1.in":              "1 2\n",
+	"hello/data/sample/1.ans":             "3\n",
+	"hello/data/secret/big.in":            "100 200",
+	"hello/data/secret/big.ans":           "300",
+	"hello/submissions/accepted/sum.py":   "print(sum(map(int, input().split())))",
+	"hello/submissions/wrong_answer/a.py": "print(0)",
+}
+
+func TestReadKattis(t *testing.T) {
+	tests := []struct {
+		name      string
+		changes   map[string]string
+		options   Options
+		title     string
+		languages []string
+		timeout   int
+		tests     int
+		checker   models.Checker
+		unmapped  []string
+		err       string
+	}{
+		{
+			name:      "full package",
+			title:     "Сумма",
+			languages: []string{"python"},
+			timeout:   1500,
+			tests:     2,
+			checker:   models.Checker{Type: models.CheckerTokens},
+		},
+		{
+			name:      "languages option",
+			options:   Options{Languages: []string{"cpp", "python"}},
+			title:     "Сумма",
+			languages: []string{"cpp", "python"},
+			timeout:   1500,
+			tests:     2,
+			checker:   models.Checker{Type: models.CheckerTokens},
+		},
+		{
+			name: "time limit file",
+			changes: map[string]string{
+				"hello/problem.yaml": "name: Sum\n",
+				"hello/.timelimit":   "2.5\n",
+			},
+			title:     "Sum",
+			languages: []string{"python"},
+			timeout:   2500,
+			tests:     2,
+			checker:   models.Checker{Type: models.CheckerTokens},
+		},
+		{
+			name:      "no time limit",
+			changes:   map[string]string{"hello/problem.yaml": "name: Sum\n"},
+			title:     "Sum",
+			languages: []string{"python"},
+			tests:     2,
+			checker:   models.Checker{Type: models.CheckerTokens},
+			unmapped:  []string{"Лимит времени не задан"},
+		},
+		{
+			name:      "memory limit",
+			changes:   map[string]string{"hello/problem.yaml": "name: Sum\nlimits:\n  time_limit: 1\n  memory: 256\n"},
+			title:     "Sum",
+			languages: []string{"python"},
+			timeout:   1000,
+			tests:     2,
+			checker:   models.Checker{Type: models.CheckerTokens},
+			unmapped:  []string{"Лимит памяти 256 МБ не перенесен: решениям на Python доступно 100 МБ"},
+		},
+		{
+			name: "custom validation",
+			changes: map[string]string{
+				"hello/problem.yaml": "name: Sum\nlimits:\n  time_limit: 1\nvalidation: custom interactive\n",
+			},
+			title:     "Sum",
+			languages: []string{"python"},
+			timeout:   1000,
+			tests:     2,
+			checker:   models.Checker{Type: models.CheckerTokens},
+			unmapped:  []string{"output_validators", "Интерактивные задачи"},
+		},
+		{
+			name: "scoring problem with groups",
+			changes: map[string]string{
+				"hello/problem.yaml":                  "name: Sum\ntype: scoring\nlimits:\n  time_limit: 1\nvalidator_flags: float_tolerance 1e-6\n",
+				"hello/data/secret/group1/2.in":       "2 2",
+				"hello/data/secret/group1/2.ans":      "4",
+				"hello/statement/problem.en.md":       "",
+				"hello/statement/problem.ru.md":       "",
+				"hello/problem_statement/problem.tex": "\\problemname{Сумма}\nСложите \\textbf{два} числа",
+			},
+			title:     "Sum",
+			languages: []string{"python"},
+			timeout:   1000,
+			tests:     3,
+			checker:   models.Checker{Type: models.CheckerFloat, AbsEpsilon: 1e-6, RelEpsilon: 1e-6},
+			unmapped:  []string{"Группы тестов", "Тип задачи scoring"},
+		},
+		{
+			name: "name from statement",
+			changes: map[string]string{
+				"hello/problem.yaml":                  "limits:\n  time_limit: 1\n",
+				"hello/statement/problem.en.md":       "",
+				"hello/statement/problem.ru.md":       "",
+				"hello/problem_statement/problem.tex": "\\problemname{Сумма}\n\\includegraphics{sum.png}",
+			},
+			title:     "Сумма",
+			languages: []string{"python"},
+			timeout:   1000,
+			tests:     2,
+			checker:   models.Checker{Type: models.CheckerTokens},
+			unmapped:  []string{"Изображения"},
+		},
+		{
+			name: "no statement and no name",
+			changes: map[string]string{
+				"hello/problem.yaml":            "limits:\n  time_limit: 1\n",
+				"hello/statement/problem.en.md": "",
+				"hello/statement/problem.ru.md": "",
+			},
+			title:     "hello",
+			languages: []string{"python"},
+			timeout:   1000,
+			tests:     2,
+			checker:   models.Checker{Type: models.CheckerTokens},
+			unmapped:  []string{"Условие не найдено"},
+		},
+		{
+			name: "unsupported accepted solution",
+			changes: map[string]string{
+				"hello/submissions/accepted/sum.py": "",
+				"hello/submissions/accepted/sum.pl": "print 3",
+			},
+			options:   Options{Languages: []string{"go"}},
+			title:     "Сумма",
+			languages: []string{"go"},
+			timeout:   1500,
+			tests:     2,
+			checker:   models.Checker{Type: models.CheckerTokens},
+			unmapped:  []string{"Принятые решения не перенесены"},
+		},
+		{
+			name:    "no language",
+			changes: map[string]string{"hello/submissions/accepted/sum.py": ""},
+			err:     "hello: task language is unknown",
+		},
+		{
+			name:    "unsupported language option",
+			options: Options{Languages: []string{"cobol"}},
+			err:     "hello: unsupported language: cobol",
+		},
+		{
+			name:    "missing answer",
+			changes: map[string]string{"hello/data/secret/big.ans": ""},
+			err:     "hello: hello/data/secret/big.in: no answer file",
+		},
+		{
+			name:    "invalid time limit file",
+			changes: map[string]string{"hello/problem.yaml": "name: Sum\n", "hello/.timelimit": "fast"},
+			err:     "hello: .timelimit:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := ReadFS(packageFS(withFiles(kattisFiles, tt.changes)), tt.options)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("ReadFS() error = %v, want error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tasks) != len(tt.languages) {
+				t.Fatalf("read %d tasks, want %d", len(tasks), len(tt.languages))
+			}
+
+			for i, task := range tasks {
+				if task.Language != tt.languages[i] {
+					t.Errorf("task %d language = %q, want %q", i+1, task.Language, tt.languages[i])
+				}
+				if task.Title != tt.title || !task.IsPublished {
+					t.Errorf("title = %q, published %v, want %q", task.Title, task.IsPublished, tt.title)
+				}
+				if len(task.Tests) != tt.tests {
+					t.Fatalf("%d tests, want %d", len(task.Tests), tt.tests)
+				}
+				for j, test := range task.Tests {
+					if test.Timeout != tt.timeout {
+						t.Errorf("test %d timeout = %d, want %d", j+1, test.Timeout, tt.timeout)
+					}
+					// Примеры открыты, остальные тесты скрыты
+					if test.IsHidden != (j > 0) {
+						t.Errorf("test %d hidden = %v", j+1, test.IsHidden)
+					}
+				}
+				if task.Checker == nil || *task.Checker != tt.checker {
+					t.Errorf("checker = %+v, want %+v", task.Checker, tt.checker)
+				}
+				if task.Language == "python" {
+					checkUnmapped(t, task.Unmapped, tt.unmapped)
+				}
+			}
+		})
+	}
+}
+
+func TestReadKattisTask(t *testing.T) {
+	tasks, err := ReadFS(packageFS(kattisFiles), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := tasks[0]
+	if task.Description != "Сложите числа" {
+		t.Errorf("description = %q, want the russian statement", task.Description)
+	}
+	want := []models.Test{
+		{Input: "1 2", ExpectedOutput: "3", Description: "1", Timeout: 1500},
+		{Input: "100 200", ExpectedOutput: "300", Description: "big", IsHidden: true, Timeout: 1500},
+	}
+	for i := range want {
+		if task.Tests[i].Input != want[i].Input || task.Tests[i].ExpectedOutput != want[i].ExpectedOutput ||
+			task.Tests[i].Description != want[i].Description {
+			t.Errorf("test %d = %+v, want %+v", i+1, task.Tests[i], want[i])
+		}
+	}
+	if task.ReferenceSolution == nil || task.ReferenceSolution.Language != "python" ||
+		task.ReferenceSolution.Code != kattisFiles["hello/submissions/accepted/sum.py"] {
+		t.Errorf("reference solution = %+v", task.ReferenceSolution)
+	}
+}
+
+// polygonXML - problem.xml пакета Polygon, который переносится без потерь
+const polygonXML = `<?xml version="1.0" encoding="utf-8"?>
+<problem short-name="a-plus-b" revision="3">
+  <names>
+    <name language="english" value="A+B"/>
+    <name language="russian" value="Сумма"/>
+  </names>
+  <judging input-file="" output-file="">
+    <testset name="tests">
+      <time-limit>2000</time-limit>
+      <memory-limit>104857600</memory-limit>
+      <input-path-pattern>tests/%02d</input-path-pattern>
+      <answer-path-pattern>tests/%02d.a</answer-path-pattern>
+      <tests>
+        <test method="manual" sample="true"/>
+        <test method="generated" cmd="gen 10"/>
+      </tests>
+    </testset>
+  </judging>
+  <assets>
+    <checker name="std::ncmp.cpp" type="testlib">
+      <source path="files/check.cpp" type="cpp.g++17"/>
+    </checker>
+    <solutions>
+      <solution tag="wrong-answer"><source path="solutions/wa.py" type="python.3"/></solution>
+      <solution tag="main"><source path="solutions/main.cpp" type="cpp.g++17"/></solution>
+    </solutions>
+  </assets>
+</problem>
+`
+
+// polygonFiles - файлы пакета Polygon с problem.xml polygonXML
+var polygonFiles = map[string]string{
+	"sum/problem.xml": polygonXML,
+	"sum/statement-sections/russian/legend.tex": "Даны \\textbf{два} числа $a$ и $b$.",
+	"sum/statement-sections/russian/input.tex":  "Два числа.",
+	"sum/statement-sections/russian/notes.tex":  "",
+	"sum/statement-sections/english/legend.tex": "Given two numbers.",
+	"sum/tests/01":           "1 2\n",
+	"sum/tests/01.a":         "3\n",
+	"sum/tests/02":           "10 20",
+	"sum/tests/02.a":         "30",
+	"sum/solutions/main.cpp": "int main() {}",
+	"sum/solutions/wa.py":    "print(0)",
+	"sum/statements/russian/problem-properties.json": `{"legend": "ignored"}`,
+}
+
+func TestReadPolygon(t *testing.T) {
+	tests := []struct {
+		name      string
+		xml       []string // Замены в polygonXML: старый текст, новый
+		changes   map[string]string
+		options   Options
+		title     string
+		languages []string
+		tests     int
+		checker   *models.Checker
+		unmapped  []string
+		err       string
+	}{
+		{
+			name:      "full package",
+			title:     "Сумма",
+			languages: []string{"cpp"},
+			tests:     2,
+			checker:   &models.Checker{Type: models.CheckerTokens},
+		},
+		{
+			name:      "float checker and languages option",
+			xml:       []string{"std::ncmp.cpp", "std::rcmp6.cpp"},
+			options:   Options{Languages: []string{"python", "cpp"}},
+			title:     "Сумма",
+			languages: []string{"python", "cpp"},
+			tests:     2,
+			checker:   &models.Checker{Type: models.CheckerFloat, AbsEpsilon: 1e-6, RelEpsilon: 1e-6},
+		},
+		{
+			name: "english statement from properties",
+			changes: map[string]string{
+				"sum/statement-sections/russian/legend.tex":      "",
+				"sum/statement-sections/russian/input.tex":       "",
+				"sum/statement-sections/russian/notes.tex":       "",
+				"sum/statements/russian/problem-properties.json": "",
+				"sum/statement-sections/english/legend.tex":      "",
+				"sum/statements/english/problem-properties.json": `{"legend": "Add.", "input": "Two numbers."}`,
+			},
+			title:     "A+B",
+			languages: []string{"cpp"},
+			tests:     2,
+			checker:   &models.Checker{Type: models.CheckerTokens},
+		},
+		{
+			name: "no statement and no names",
+			xml:  []string{`<name language="english" value="A+B"/>`, "", `<name language="russian" value="Сумма"/>`, ""},
+			changes: map[string]string{
+				"sum/statement-sections/russian/legend.tex":      "",
+				"sum/statement-sections/russian/input.tex":       "",
+				"sum/statement-sections/russian/notes.tex":       "",
+				"sum/statement-sections/english/legend.tex":      "",
+				"sum/statements/russian/problem-properties.json": "",
+			},
+			title:     "a-plus-b",
+			languages: []string{"cpp"},
+			tests:     2,
+			checker:   &models.Checker{Type: models.CheckerTokens},
+			unmapped:  []string{"Условие не найдено"},
+		},
+		{
+			name: "unsupported features",
+			xml: []string{
+				`input-file=""`, `input-file="input.txt"`,
+				"</testset>", "</testset><testset name=\"pretests\"></testset>",
+				"std::ncmp.cpp", "check.cpp",
+				"<solutions>", `<interactor><source path="files/interactor.cpp" type="cpp.g++17"/></interactor>` +
+					`<validators><validator><source path="files/val.cpp" type="cpp.g++17"/></validator></validators><solutions>`,
+			},
+			title:     "Сумма",
+			languages: []string{"cpp"},
+			tests:     2,
+			checker:   &models.Checker{Type: models.CheckerTokens},
+			unmapped: []string{
+				"Ввод и вывод через файлы (input.txt, )",
+				"Набор тестов pretests пропущен",
+				"Чекер check.cpp не перенесен",
+				"Интерактор files/interactor.cpp",
+				"Валидатор files/val.cpp",
+			},
+		},
+		{
+			name:      "scored and missing tests",
+			xml:       []string{`<test method="generated" cmd="gen 10"/>`, `<test points="5" group="1"/><test points="5" group="1"/>`},
+			changes:   map[string]string{"sum/tests/03": "5 5"},
+			title:     "Сумма",
+			languages: []string{"cpp"},
+			tests:     2,
+			checker:   &models.Checker{Type: models.CheckerTokens},
+			unmapped:  []string{"Тесты 3 пропущены", "Баллы и группы тестов"},
+		},
+		{
+			name:      "memory limit",
+			xml:       []string{"104857600", "268435456"},
+			title:     "Сумма",
+			languages: []string{"cpp"},
+			tests:     2,
+			checker:   &models.Checker{Type: models.CheckerTokens},
+			unmapped:  []string{"Лимит памяти 256 МБ не перенесен: решениям на C++ доступно 100 МБ"},
+		},
+		{
+			name:      "unsupported main solution",
+			xml:       []string{`path="solutions/main.cpp" type="cpp.g++17"`, `path="solutions/main.pas" type="pascal.fpc"`},
+			options:   Options{Languages: []string{"cpp"}},
+			title:     "Сумма",
+			languages: []string{"cpp"},
+			tests:     2,
+			checker:   &models.Checker{Type: models.CheckerTokens},
+			unmapped:  []string{"Главное решение solutions/main.pas (pascal.fpc)"},
+		},
+		{
+			name: "no language",
+			xml:  []string{`path="solutions/main.cpp" type="cpp.g++17"`, `path="solutions/main.pas" type="pascal.fpc"`},
+			err:  "sum: task language is unknown",
+		},
+		{
+			name: "no name",
+			xml:  []string{`short-name="a-plus-b"`, "", `<name language="english" value="A+B"/>`, "", `<name language="russian" value="Сумма"/>`, ""},
+			err:  "sum: problem.xml: problem name is required",
+		},
+		{
+			name:    "missing main solution file",
+			changes: map[string]string{"sum/solutions/main.cpp": ""},
+			err:     "sum: main solution:",
+		},
+		{
+			name: "invalid xml",
+			xml:  []string{"</problem>", ""},
+			err:  "sum: problem.xml:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := map[string]string{"sum/problem.xml": strings.NewReplacer(tt.xml...).Replace(polygonXML)}
+			for name, content := range tt.changes {
+				changes[name] = content
+			}
+			tasks, err := ReadFS(packageFS(withFiles(polygonFiles, changes)), tt.options)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("ReadFS() error = %v, want error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tasks) != len(tt.languages) {
+				t.Fatalf("read %d tasks, want %d", len(tasks), len(tt.languages))
+			}
+
+			for i, task := range tasks {
+				if task.Language != tt.languages[i] {
+					t.Errorf("task %d language = %q, want %q", i+1, task.Language, tt.languages[i])
+				}
+				if task.Title != tt.title || !task.IsPublished {
+					t.Errorf("title = %q, published %v, want %q", task.Title, task.IsPublished, tt.title)
+				}
+				if len(task.Tests) != tt.tests {
+					t.Fatalf("%d tests, want %d", len(task.Tests), tt.tests)
+				}
+				if task.Checker == nil || *task.Checker != *tt.checker {
+					t.Errorf("checker = %+v, want %+v", task.Checker, tt.checker)
+				}
+				if task.Language == "cpp" {
+					checkUnmapped(t, task.Unmapped, tt.unmapped)
+				}
+			}
+		})
+	}
+}
+
+func TestReadPolygonTask(t *testing.T) {
+	tasks, err := ReadFS(packageFS(polygonFiles), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := tasks[0]
+	if want := "Даны **два** числа $a$ и $b$.\n\n## Входные данные\n\nДва числа."; task.Description != want {
+		t.Errorf("description = %q, want %q", task.Description, want)
+	}
+	want := []models.Test{
+		{Input: "1 2", ExpectedOutput: "3", Timeout: 2000},
+		{Input: "10 20", ExpectedOutput: "30", Description: "gen 10", IsHidden: true, Timeout: 2000},
+	}
+	for i := range want {
+		if task.Tests[i].Input != want[i].Input || task.Tests[i].ExpectedOutput != want[i].ExpectedOutput ||
+			task.Tests[i].Description != want[i].Description || task.Tests[i].IsHidden != want[i].IsHidden ||
+			task.Tests[i].Timeout != want[i].Timeout {
+			t.Errorf("test %d = %+v, want %+v", i+1, task.Tests[i], want[i])
+		}
+	}
+	if task.ReferenceSolution == nil || task.ReferenceSolution.Language != "cpp" ||
+		task.ReferenceSolution.Code != "int main() {}" {
+		t.Errorf("reference solution = %+v", task.ReferenceSolution)
+	}
+}
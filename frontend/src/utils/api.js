@@ -302,6 +302,10 @@ export const api = {
         body: JSON.stringify(requestData)
       })
       
+      // Сервер перегружен - в ответе сообщение для пользователя
+      if (response.status === 503 || response.status === 429) {
+        return await response.json()
+      }
       if (!response.ok) {
         throw new Error(`HTTP error! status: ${response.status}`)
       }
@@ -317,6 +321,119 @@ export const api = {
     }
   },
 
+  /**
+   * Выполнение кода с выводом в реальном времени (Server-Sent Events).
+   * onOutput(stream, text) вызывается для каждого фрагмента stdout/stderr,
+   * onQueued(position) - пока запуск ждет в очереди сервера,
+   * результат - данные события exit (или error)
+   */
+  async executeCodeStream(requestData, onOutput, onQueued) {
+    try {
+      const response = await fetch(`${API_BASE}/execute/stream`, {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify(requestData)
+      })
+
+      if (!response.ok || !response.body) {
+        throw new Error(`HTTP error! status: ${response.status}`)
+      }
+
+      const reader = response.body.getReader()
+      const decoder = new TextDecoder()
+      let buffer = ''
+      let result = null
+
+      while (true) {
+        const { value, done } = await reader.read()
+        if (done) break
+        buffer += decoder.decode(value, { stream: true })
+
+        // События разделены пустой строкой
+        let boundary
+        while ((boundary = buffer.indexOf('\n\n')) >= 0) {
+          const block = buffer.slice(0, boundary)
+          buffer = buffer.slice(boundary + 2)
+
+          let event = 'message'
+          let data = ''
+          for (const line of block.split('\n')) {
+            if (line.startsWith('event: ')) event = line.slice(7)
+            else if (line.startsWith('data: ')) data += line.slice(6)
+          }
+          if (!data) continue
+
+          const payload = JSON.parse(data)
+          if (event === 'stdout' || event === 'stderr') {
+            onOutput?.(event, payload.data)
+          } else if (event === 'queued') {
+            onQueued?.(payload.position)
+          } else if (event === 'exit' || event === 'error') {
+            result = payload
+          }
+        }
+      }
+
+      return result || {
+        success: false,
+        message: 'Соединение закрыто до завершения программы'
+      }
+    } catch (error) {
+      console.error('API Execute stream error:', error)
+      return {
+        success: false,
+        message: `Connection error: ${error.message}`
+      }
+    }
+  },
+
+  /**
+   * Интерактивный запуск кода через WebSocket: ввод передается во время работы программы.
+   * onOutput(stream, text) вызывается для каждого фрагмента stdout/stderr,
+   * onQueued(position) - пока запуск ждет в очереди сервера,
+   * onExit(result) - один раз с данными сообщения exit или error.
+   * Возвращает объект с методами send(line), eof() и kill()
+   */
+  runInteractive({ code, language }, { onOutput, onQueued, onExit }) {
+    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
+    const socket = new WebSocket(`${protocol}//${window.location.host}${API_BASE}/execute/interactive`)
+    let finished = false
+
+    const finish = (result) => {
+      if (!finished) {
+        finished = true
+        onExit?.(result)
+      }
+    }
+    const send = (message) => {
+      if (socket.readyState === WebSocket.OPEN) {
+        socket.send(JSON.stringify(message))
+      }
+    }
+
+    socket.onopen = () => send({ type: 'start', code, language })
+    socket.onmessage = (event) => {
+      const message = JSON.parse(event.data)
+      if (message.type === 'stdout' || message.type === 'stderr') {
+        onOutput?.(message.type, message.data)
+      } else if (message.type === 'queued') {
+        onQueued?.(message.position)
+      } else if (message.type === 'exit' || message.type === 'error') {
+        finish(message)
+      }
+    }
+    socket.onerror = () => finish({ success: false, message: 'Ошибка соединения' })
+    socket.onclose = () => finish({ success: false, message: 'Соединение закрыто до завершения программы' })
+
+    return {
+      send: (line) => send({ type: 'stdin', data: `${line}\n` }),
+      eof: () => send({ type: 'eof' }),
+      kill: () => send({ type: 'kill' })
+    }
+  },
+
   /**
    * Проверка решения задачи
    */
@@ -338,6 +455,10 @@ export const api = {
         body: JSON.stringify(requestData)
       })
       
+      // Сервер перегружен - в ответе сообщение для пользователя
+      if (response.status === 503 || response.status === 429) {
+        return await response.json()
+      }
       if (!response.ok) {
         throw new Error(`HTTP error! status: ${response.status}`)
       }